@@ -1,6 +1,7 @@
 package appcli
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"sort"
@@ -9,6 +10,7 @@ import (
 	"github.com/urfave/cli"
 
 	"github.com/rliebz/tusk/runner"
+	"github.com/rliebz/tusk/ui"
 )
 
 // newBaseApp creates a basic cli.App with top-level flags.
@@ -26,10 +28,54 @@ func newBaseApp() *cli.App {
 			Name:  "h, help",
 			Usage: "Show help and exit",
 		},
+		cli.BoolFlag{
+			Name:  "allow-network",
+			Usage: "Allow option values to be read from a url",
+		},
+		cli.BoolFlag{
+			Name:  "artifacts",
+			Usage: "Print the artifacts a task produces without running it",
+		},
+		cli.StringFlag{
+			Name:  "capture-output",
+			Usage: "Save a copy of all output to a `file`",
+		},
+		cli.BoolFlag{
+			Name:  "check",
+			Usage: "Evaluate conditions and options without running commands",
+		},
+		cli.StringFlag{
+			Name:  "docs",
+			Usage: "Print documentation for all tasks in a `format` (markdown, json)",
+		},
+		cli.BoolFlag{
+			Name:  "env-dump-on-failure",
+			Usage: "Print the environment of commands that fail",
+		},
+		cli.StringFlag{
+			Name:  "explain-option",
+			Usage: "Print how the option `name` gets its value for a task",
+		},
+		cli.StringFlag{
+			Name:  "export-options",
+			Usage: "Write the option values for a task to an env-`file` without running it",
+		},
 		cli.StringFlag{
 			Name:  "f, file",
 			Usage: "Set `file` to use as the config file",
 		},
+		cli.StringFlag{
+			Name:  "fail-on",
+			Usage: "Treat a comma-separated `list` of warning categories as failures (budget, duplicate-task, line-buffering, undeclared-variable, unknown-field)",
+		},
+		cli.BoolFlag{
+			Name:  "fail-on-budget",
+			Usage: "Fail tasks that take longer than their time budget",
+		},
+		cli.BoolFlag{
+			Name:  "ignore-version",
+			Usage: "Run even if the config requires a newer version of tusk",
+		},
 		cli.StringFlag{
 			Name:   "install-completion",
 			Usage:  "Install tab completion for a `shell`",
@@ -40,14 +86,70 @@ func newBaseApp() *cli.App {
 			Usage:  "Uninstall tab completion for a `shell`",
 			Hidden: true,
 		},
+		cli.BoolFlag{
+			Name:  "layered-defaults",
+			Usage: "Read option defaults for a task from tusk.<task>.yml beside the config file",
+		},
+		cli.BoolFlag{
+			Name:  "list-changed-options",
+			Usage: "Print the options for a task that differ from their defaults without running it",
+		},
+		cli.BoolFlag{
+			Name:  "mask-secrets",
+			Usage: "Mask secret option values written by --export-options",
+		},
+		cli.StringFlag{
+			Name:  "matrix",
+			Usage: "Run a task for every combination of option values in a `list` such as \"a=1,2 b=3,4\"",
+		},
+		cli.BoolFlag{
+			Name:  "matrix-parallel",
+			Usage: "Run every combination of a matrix at the same time",
+		},
+		cli.StringFlag{
+			Name:  "max-parallel",
+			Usage: "Run at most a `number` of the children of each parallel block at once",
+		},
+		cli.BoolFlag{
+			Name:  "no-cleanup-on-interrupt",
+			Usage: "Skip the finally steps of tasks when interrupted",
+		},
+		cli.BoolFlag{
+			Name:  "no-env-inherit",
+			Usage: "Run commands with only the environment variables set by tasks",
+		},
+		cli.BoolFlag{
+			Name:  "no-interactive",
+			Usage: "Print help instead of prompting for a task when none is given",
+		},
+		cli.StringFlag{
+			Name:  "on-failure",
+			Usage: "Run a `task` after the task being run fails",
+		},
+		cli.StringFlag{
+			Name:  "parallel-order",
+			Usage: "Launch the children of parallel blocks in an `order` (declared, random)",
+		},
 		cli.BoolFlag{
 			Name:  "q, quiet",
 			Usage: "Only print command output and application errors",
 		},
+		cli.StringFlag{
+			Name:  "run-dir",
+			Usage: "Save the output of each step to a file in a `dir`",
+		},
+		cli.StringFlag{
+			Name:  "seed",
+			Usage: "Set the `number` used to shuffle parallel children with --parallel-order random",
+		},
 		cli.BoolFlag{
 			Name:  "s, silent",
 			Usage: "Print no output",
 		},
+		cli.BoolFlag{
+			Name:  "summary-only",
+			Usage: "Print only a summary of the run and the output of commands that fail",
+		},
 		cli.BoolFlag{
 			Name:  "v, verbose",
 			Usage: "Print verbose output",
@@ -56,6 +158,18 @@ func newBaseApp() *cli.App {
 			Name:  "V, version",
 			Usage: "Print version and exit",
 		},
+		cli.BoolFlag{
+			Name:  "verbose-errors",
+			Usage: "Print the end of a command's stderr when it fails, even with --silent",
+		},
+		cli.BoolFlag{
+			Name:  "which",
+			Usage: "Print the file where a task is defined",
+		},
+		cli.BoolFlag{
+			Name:  "yes",
+			Usage: "Run tasks that require confirmation without prompting",
+		},
 	)
 
 	sort.Sort(cli.FlagsByName(app.Flags))
@@ -88,6 +202,14 @@ func newMetaApp(cfgText []byte) (*cli.App, error) {
 		return nil, err
 	}
 
+	// Unknown task names are recorded so they can be matched as abbreviations
+	app.Action = func(c *cli.Context) error {
+		if c.NArg() > 0 {
+			app.Metadata["unknownTask"] = c.Args().First()
+		}
+		return nil
+	}
+
 	return app, nil
 }
 
@@ -102,6 +224,21 @@ func NewApp(args []string, meta *runner.Metadata) (*cli.App, error) {
 		return nil, rerr
 	}
 
+	// Names that match no tasks at all are left for the cli to report
+	abbreviation, isAbbreviated := metaApp.Metadata["unknownTask"].(string)
+	isAbbreviated = isAbbreviated && len(matchingCommands(metaApp.Commands, abbreviation)) > 0
+	if isAbbreviated {
+		name, err := matchCommand(metaApp.Commands, abbreviation)
+		if err != nil {
+			return nil, err
+		}
+
+		addAbbreviation(metaApp, name, abbreviation)
+		if rerr := metaApp.Run(args); rerr != nil {
+			return nil, rerr
+		}
+	}
+
 	var taskName string
 	command, ok := metaApp.Metadata["command"].(*cli.Command)
 	if ok {
@@ -113,7 +250,7 @@ func NewApp(args []string, meta *runner.Metadata) (*cli.App, error) {
 		return nil, err
 	}
 
-	cfg, err := runner.ParseComplete(meta.CfgText, taskName, argsPassed, flagsPassed)
+	cfg, matrixRuns, err := parseForTask(meta, taskName, argsPassed, flagsPassed)
 	if err != nil {
 		return nil, err
 	}
@@ -127,12 +264,57 @@ func NewApp(args []string, meta *runner.Metadata) (*cli.App, error) {
 		app.Usage = cfg.Usage
 	}
 
-	if err := addTasks(app, cfg, createExecuteCommand); err != nil {
+	templates, err := cfg.Templates.Parse()
+	if err != nil {
 		return nil, err
 	}
+	ui.CustomTemplates = templates
+
+	onFailure := meta.OnFailure
+	if onFailure == "" {
+		onFailure = cfg.OnFailure
+	}
+	if _, ok := cfg.Tasks[onFailure]; onFailure != "" && !ok {
+		return nil, fmt.Errorf("on-failure task %q does not exist", onFailure)
+	}
+
+	ctx := meta.RunContext()
+	if ctx.ParallelOrder == runner.ParallelOrderRandom {
+		ui.Info(fmt.Sprintf("launching parallel children in random order with --seed %d", ctx.Seed))
+	}
+	creator := createExecuteCommand(ctx, failureHook(meta, onFailure))
+	switch {
+	case meta.Artifacts:
+		creator = createArtifactsCommand
+	case meta.Which:
+		creator = createWhichCommand(meta)
+	case meta.ExplainOption != "":
+		creator = createExplainCommand(cfg, meta.ExplainOption)
+	case meta.ExportOptions != "":
+		creator = createExportOptionsCommand(cfg, meta)
+	case meta.ListChangedOptions:
+		creator = createListChangedOptionsCommand(cfg)
+	case len(matrixRuns) > 0:
+		parallel := meta.MatrixParallel || cfg.Tasks[taskName].MatrixParallel
+		creator = createMatrixCommand(
+			creator, ctx, failureHook(meta, onFailure), matrixRuns, parallel,
+		)
+	}
+
+	if err := addTasks(app, cfg, creator); err != nil {
+		return nil, err
+	}
+
+	if isAbbreviated {
+		addAbbreviation(app, taskName, abbreviation)
+	}
 
 	copyFlags(app, metaApp)
 
+	if taskName == "" && !meta.NoInteractive && isInteractive() {
+		app.Action = createSelectAction(args, meta, cfg)
+	}
+
 	app.BashComplete = createDefaultComplete(os.Stdout, app)
 	for i := range app.Commands {
 		cmd := &app.Commands[i]
@@ -142,6 +324,20 @@ func NewApp(args []string, meta *runner.Metadata) (*cli.App, error) {
 	return app, nil
 }
 
+// parseForTask parses the config for running a task. When only the file where
+// a task is defined is printed, nothing is evaluated, so that options with
+// side effects are not run and missing values are not an error.
+func parseForTask(
+	meta *runner.Metadata, taskName string, args []string, flags map[string]string,
+) (*runner.Config, []runner.MatrixRun, error) {
+	if meta.Which {
+		cfg, err := runner.Parse(meta.CfgText)
+		return cfg, nil, err
+	}
+
+	return runner.ParseMatrix(meta, taskName, args, flags)
+}
+
 // getPassedValues returns the args and flags passed by command line.
 func getPassedValues(app *cli.App) (args []string, flags map[string]string, err error) {
 	argsPassed, ok := app.Metadata["argsPassed"].([]string)
@@ -1,6 +1,7 @@
 package appcli
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -13,6 +14,7 @@ import (
 	"github.com/rliebz/tusk/runner"
 	"github.com/rliebz/tusk/ui"
 	"github.com/urfave/cli"
+	"gotest.tools/v3/fs"
 )
 
 func TestNewFlagApp(t *testing.T) {
@@ -246,6 +248,514 @@ tasks:
 	}
 }
 
+func TestNewApp_namespaces(t *testing.T) {
+	args := []string{"tusk", "db:migrate"}
+	cfgText := []byte(`
+namespaces:
+  db:
+    tasks:
+      migrate:
+        usage: Run migrations
+        run: echo migrate
+tasks:
+  build:
+    usage: Build the project
+    run: echo build
+`)
+	meta := &runner.Metadata{CfgText: cfgText}
+
+	app, err := NewApp(args, meta)
+	if err != nil {
+		t.Fatalf("NewApp(): unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	app.Setup()
+	cli.HelpPrinter(&buf, cli.AppHelpTemplate, app)
+
+	want := `Tasks:
+   build  Build the project
+   db:
+     db:migrate  Run migrations
+`
+	if !bytes.Contains(buf.Bytes(), []byte(want)) {
+		t.Errorf("help for namespaced tasks: want to contain:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
+
+func TestNewApp_description_file(t *testing.T) {
+	cfgText := `
+tasks:
+  build:
+    usage: Build the project
+    description-file: docs/build.md
+    run: echo build
+`
+	dir := fs.NewDir(t, "description-file",
+		fs.WithFile("tusk.yml", cfgText),
+		fs.WithDir("docs", fs.WithFile("build.md", "Builds every binary.\n\nSee the README.\n")),
+	)
+	defer dir.Remove()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd) // nolint: errcheck
+
+	if err := os.Chdir(dir.Path()); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"tusk", "build"}
+	meta := &runner.Metadata{CfgText: []byte(cfgText), Directory: dir.Path()}
+
+	app, err := NewApp(args, meta)
+	if err != nil {
+		t.Fatalf("NewApp(): unexpected error: %v", err)
+	}
+
+	command := app.Command("build")
+	if command == nil {
+		t.Fatal("NewApp(): expected command build")
+	}
+
+	var buf bytes.Buffer
+	cli.HelpPrinter(&buf, command.CustomHelpTemplate, command)
+
+	want := "Description:\n   Builds every binary.\n   \n   See the README."
+	if !bytes.Contains(buf.Bytes(), []byte(want)) {
+		t.Errorf("help for task: want to contain:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
+
+func TestNewApp_conditionally_private_task(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  debug:
+    private:
+      environment: {TUSK_TEST_APP_ENV: production}
+    run: echo debugging
+  deploy:
+    run: echo deploying`)
+	meta := &runner.Metadata{CfgText: cfgText}
+
+	defer os.Unsetenv("TUSK_TEST_APP_ENV") // nolint: errcheck
+
+	for env, want := range map[string]int{"production": 1, "local": 2} {
+		if err := os.Setenv("TUSK_TEST_APP_ENV", env); err != nil {
+			t.Fatal(err)
+		}
+
+		app, err := NewApp([]string{"tusk"}, meta)
+		if err != nil {
+			t.Fatalf("NewApp(): unexpected error: %v", err)
+		}
+
+		if len(app.Commands) != want {
+			t.Errorf(
+				"with environment %q: expected %d commands, got %d",
+				env, want, len(app.Commands),
+			)
+		}
+	}
+}
+
+func TestNewApp_check_only(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  deploy:
+    options:
+      target:
+        required: true
+    run: exit 1`)
+	meta := &runner.Metadata{CfgText: cfgText, CheckOnly: true}
+
+	args := []string{"tusk", "deploy", "--target", "prod"}
+	app, err := NewApp(args, meta)
+	if err != nil {
+		t.Fatalf("NewApp(): unexpected error: %v", err)
+	}
+
+	if err := app.Run(args); err != nil {
+		t.Errorf("app.Run(%v): unexpected error: %v", args, err)
+	}
+
+	args = []string{"tusk", "deploy"}
+	if _, err := NewApp(args, meta); err == nil {
+		t.Errorf("NewApp(%v): expected error for missing required option", args)
+	}
+}
+
+func TestNewApp_artifacts(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  build:
+    options:
+      target:
+        default: linux
+    produces:
+      - dist/${target}/*
+      - coverage.out
+    run:
+      - exit 1
+      - task: test
+  test:
+    produces: coverage.out
+    run: exit 1`)
+	meta := &runner.Metadata{CfgText: cfgText, Artifacts: true}
+
+	args := []string{"tusk", "build", "--target", "darwin"}
+	app, err := NewApp(args, meta)
+	if err != nil {
+		t.Fatalf("NewApp(): unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	app.Writer = &buf
+
+	if err := app.Run(args); err != nil {
+		t.Fatalf("app.Run(%v): unexpected error: %v", args, err)
+	}
+
+	want := "dist/darwin/*\ncoverage.out\n"
+	if got := buf.String(); got != want {
+		t.Errorf("app.Run(%v): want output %q, got %q", args, want, got)
+	}
+}
+
+func TestNewApp_which(t *testing.T) {
+	cfgText := `
+tasks:
+  local:
+    run: exit 1
+  included:
+    include: .tusk/included.yml
+  unevaluated:
+    args:
+      target: {}
+    options:
+      token:
+        required: true
+      marker:
+        default:
+          command: touch evaluated
+    run: echo ${target} ${token} ${marker}
+`
+	dir := fs.NewDir(t, "which",
+		fs.WithFile("tusk.yml", cfgText),
+		fs.WithDir(".tusk", fs.WithFile("included.yml", "run: exit 1")),
+	)
+	defer dir.Remove()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd) // nolint: errcheck
+
+	if err := os.Chdir(dir.Path()); err != nil {
+		t.Fatal(err)
+	}
+
+	meta := &runner.Metadata{
+		CfgPath:   filepath.Join(dir.Path(), "tusk.yml"),
+		CfgText:   []byte(cfgText),
+		Directory: dir.Path(),
+		Which:     true,
+	}
+
+	tests := []struct {
+		task string
+		want string
+	}{
+		{"local", filepath.Join(dir.Path(), "tusk.yml")},
+		{"included", filepath.Join(dir.Path(), ".tusk", "included.yml")},
+		{"unevaluated", filepath.Join(dir.Path(), "tusk.yml")},
+	}
+
+	for _, tt := range tests {
+		args := []string{"tusk", tt.task}
+		app, err := NewApp(args, meta)
+		if err != nil {
+			t.Fatalf("NewApp(): unexpected error: %v", err)
+		}
+
+		var buf bytes.Buffer
+		app.Writer = &buf
+
+		if err := app.Run(args); err != nil {
+			t.Fatalf("app.Run(%v): unexpected error: %v", args, err)
+		}
+
+		if got := buf.String(); got != tt.want+"\n" {
+			t.Errorf("app.Run(%v): want output %q, got %q", args, tt.want+"\n", got)
+		}
+	}
+
+	if _, err := os.Stat(dir.Join("evaluated")); !os.IsNotExist(err) {
+		t.Errorf("--which: want option defaults not to be evaluated, got %v", err)
+	}
+}
+
+func TestNewApp_explain_option(t *testing.T) {
+	cfgText := []byte(`
+options:
+  env:
+    default: dev
+tasks:
+  deploy:
+    options:
+      region:
+        default:
+          - when:
+              equal: {env: dev}
+            value: local
+          - when:
+              equal: {env: prod}
+            value: us-east-1
+    run: exit 1
+  other:
+    run: exit 1`)
+	meta := &runner.Metadata{CfgText: cfgText, ExplainOption: "region"}
+
+	args := []string{"tusk", "deploy", "--env", "prod"}
+	app, err := NewApp(args, meta)
+	if err != nil {
+		t.Fatalf("NewApp(): unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	app.Writer = &buf
+
+	if err := app.Run(args); err != nil {
+		t.Fatalf("app.Run(%v): unexpected error: %v", args, err)
+	}
+
+	want := `option "region":
+  1. not passed by command line
+  2. no environment variable defined
+  3. default 1 skipped: no options matched
+  4. default 2 conditions met:
+       - equal:
+           env:
+           - prod
+value: "us-east-1" (from default 2)
+`
+	if got := buf.String(); got != want {
+		t.Errorf("app.Run(%v): want output:\n%s\ngot:\n%s", args, want, got)
+	}
+
+	args = []string{"tusk", "other"}
+	app, err = NewApp(args, meta)
+	if err != nil {
+		t.Fatalf("NewApp(): unexpected error: %v", err)
+	}
+
+	app.Writer = ioutil.Discard
+	if err := app.Run(args); err == nil {
+		t.Errorf("app.Run(%v): expected error for unused option", args)
+	}
+}
+
+func TestNewApp_export_options(t *testing.T) {
+	dir := fs.NewDir(t, "export-options")
+	defer dir.Remove()
+
+	cfgText := []byte(`
+options:
+  env:
+    default: dev
+tasks:
+  deploy:
+    options:
+      region:
+        default: us-east-1
+      token:
+        secret: true
+        default: abc123
+    run: echo ${env} && exit 1`)
+
+	for _, mask := range []bool{false, true} {
+		file := dir.Join(fmt.Sprintf("options-%t.env", mask))
+		meta := &runner.Metadata{CfgText: cfgText, ExportOptions: file, MaskSecrets: mask}
+
+		args := []string{"tusk", "deploy", "--env", "prod"}
+		app, err := NewApp(args, meta)
+		if err != nil {
+			t.Fatalf("NewApp(): unexpected error: %v", err)
+		}
+
+		if err := app.Run(args); err != nil {
+			t.Fatalf("app.Run(%v): unexpected error: %v", args, err)
+		}
+
+		want := "ENV=prod\nREGION=us-east-1\nTOKEN=abc123\n"
+		if mask {
+			want = "ENV=prod\nREGION=us-east-1\nTOKEN=****\n"
+		}
+
+		got, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("app.Run(%v): expected file to be written: %v", args, err)
+		}
+		if string(got) != want {
+			t.Errorf("app.Run(%v): want file:\n%s\ngot:\n%s", args, want, got)
+		}
+	}
+}
+
+func TestNewApp_on_failure(t *testing.T) {
+	tests := []struct {
+		name      string
+		task      string
+		cfgHook   string
+		flagHook  string
+		wantHook  string
+		wantError bool
+	}{
+		{name: "config", task: "fail", cfgHook: "notify", wantHook: "fail - exit status 3\n", wantError: true},
+		{name: "flag", task: "fail", flagHook: "notify", wantHook: "fail - exit status 3\n", wantError: true},
+		{
+			name:      "flag overrides config",
+			task:      "fail",
+			cfgHook:   "other",
+			flagHook:  "notify",
+			wantHook:  "fail - exit status 3\n",
+			wantError: true,
+		},
+		{name: "success", task: "pass", cfgHook: "notify"},
+		{name: "no hook", task: "fail", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fs.NewDir(t, "on-failure")
+			defer dir.Remove()
+
+			hookFile := filepath.Join(dir.Path(), "hook")
+			cfgText := []byte(fmt.Sprintf(`
+on-failure: %q
+tasks:
+  fail:
+    run: exit 3
+  pass:
+    run: "true"
+  notify:
+    run: echo "${failed-task} - ${failed-error}" > %s
+  other:
+    run: echo other > %s
+`, tt.cfgHook, hookFile, hookFile))
+			meta := &runner.Metadata{CfgText: cfgText, OnFailure: tt.flagHook}
+
+			args := []string{"tusk", tt.task}
+			app, err := NewApp(args, meta)
+			if err != nil {
+				t.Fatalf("NewApp(): unexpected error: %v", err)
+			}
+
+			exitErr, ok := app.Run(args).(*exec.ExitError)
+			if tt.wantError {
+				if !ok {
+					t.Fatalf("app.Run(%v): expected exit err, got %#v", args, err)
+				}
+				if code := exitErr.ExitCode(); code != 3 {
+					t.Errorf("app.Run(%v): expected exit code 3, got %d", args, code)
+				}
+			} else if exitErr != nil {
+				t.Fatalf("app.Run(%v): unexpected error: %v", args, exitErr)
+			}
+
+			got, err := ioutil.ReadFile(hookFile)
+			if tt.wantHook == "" {
+				if !os.IsNotExist(err) {
+					t.Errorf("app.Run(%v): expected hook not to run, got %q", args, got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("app.Run(%v): expected hook to run: %v", args, err)
+			}
+			if string(got) != tt.wantHook {
+				t.Errorf("app.Run(%v): want hook output %q, got %q", args, tt.wantHook, got)
+			}
+		})
+	}
+}
+
+func TestNewApp_on_failure_unknown_task(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  fail:
+    run: exit 1`)
+	meta := &runner.Metadata{CfgText: cfgText, OnFailure: "missing"}
+
+	args := []string{"tusk", "fail"}
+	if _, err := NewApp(args, meta); err == nil {
+		t.Errorf("NewApp(): expected error for unknown on-failure task")
+	}
+}
+
+func TestNewApp_abbreviation(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  deploy:
+    options:
+      env:
+        default: dev
+    run: exit 1
+  destroy:
+    run: exit 2
+  test:
+    run: exit 3
+  testing:
+    run: exit 4`)
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{name: "unique prefix", args: []string{"tusk", "dep"}, wantCode: 1},
+		{name: "unique prefix with flags", args: []string{"tusk", "dep", "--env", "prod"}, wantCode: 1},
+		{name: "exact over prefix", args: []string{"tusk", "test"}, wantCode: 3},
+		{name: "longer prefix", args: []string{"tusk", "testi"}, wantCode: 4},
+		{
+			name:    "ambiguous prefix",
+			args:    []string{"tusk", "de"},
+			wantErr: `"de" matches multiple tasks: deploy, destroy`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := &runner.Metadata{CfgText: cfgText}
+
+			app, err := NewApp(tt.args, meta)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("NewApp(): want error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewApp(): unexpected error: %v", err)
+			}
+
+			exitErr, ok := app.Run(tt.args).(*exec.ExitError)
+			if !ok {
+				t.Fatalf("app.Run(%v): expected exit err, got %#v", tt.args, err)
+			}
+
+			if code := exitErr.ExitCode(); code != tt.wantCode {
+				t.Errorf("app.Run(%v): want exit code %d, got %d", tt.args, tt.wantCode, code)
+			}
+		})
+	}
+}
+
 func TestNewApp_fails_bad_config(t *testing.T) {
 	args := []string{"tusk"}
 	cfgText := []byte(`invalid`)
@@ -0,0 +1,166 @@
+package appcli
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/rliebz/tusk/runner"
+)
+
+// catalogSchemaVersion is the version of the json catalog format, which is
+// incremented for any change that is not backwards compatible.
+const catalogSchemaVersion = 1
+
+// catalog is a machine-readable description of all public tasks in a config.
+type catalog struct {
+	SchemaVersion int           `json:"schema-version"`
+	Name          string        `json:"name"`
+	Usage         string        `json:"usage,omitempty"`
+	Tasks         []catalogTask `json:"tasks"`
+}
+
+type catalogTask struct {
+	Name         string          `json:"name"`
+	Namespace    string          `json:"namespace,omitempty"`
+	Usage        string          `json:"usage,omitempty"`
+	Description  string          `json:"description,omitempty"`
+	Args         []catalogArg    `json:"args"`
+	Options      []catalogOption `json:"options"`
+	Dependencies []string        `json:"dependencies"`
+}
+
+type catalogArg struct {
+	Name    string   `json:"name"`
+	Usage   string   `json:"usage,omitempty"`
+	Default *string  `json:"default,omitempty"`
+	Values  []string `json:"values,omitempty"`
+}
+
+type catalogOption struct {
+	Name        string   `json:"name"`
+	Short       string   `json:"short,omitempty"`
+	Type        string   `json:"type"`
+	Usage       string   `json:"usage,omitempty"`
+	Environment string   `json:"environment,omitempty"`
+	Required    bool     `json:"required"`
+	Secret      bool     `json:"secret"`
+	Default     *string  `json:"default,omitempty"`
+	Values      []string `json:"values,omitempty"`
+}
+
+func writeJSON(w io.Writer, cfg *runner.Config) error {
+	c := catalog{
+		SchemaVersion: catalogSchemaVersion,
+		Name:          cfg.Name,
+		Usage:         strings.TrimSpace(cfg.Usage),
+		Tasks:         []catalogTask{},
+	}
+	if c.Name == "" {
+		c.Name = "tusk"
+	}
+
+	names := make([]string, 0, len(cfg.Tasks))
+	for taskName, t := range cfg.Tasks {
+		if !t.Private {
+			names = append(names, taskName)
+		}
+	}
+	sort.Strings(names)
+
+	for _, taskName := range names {
+		task, err := newCatalogTask(cfg, cfg.Tasks[taskName])
+		if err != nil {
+			return err
+		}
+
+		c.Tasks = append(c.Tasks, task)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c)
+}
+
+func newCatalogTask(cfg *runner.Config, t *runner.Task) (catalogTask, error) {
+	options, err := runner.FindAllOptions(t, cfg)
+	if err != nil {
+		return catalogTask{}, err
+	}
+
+	task := catalogTask{
+		Name:         t.Name,
+		Namespace:    t.Namespace,
+		Usage:        strings.TrimSpace(t.Usage),
+		Description:  strings.TrimSpace(t.Description),
+		Args:         make([]catalogArg, 0, len(t.Args)),
+		Options:      make([]catalogOption, 0, len(options)),
+		Dependencies: taskDependencies(t),
+	}
+
+	for _, arg := range t.Args {
+		task.Args = append(task.Args, catalogArg{
+			Name:    arg.Name,
+			Usage:   arg.Usage,
+			Default: arg.Default,
+			Values:  arg.ValuesAllowed,
+		})
+	}
+
+	for _, opt := range options {
+		if opt.Private {
+			continue
+		}
+
+		task.Options = append(task.Options, catalogOption{
+			Name:        opt.Name,
+			Short:       opt.Short,
+			Type:        optionType(opt),
+			Usage:       opt.Usage,
+			Environment: opt.Environment,
+			Required:    opt.Required,
+			Secret:      opt.Secret,
+			Default:     staticDefault(opt),
+			Values:      opt.ValuesAllowed,
+		})
+	}
+
+	return task, nil
+}
+
+// taskDependencies returns the names of the sub-tasks a task runs, in the
+// order they are first run.
+func taskDependencies(t *runner.Task) []string {
+	seen := make(map[string]bool)
+	dependencies := []string{}
+
+	for _, run := range t.AllRunItems() {
+		for _, sub := range run.SubTaskList {
+			if seen[sub.Name] {
+				continue
+			}
+
+			seen[sub.Name] = true
+			dependencies = append(dependencies, sub.Name)
+		}
+	}
+
+	return dependencies
+}
+
+// staticDefault returns the default value of an option when it can be known
+// without evaluating anything, such as a command or a condition. Defaults of
+// secret options are never included.
+func staticDefault(opt *runner.Option) *string {
+	if opt.Secret || len(opt.DefaultValues) != 1 {
+		return nil
+	}
+
+	value := opt.DefaultValues[0]
+	if len(value.When) > 0 || value.Command != "" || value.URL != "" || value.FromTask != nil {
+		return nil
+	}
+
+	return &value.Value
+}
@@ -1,28 +1,198 @@
 package appcli
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"strings"
 
 	"github.com/urfave/cli"
 
 	"github.com/rliebz/tusk/runner"
+	"github.com/rliebz/tusk/ui"
 )
 
 type commandCreator func(app *cli.App, t *runner.Task) (*cli.Command, error)
 
-func createExecuteCommand(_ *cli.App, t *runner.Task) (*cli.Command, error) {
+// createExecuteCommand returns a command creator that executes tasks using
+// the given run context. If a task fails, the failure hook is run before the
+// original error is returned, unless the task was interrupted.
+func createExecuteCommand(ctx runner.RunContext, onFailure failureHandler) commandCreator {
+	return func(_ *cli.App, t *runner.Task) (*cli.Command, error) {
+		return createCommand(t, func(c *cli.Context) error {
+			if err := checkArgCount(c, t); err != nil {
+				return err
+			}
+			defer ctx.HandleInterrupts()()
+			if err := t.Execute(ctx); err != nil {
+				if !ctx.CheckOnly && !runner.IsInterrupted(err) {
+					onFailure(ctx, t, err)
+				}
+				return err
+			}
+
+			if ctx.CheckOnly {
+				ui.Info(fmt.Sprintf("task %q is ready to run", t.Name))
+			}
+
+			return nil
+		}), nil
+	}
+}
+
+// checkArgCount returns an error if the number of args passed is not valid
+// for a task.
+func checkArgCount(c *cli.Context, t *runner.Task) error {
+	if n := len(c.Args()); n < t.Args.Required() || n > len(t.Args) {
+		return fmt.Errorf(
+			"task %q requires %s args, got %d",
+			t.Name, t.Args.DescribeCount(), n,
+		)
+	}
+
+	return nil
+}
+
+// createMatrixCommand returns a command creator that executes a task once for
+// every run in its matrix. Other tasks are created as usual.
+func createMatrixCommand(
+	creator commandCreator,
+	ctx runner.RunContext,
+	onFailure failureHandler,
+	runs []runner.MatrixRun,
+	parallel bool,
+) commandCreator {
+	name := runs[0].Task.Name
+	return func(app *cli.App, t *runner.Task) (*cli.Command, error) {
+		if t.Name != name {
+			return creator(app, t)
+		}
+
+		return createCommand(t, func(c *cli.Context) error {
+			if err := checkArgCount(c, t); err != nil {
+				return err
+			}
+
+			defer ctx.HandleInterrupts()()
+			if err := runner.RunMatrix(ctx, runs, parallel); err != nil {
+				if !ctx.CheckOnly && !runner.IsInterrupted(err) {
+					onFailure(ctx, t, err)
+				}
+				return err
+			}
+
+			if ctx.CheckOnly {
+				ui.Info(fmt.Sprintf("task %q is ready to run", t.Name))
+			}
+
+			return nil
+		}), nil
+	}
+}
+
+// failureHandler is called when a task fails.
+type failureHandler func(ctx runner.RunContext, t *runner.Task, err error)
+
+// failureHook returns a failure handler that runs the named task. Errors from
+// the hook task are printed, so that the original error is still returned.
+func failureHook(meta *runner.Metadata, name string) failureHandler {
+	return func(ctx runner.RunContext, t *runner.Task, failure error) {
+		// A failing hook task is not run a second time
+		if name == "" || name == t.Name {
+			return
+		}
+
+		hook, err := runner.ParseFailureHook(meta, name, t.Name, failure)
+		if err == nil {
+			err = hook.Execute(ctx)
+		}
+
+		if err != nil {
+			ui.Warn(fmt.Sprintf("on-failure task %q failed: %v", name, err))
+		}
+	}
+}
+
+// createArtifactsCommand creates a command that prints the artifacts a task
+// produces instead of executing it.
+func createArtifactsCommand(_ *cli.App, t *runner.Task) (*cli.Command, error) {
 	return createCommand(t, func(c *cli.Context) error {
-		if len(t.Args) != len(c.Args()) {
-			return fmt.Errorf(
-				"task %q requires exactly %d args, got %d",
-				t.Name, len(t.Args), len(c.Args()),
-			)
+		for _, artifact := range t.Artifacts() {
+			if _, err := fmt.Fprintln(c.App.Writer, artifact); err != nil {
+				return err
+			}
 		}
-		return t.Execute(runner.RunContext{})
+
+		return nil
 	}), nil
 }
 
+// createWhichCommand returns a command creator that prints the file where a
+// task is defined instead of executing it.
+func createWhichCommand(meta *runner.Metadata) commandCreator {
+	return func(_ *cli.App, t *runner.Task) (*cli.Command, error) {
+		return createCommand(t, func(c *cli.Context) error {
+			source := meta.CfgPath
+			if t.Source != "" {
+				source = t.Source
+				if !filepath.IsAbs(source) {
+					source = filepath.Join(meta.Directory, source)
+				}
+			}
+
+			_, err := fmt.Fprintln(c.App.Writer, source)
+			return err
+		}), nil
+	}
+}
+
+// createExplainCommand returns a command creator that prints how the value of
+// an option is determined for a task instead of executing it.
+func createExplainCommand(cfg *runner.Config, name string) commandCreator {
+	return func(_ *cli.App, t *runner.Task) (*cli.Command, error) {
+		return createCommand(t, func(c *cli.Context) error {
+			o, ok := t.Options.Lookup(name)
+			if !ok {
+				o, ok = cfg.Options.Lookup(name)
+			}
+
+			value, used := t.Vars[name]
+			if !ok || !used {
+				return fmt.Errorf("option %q is not used by task %q", name, t.Name)
+			}
+
+			return o.Explain(c.App.Writer, t.Vars, value)
+		}), nil
+	}
+}
+
+// createListChangedOptionsCommand returns a command creator that prints the
+// options for a task that differ from their defaults instead of executing it.
+func createListChangedOptionsCommand(cfg *runner.Config) commandCreator {
+	return func(_ *cli.App, t *runner.Task) (*cli.Command, error) {
+		return createCommand(t, func(c *cli.Context) error {
+			return t.WriteChangedOptions(c.App.Writer, cfg.Options)
+		}), nil
+	}
+}
+
+// createExportOptionsCommand returns a command creator that writes the option
+// values for a task to an env-file instead of executing it.
+func createExportOptionsCommand(cfg *runner.Config, meta *runner.Metadata) commandCreator {
+	return func(_ *cli.App, t *runner.Task) (*cli.Command, error) {
+		return createCommand(t, func(c *cli.Context) error {
+			var buf bytes.Buffer
+			if err := t.WriteEnvFile(&buf, cfg.Options, meta.MaskSecrets); err != nil {
+				return err
+			}
+
+			// The file may contain secrets, so it is only readable by the user
+			return ioutil.WriteFile(meta.ExportOptions, buf.Bytes(), 0600)
+		}), nil
+	}
+}
+
 func createMetadataBuildCommand(app *cli.App, t *runner.Task) (*cli.Command, error) {
 	argsPassed, flagsPassed, err := getPassedValues(app)
 	if err != nil {
@@ -50,11 +220,16 @@ func createCommand(t *runner.Task, actionFunc func(*cli.Context) error) *cli.Com
 		Name:        t.Name,
 		Usage:       strings.TrimSpace(t.Usage),
 		Description: strings.TrimSpace(t.Description),
+		Category:    t.Namespace,
 		Action:      actionFunc,
 	}
 
 	for _, arg := range t.Args {
-		command.ArgsUsage += fmt.Sprintf("<%s> ", arg.Name)
+		if arg.Default != nil {
+			command.ArgsUsage += fmt.Sprintf("[<%s>] ", arg.Name)
+		} else {
+			command.ArgsUsage += fmt.Sprintf("<%s> ", arg.Name)
+		}
 	}
 
 	command.CustomHelpTemplate = createCommandHelp(t)
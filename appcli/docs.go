@@ -0,0 +1,184 @@
+package appcli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/rliebz/tusk/runner"
+)
+
+// WriteDocs writes reference documentation for all public tasks in a config,
+// either as markdown or as a json catalog for use by other tools.
+func WriteDocs(w io.Writer, format string, cfgText []byte) error {
+	var write func(io.Writer, *runner.Config) error
+	switch format {
+	case "markdown":
+		write = writeMarkdown
+	case "json":
+		write = writeJSON
+	default:
+		return fmt.Errorf("unsupported docs format %q", format)
+	}
+
+	cfg, err := runner.Parse(cfgText)
+	if err != nil {
+		return err
+	}
+
+	return write(w, cfg)
+}
+
+func writeMarkdown(w io.Writer, cfg *runner.Config) error {
+	name := cfg.Name
+	if name == "" {
+		name = "tusk"
+	}
+
+	fmt.Fprintf(w, "# %s\n", name)
+	if cfg.Usage != "" {
+		fmt.Fprintf(w, "\n%s\n", strings.TrimSpace(cfg.Usage))
+	}
+
+	names := make([]string, 0, len(cfg.Tasks))
+	for taskName, t := range cfg.Tasks {
+		if !t.Private {
+			names = append(names, taskName)
+		}
+	}
+	sort.Strings(names)
+
+	for _, taskName := range names {
+		if err := writeMarkdownTask(w, name, cfg, cfg.Tasks[taskName]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeMarkdownTask(w io.Writer, appName string, cfg *runner.Config, t *runner.Task) error {
+	options, err := runner.FindAllOptions(t, cfg)
+	if err != nil {
+		return err
+	}
+
+	public := make([]*runner.Option, 0, len(options))
+	for _, opt := range options {
+		if !opt.Private {
+			public = append(public, opt)
+		}
+	}
+
+	fmt.Fprintf(w, "\n## %s\n", t.Name)
+	if t.Usage != "" {
+		fmt.Fprintf(w, "\n%s\n", strings.TrimSpace(t.Usage))
+	}
+
+	usage := fmt.Sprintf("%s %s", appName, t.Name)
+	if len(public) > 0 {
+		usage += " [options]"
+	}
+	for _, arg := range t.Args {
+		if arg.Default != nil {
+			usage += fmt.Sprintf(" [<%s>]", arg.Name)
+		} else {
+			usage += fmt.Sprintf(" <%s>", arg.Name)
+		}
+	}
+	fmt.Fprintf(w, "\n```text\n%s\n```\n", usage)
+
+	if t.Description != "" {
+		fmt.Fprintf(w, "\n%s\n", strings.TrimSpace(t.Description))
+	}
+
+	if len(t.Args) > 0 {
+		fmt.Fprint(w, "\n### Arguments\n\n")
+		fmt.Fprintln(w, "| Name | Usage | Values |")
+		fmt.Fprintln(w, "| ---- | ----- | ------ |")
+		for _, arg := range t.Args {
+			fmt.Fprintf(
+				w, "| %s | %s | %s |\n",
+				markdownCell(arg.Name),
+				markdownCell(arg.Usage),
+				markdownCell(strings.Join(arg.ValuesAllowed, ", ")),
+			)
+		}
+	}
+
+	if len(public) > 0 {
+		fmt.Fprint(w, "\n### Options\n\n")
+		fmt.Fprintln(w, "| Name | Type | Default | Values | Usage |")
+		fmt.Fprintln(w, "| ---- | ---- | ------- | ------ | ----- |")
+		for _, opt := range public {
+			fmt.Fprintf(
+				w, "| %s | %s | %s | %s | %s |\n",
+				markdownCell(optionFlagNames(opt)),
+				markdownCell(optionType(opt)),
+				markdownCell(describeDefault(opt)),
+				markdownCell(strings.Join(opt.ValuesAllowed, ", ")),
+				markdownCell(opt.Usage),
+			)
+		}
+	}
+
+	return nil
+}
+
+// optionFlagNames returns the command-line flags used to set an option.
+func optionFlagNames(opt *runner.Option) string {
+	names := "--" + opt.Name
+	if opt.Short != "" {
+		names = fmt.Sprintf("-%s, %s", opt.Short, names)
+	}
+
+	return names
+}
+
+// optionType returns the normalized type name of an option.
+func optionType(opt *runner.Option) string {
+	if opt.Type == "" {
+		return "string"
+	}
+
+	return strings.ToLower(opt.Type)
+}
+
+// describeDefault summarizes the default value of an option without
+// evaluating any commands.
+func describeDefault(opt *runner.Option) string {
+	if opt.Required {
+		return "required"
+	}
+
+	descriptions := make([]string, 0, len(opt.DefaultValues))
+	for _, value := range opt.DefaultValues {
+		var d string
+		switch {
+		case value.Command != "":
+			d = fmt.Sprintf("$(%s)", value.Command)
+		case value.URL != "":
+			d = value.URL
+		case opt.Secret:
+			d = "(secret)"
+		default:
+			d = value.Value
+		}
+
+		if len(value.When) > 0 {
+			d += " (conditional)"
+		}
+
+		descriptions = append(descriptions, d)
+	}
+
+	return strings.Join(descriptions, ", ")
+}
+
+// markdownCell escapes text for use in a single markdown table cell.
+func markdownCell(text string) string {
+	text = strings.TrimSpace(text)
+	text = strings.ReplaceAll(text, "|", `\|`)
+	return strings.ReplaceAll(text, "\n", " ")
+}
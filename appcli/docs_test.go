@@ -0,0 +1,176 @@
+package appcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+func TestWriteDocs_markdown(t *testing.T) {
+	cfgText := []byte(`
+name: mycli
+usage: A custom application
+
+options:
+  verbose:
+    type: bool
+    usage: Print more output
+
+tasks:
+  greet:
+    usage: Say hello
+    description: Greets a person by name.
+    args:
+      person:
+        usage: The person to greet
+    options:
+      greeting:
+        short: g
+        usage: The greeting to use
+        default: Hello
+        values: [Hello, Howdy]
+      count:
+        type: int
+        default:
+          command: echo 3
+    run: echo "${greeting}, ${person}!"
+  hidden:
+    private: true
+    run: echo secret
+`)
+
+	var buf bytes.Buffer
+	err := WriteDocs(&buf, "markdown", cfgText)
+	assert.NilError(t, err)
+
+	got := buf.String()
+	assert.Check(t, cmp.Contains(got, "# mycli\n\nA custom application\n"))
+	assert.Check(t, cmp.Contains(got, "## greet\n\nSay hello\n"))
+	assert.Check(t, cmp.Contains(got, "mycli greet [options] <person>"))
+	assert.Check(t, cmp.Contains(got, "Greets a person by name."))
+	assert.Check(t, cmp.Contains(got, "| person | The person to greet |  |"))
+	assert.Check(t, cmp.Contains(got, "| Name | Type | Default | Values | Usage |"))
+	assert.Check(t, cmp.Contains(got,
+		"| -g, --greeting | string | Hello | Hello, Howdy | The greeting to use |",
+	))
+	assert.Check(t, cmp.Contains(got, "| --count | int | $(echo 3) |  |  |"))
+	assert.Check(t, !bytes.Contains(buf.Bytes(), []byte("hidden")))
+	assert.Check(t, !bytes.Contains(buf.Bytes(), []byte("--verbose")))
+}
+
+func TestWriteDocs_unsupported_format(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteDocs(&buf, "html", []byte(`tasks: {}`))
+	assert.Error(t, err, `unsupported docs format "html"`)
+}
+
+func TestWriteDocs_json(t *testing.T) {
+	cfgText := []byte(`
+name: mycli
+usage: A custom application
+
+options:
+  region:
+    usage: The region to deploy to
+    default: us-east-1
+  token:
+    secret: true
+    default: abc123
+
+tasks:
+  build:
+    usage: Build the project
+    run: echo build
+  deploy:
+    usage: Deploy the project
+    description: Deploys every service.
+    args:
+      target:
+        usage: The environment to deploy to
+        values: [staging, production]
+    options:
+      replicas:
+        short: r
+        type: int
+        usage: The number of replicas
+        default: 2
+      version:
+        default:
+          command: git describe
+    run:
+      - task: build
+      - echo ${target} ${region} ${replicas} ${version} ${token}
+      - task: {name: build}
+    finally:
+      - task: notify
+  notify:
+    private: true
+    run: echo done
+`)
+
+	var buf bytes.Buffer
+	err := WriteDocs(&buf, "json", cfgText)
+	assert.NilError(t, err)
+
+	assert.Check(t, cmp.Contains(buf.String(), `"schema-version": 1,`))
+
+	var got catalog
+	assert.NilError(t, json.Unmarshal(buf.Bytes(), &got))
+
+	str := func(s string) *string { return &s }
+	want := catalog{
+		SchemaVersion: catalogSchemaVersion,
+		Name:          "mycli",
+		Usage:         "A custom application",
+		Tasks: []catalogTask{
+			{
+				Name:         "build",
+				Usage:        "Build the project",
+				Args:         []catalogArg{},
+				Options:      []catalogOption{},
+				Dependencies: []string{},
+			},
+			{
+				Name:        "deploy",
+				Usage:       "Deploy the project",
+				Description: "Deploys every service.",
+				Args: []catalogArg{
+					{
+						Name:   "target",
+						Usage:  "The environment to deploy to",
+						Values: []string{"staging", "production"},
+					},
+				},
+				Options: []catalogOption{
+					{
+						Name:    "replicas",
+						Short:   "r",
+						Type:    "int",
+						Usage:   "The number of replicas",
+						Default: str("2"),
+					},
+					{
+						Name: "version",
+						Type: "string",
+					},
+					{
+						Name:    "region",
+						Type:    "string",
+						Usage:   "The region to deploy to",
+						Default: str("us-east-1"),
+					},
+					{
+						Name:   "token",
+						Type:   "string",
+						Secret: true,
+					},
+				},
+				Dependencies: []string{"build", "notify"},
+			},
+		},
+	}
+	assert.Check(t, cmp.DeepEqual(got, want))
+}
@@ -74,29 +74,88 @@ func createCLIFlag(opt *runner.Option) (cli.Flag, error) {
 		name = fmt.Sprintf("%s, %s", name, opt.Short)
 	}
 
+	usage := optionUsage(opt)
+
 	opt.Type = strings.ToLower(opt.Type)
 	switch opt.Type {
 	case "int", "integer":
 		return cli.IntFlag{
 			Name:  name,
-			Usage: opt.Usage,
+			Usage: usage,
 		}, nil
 	case "float", "float64", "double":
 		return cli.Float64Flag{
 			Name:  name,
-			Usage: opt.Usage,
+			Usage: usage,
 		}, nil
 	case "bool", "boolean":
 		return cli.BoolFlag{
 			Name:  name,
-			Usage: opt.Usage,
+			Usage: usage,
 		}, nil
-	case "string", "":
+	case "string", "path", "":
 		return cli.StringFlag{
 			Name:  name,
-			Usage: opt.Usage,
+			Usage: usage,
 		}, nil
 	default:
 		return nil, fmt.Errorf(`unsupported flag type "%s"`, opt.Type)
 	}
 }
+
+// optionUsage returns the usage text of an option with its default appended.
+func optionUsage(opt *runner.Option) string {
+	d := describeHelpDefault(opt)
+	if d == "" {
+		return opt.Usage
+	}
+
+	if opt.Usage == "" {
+		return d
+	}
+
+	return opt.Usage + " " + d
+}
+
+// describeHelpDefault describes where the default value of an option comes
+// from for use in help text. Literal values are shown, while commands, urls,
+// and task outputs are labeled rather than evaluated.
+func describeHelpDefault(opt *runner.Option) string {
+	var sources []string
+	if opt.Environment != "" {
+		sources = append(sources, "from $"+opt.Environment)
+	}
+
+	for _, value := range opt.DefaultValues {
+		var d string
+		switch {
+		case value.Command != "":
+			d = "from a command"
+		case value.URL != "":
+			d = "from a url"
+		case value.FromTask != nil:
+			d = fmt.Sprintf("from task %q", value.FromTask.Task)
+		case opt.Secret:
+			d = fmt.Sprintf("%q", "****")
+		default:
+			d = fmt.Sprintf("%q", value.Value)
+		}
+
+		if len(value.When) > 0 {
+			d += " when conditions match"
+		}
+
+		sources = append(sources, d)
+	}
+
+	if len(sources) == 0 {
+		return ""
+	}
+
+	separator := " "
+	if !strings.HasPrefix(sources[0], "from ") {
+		separator = ": "
+	}
+
+	return "(default" + separator + strings.Join(sources, ", then ") + ")"
+}
@@ -41,3 +41,88 @@ func TestAddFlag_no_duplicates(t *testing.T) {
 		)
 	}
 }
+
+func TestCreateCLIFlag_default_usage(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  *runner.Option
+		want string
+	}{
+		{
+			"no default",
+			&runner.Option{Name: "foo", Usage: "a value"},
+			"a value",
+		},
+		{
+			"literal",
+			&runner.Option{
+				Name:          "foo",
+				Usage:         "a value",
+				DefaultValues: runner.ValueList{{Value: "bar"}},
+			},
+			`a value (default: "bar")`,
+		},
+		{
+			"command",
+			&runner.Option{
+				Name:          "foo",
+				Usage:         "a value",
+				DefaultValues: runner.ValueList{{Command: "echo bar"}},
+			},
+			"a value (default from a command)",
+		},
+		{
+			"from task",
+			&runner.Option{
+				Name:          "foo",
+				Usage:         "a value",
+				DefaultValues: runner.ValueList{{FromTask: &runner.FromTask{Task: "setup"}}},
+			},
+			`a value (default from task "setup")`,
+		},
+		{
+			"environment",
+			&runner.Option{
+				Name:          "foo",
+				Usage:         "a value",
+				Environment:   "FOO",
+				DefaultValues: runner.ValueList{{Value: "bar"}},
+			},
+			`a value (default from $FOO, then "bar")`,
+		},
+		{
+			"conditional",
+			&runner.Option{
+				Name: "foo",
+				DefaultValues: runner.ValueList{
+					{When: runner.WhenList{{}}, Value: "bar"},
+					{URL: "https://example.com"},
+				},
+			},
+			`(default: "bar" when conditions match, then from a url)`,
+		},
+		{
+			"secret",
+			&runner.Option{
+				Name:          "foo",
+				Usage:         "a value",
+				Secret:        true,
+				DefaultValues: runner.ValueList{{Value: "bar"}},
+			},
+			`a value (default: "****")`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flag, err := createCLIFlag(tt.opt)
+			if err != nil {
+				t.Fatalf("createCLIFlag(): unexpected err: %s", err)
+			}
+
+			if got := getDescription(flag); got != tt.want {
+				t.Errorf("createCLIFlag(): want usage %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
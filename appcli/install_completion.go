@@ -197,7 +197,7 @@ func installFileInDir(dir, file string, content []byte) error {
 		return err
 	}
 
-	ui.Info("Tab completion successfully installed", target)
+	ui.Info("Tab completion successfully installed", ui.LinkPath(target))
 	ui.Info("You may need to restart your shell for completion to take effect")
 	return nil
 }
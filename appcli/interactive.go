@@ -0,0 +1,169 @@
+package appcli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+
+	"github.com/rliebz/tusk/runner"
+)
+
+// isInteractive allows overwriting during tests.
+var isInteractive = func() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stdout.Fd())
+}
+
+// selectInput allows overwriting during tests.
+var selectInput io.Reader = os.Stdin
+
+// createSelectAction creates an action that prompts for a task to run when
+// none is given, then runs it with the original arguments.
+func createSelectAction(
+	args []string, meta *runner.Metadata, cfg *runner.Config,
+) func(*cli.Context) error {
+	return func(c *cli.Context) error {
+		if c.NArg() > 0 {
+			return cli.ShowCommandHelp(c, c.Args().First())
+		}
+
+		if len(c.App.Commands) == 0 {
+			return cli.ShowAppHelp(c)
+		}
+
+		selected, err := selectTask(cfg, c.App, bufio.NewReader(selectInput), c.App.Writer)
+		if err != nil {
+			return err
+		}
+
+		taskArgs := append(append([]string{}, args...), selected...)
+		app, err := NewApp(taskArgs, meta)
+		if err != nil {
+			return err
+		}
+
+		return app.Run(taskArgs)
+	}
+}
+
+// selectTask prompts for a task from the commands available, then for the
+// values of its args and required options. It returns the arguments needed to
+// run the selected task.
+func selectTask(cfg *runner.Config, app *cli.App, r *bufio.Reader, w io.Writer) ([]string, error) {
+	fmt.Fprintln(w, "Tasks:")
+	for i, cmd := range app.Commands {
+		fmt.Fprintf(w, "  %d) %s", i+1, cmd.Name)
+		if cmd.Usage != "" {
+			fmt.Fprintf(w, " - %s", cmd.Usage)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "Select a task [1-%d]: ", len(app.Commands))
+	answer, err := readAnswer(r)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := lookupCommand(app.Commands, answer)
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := cfg.Tasks[name]
+	if !ok {
+		return nil, fmt.Errorf("task %q not found", name)
+	}
+
+	selected := []string{name}
+
+	for _, arg := range t.Args {
+		if arg.Default != nil {
+			// Args with defaults are last, so the rest can be left unset
+			fmt.Fprintf(w, "Value for %s [%s]: ", arg.Name, *arg.Default)
+			value, err := readLine(r)
+			if err != nil {
+				return nil, err
+			}
+			if value == "" {
+				break
+			}
+
+			selected = append(selected, value)
+			continue
+		}
+
+		fmt.Fprintf(w, "Value for %s: ", arg.Name)
+		value, err := readAnswer(r)
+		if err != nil {
+			return nil, err
+		}
+
+		selected = append(selected, value)
+	}
+
+	options, err := runner.FindAllOptions(t, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, opt := range options {
+		if !opt.Required || opt.Private {
+			continue
+		}
+
+		fmt.Fprintf(w, "Value for --%s: ", opt.Name)
+		value, err := readAnswer(r)
+		if err != nil {
+			return nil, err
+		}
+
+		selected = append(selected, "--"+opt.Name, value)
+	}
+
+	return selected, nil
+}
+
+// lookupCommand finds a command by its number in the list, its name, or a
+// unique prefix of its name.
+func lookupCommand(commands []cli.Command, answer string) (string, error) {
+	if i, err := strconv.Atoi(answer); err == nil {
+		if i < 1 || i > len(commands) {
+			return "", fmt.Errorf("selection %d is out of range", i)
+		}
+
+		return commands[i-1].Name, nil
+	}
+
+	return matchCommand(commands, answer)
+}
+
+// readAnswer reads a single non-empty line of input.
+func readAnswer(r *bufio.Reader) (string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return "", err
+	}
+
+	if line == "" {
+		return "", errors.New("no value entered")
+	}
+
+	return line, nil
+}
+
+// readLine reads a single line of input, which may be empty.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", errors.Wrap(err, "reading selection")
+	}
+
+	return strings.TrimSpace(line), nil
+}
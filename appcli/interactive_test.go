@@ -0,0 +1,189 @@
+package appcli
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rliebz/tusk/runner"
+	"gotest.tools/v3/fs"
+)
+
+var selectCfgText = []byte(`
+options:
+  target:
+    required: true
+tasks:
+  build:
+    usage: Build the project
+    run: exit 1
+  bump:
+    run: exit 1
+  deploy:
+    args:
+      env:
+        usage: The environment
+    run: echo ${env} ${target}
+  hidden:
+    private: true
+    run: exit 1
+`)
+
+func TestSelectTask(t *testing.T) {
+	cfg, err := runner.Parse(selectCfgText)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app, err := NewApp([]string{"tusk"}, &runner.Metadata{CfgText: selectCfgText})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"1\n", []string{"build"}},
+		{"bump\n", []string{"bump"}},
+		{"d\nprod\nus-east\n", []string{"deploy", "prod", "--target", "us-east"}},
+		{"3\nprod\nus-east", []string{"deploy", "prod", "--target", "us-east"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := bufio.NewReader(strings.NewReader(tt.input))
+
+			got, err := selectTask(cfg, app, r, &buf)
+			if err != nil {
+				t.Fatalf("selectTask(): unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("selectTask(): want %q, got %q", tt.want, got)
+			}
+
+			if strings.Contains(buf.String(), "hidden") {
+				t.Errorf("selectTask(): private task listed in output:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func TestSelectTask_invalid(t *testing.T) {
+	cfg, err := runner.Parse(selectCfgText)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app, err := NewApp([]string{"tusk"}, &runner.Metadata{CfgText: selectCfgText})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", "no value entered"},
+		{"0\n", "out of range"},
+		{"4\n", "out of range"},
+		{"b\n", "matches multiple tasks"},
+		{"hidden\n", "no task matches"},
+		{"deploy\n\n", "no value entered"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := bufio.NewReader(strings.NewReader(tt.input))
+
+			_, err := selectTask(cfg, app, r, &buf)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("selectTask(): want error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestNewApp_select(t *testing.T) {
+	defer func(f func() bool) { isInteractive = f }(isInteractive)
+	defer func(r io.Reader) { selectInput = r }(selectInput)
+
+	dir := fs.NewDir(t, "select")
+	defer dir.Remove()
+
+	cfgText := []byte(`
+tasks:
+  create:
+    args:
+      name:
+        usage: The file to create
+    run: touch ` + dir.Path() + `/${name}
+`)
+
+	isInteractive = func() bool { return true }
+	selectInput = strings.NewReader("create\nselected\n")
+
+	args := []string{"tusk"}
+	app, err := NewApp(args, &runner.Metadata{CfgText: cfgText})
+	if err != nil {
+		t.Fatalf("NewApp(): unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	app.Writer = &buf
+
+	if err := app.Run(args); err != nil {
+		t.Fatalf("app.Run(%v): unexpected error: %v", args, err)
+	}
+
+	if _, err := os.Stat(dir.Join("selected")); err != nil {
+		t.Errorf("selected task did not run: %v\noutput:\n%s", err, buf.String())
+	}
+}
+
+func TestNewApp_select_fallback(t *testing.T) {
+	defer func(f func() bool) { isInteractive = f }(isInteractive)
+
+	tests := []struct {
+		desc        string
+		interactive bool
+		meta        *runner.Metadata
+	}{
+		{"not a tty", false, &runner.Metadata{CfgText: selectCfgText}},
+		{"no-interactive", true, &runner.Metadata{CfgText: selectCfgText, NoInteractive: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			isInteractive = func() bool { return tt.interactive }
+
+			args := []string{"tusk"}
+			app, err := NewApp(args, tt.meta)
+			if err != nil {
+				t.Fatalf("NewApp(): unexpected error: %v", err)
+			}
+
+			var buf bytes.Buffer
+			app.Writer = &buf
+
+			if err := app.Run(args); err != nil {
+				t.Fatalf("app.Run(%v): unexpected error: %v", args, err)
+			}
+
+			if !strings.Contains(buf.String(), "Build the project") {
+				t.Errorf("app.Run(%v): want task listing, got:\n%s", args, buf.String())
+			}
+
+			if strings.Contains(buf.String(), "Select a task") {
+				t.Errorf("app.Run(%v): unexpected prompt:\n%s", args, buf.String())
+			}
+		})
+	}
+}
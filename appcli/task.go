@@ -1,7 +1,9 @@
 package appcli
 
 import (
+	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/urfave/cli"
@@ -39,3 +41,45 @@ func addTask(app *cli.App, cfg *runner.Config, t *runner.Task, create commandCre
 
 	return nil
 }
+
+// matchCommand finds a command by its name, or by a unique prefix of its name.
+func matchCommand(commands []cli.Command, name string) (string, error) {
+	matches := matchingCommands(commands, name)
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no task matches %q", name)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf(
+			"%q matches multiple tasks: %s", name, strings.Join(matches, ", "),
+		)
+	}
+}
+
+// matchingCommands returns the names of the commands that start with a name.
+// An exact match always takes priority over prefixes.
+func matchingCommands(commands []cli.Command, name string) []string {
+	var matches []string
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return []string{cmd.Name}
+		}
+
+		if strings.HasPrefix(cmd.Name, name) {
+			matches = append(matches, cmd.Name)
+		}
+	}
+
+	return matches
+}
+
+// addAbbreviation allows a task to be run by an abbreviation of its name.
+func addAbbreviation(app *cli.App, name, abbreviation string) {
+	for i := range app.Commands {
+		if app.Commands[i].Name == name {
+			app.Commands[i].Aliases = append(app.Commands[i].Aliases, abbreviation)
+		}
+	}
+}
@@ -8,7 +8,7 @@ require (
 	github.com/google/go-cmp v0.3.1
 	github.com/kr/pretty v0.1.0 // indirect
 	github.com/mattn/go-colorable v0.1.4 // indirect
-	github.com/mattn/go-isatty v0.0.10 // indirect
+	github.com/mattn/go-isatty v0.0.10
 	github.com/pkg/errors v0.8.1
 	github.com/urfave/cli v1.22.2
 	golang.org/x/sys v0.0.0-20191010194322-b09406accb47 // indirect
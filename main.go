@@ -6,7 +6,9 @@ import (
 	"os/exec"
 	"syscall"
 
+	"github.com/pkg/errors"
 	"github.com/rliebz/tusk/appcli"
+	"github.com/rliebz/tusk/runner"
 	"github.com/rliebz/tusk/ui"
 	"github.com/urfave/cli"
 )
@@ -45,6 +47,10 @@ func run(args []string) (exitStatus int, err error) {
 	if ui.Verbosity != ui.VerbosityLevelSilent {
 		ui.Verbosity = meta.Verbosity
 	}
+	ui.Hyperlinks = ui.DetectHyperlinks()
+	ui.SummaryOnly = meta.SummaryOnly
+
+	meta.Version = version
 
 	switch {
 	case meta.InstallCompletion != "":
@@ -53,13 +59,29 @@ func run(args []string) (exitStatus int, err error) {
 		return 0, appcli.UninstallCompletion(meta.UninstallCompletion)
 	case meta.PrintVersion && !meta.PrintHelp:
 		ui.Println(version)
+		if meta.CheckOnly {
+			runner.CheckForUpdate(ui.LoggerStdout.Writer(), version)
+		}
 		return 0, nil
 	}
 
+	if meta.CaptureOutput != "" {
+		var f *os.File
+		if f, err = os.Create(meta.CaptureOutput); err != nil {
+			return 1, err
+		}
+		defer f.Close() // nolint: errcheck
+		defer ui.Capture(f)()
+	}
+
 	if err = os.Chdir(meta.Directory); err != nil {
 		return 1, err
 	}
 
+	if meta.Docs != "" && !meta.PrintHelp {
+		return 0, appcli.WriteDocs(ui.LoggerStdout.Writer(), meta.Docs, meta.CfgText)
+	}
+
 	app, err := appcli.NewApp(args, meta)
 	if err != nil {
 		return 1, err
@@ -73,9 +95,21 @@ func run(args []string) (exitStatus int, err error) {
 	return runApp(app, args)
 }
 
+// interruptedStatus is the exit status used when a run is interrupted, which
+// follows the shell convention of 128 plus the signal number.
+const interruptedStatus = 130
+
 func runApp(app *cli.App, args []string) (int, error) {
 	if err := app.Run(args); err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
+		if runner.IsInterrupted(err) {
+			return interruptedStatus, err
+		}
+
+		if status, ok := runner.ExitStatus(err); ok {
+			return status, err
+		}
+
+		if exitErr, ok := errors.Cause(err).(*exec.ExitError); ok {
 			if ui.Verbosity < ui.VerbosityLevelVerbose {
 				err = nil
 			}
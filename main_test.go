@@ -2,14 +2,17 @@ package main
 
 import (
 	"bytes"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
 	"text/template"
 
+	"github.com/fatih/color"
 	"github.com/rliebz/tusk/ui"
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
 )
 
 func TestRun_printVersion(t *testing.T) {
@@ -50,12 +53,40 @@ Tasks:
    tidy       Clean up and format the repo
 
 Global Options:
-   -f, --file <file>  Set file to use as the config file
-   -h, --help         Show help and exit
-   -q, --quiet        Only print command output and application errors
-   -s, --silent       Print no output
-   -V, --version      Print version and exit
-   -v, --verbose      Print verbose output
+       --allow-network            Allow option values to be read from a url
+       --artifacts                Print the artifacts a task produces without running it
+       --capture-output <file>    Save a copy of all output to a file
+       --check                    Evaluate conditions and options without running commands
+       --docs <format>            Print documentation for all tasks in a format (markdown, json)
+       --env-dump-on-failure      Print the environment of commands that fail
+       --explain-option <name>    Print how the option name gets its value for a task
+       --export-options <file>    Write the option values for a task to an env-file without running it
+   -f, --file <file>              Set file to use as the config file
+       --fail-on <list>           Treat a comma-separated list of warning categories as failures (budget, duplicate-task, line-buffering, undeclared-variable, unknown-field)
+       --fail-on-budget           Fail tasks that take longer than their time budget
+   -h, --help                     Show help and exit
+       --ignore-version           Run even if the config requires a newer version of tusk
+       --layered-defaults         Read option defaults for a task from tusk.<task>.yml beside the config file
+       --list-changed-options     Print the options for a task that differ from their defaults without running it
+       --mask-secrets             Mask secret option values written by --export-options
+       --matrix <list>            Run a task for every combination of option values in a list such as "a=1,2 b=3,4"
+       --matrix-parallel          Run every combination of a matrix at the same time
+       --max-parallel <number>    Run at most a number of the children of each parallel block at once
+       --no-cleanup-on-interrupt  Skip the finally steps of tasks when interrupted
+       --no-env-inherit           Run commands with only the environment variables set by tasks
+       --no-interactive           Print help instead of prompting for a task when none is given
+       --on-failure <task>        Run a task after the task being run fails
+       --parallel-order <order>   Launch the children of parallel blocks in an order (declared, random)
+   -q, --quiet                    Only print command output and application errors
+       --run-dir <dir>            Save the output of each step to a file in a dir
+   -s, --silent                   Print no output
+       --seed <number>            Set the number used to shuffle parallel children with --parallel-order random
+       --summary-only             Print only a summary of the run and the output of commands that fail
+   -V, --version                  Print version and exit
+   -v, --verbose                  Print verbose output
+       --verbose-errors           Print the end of a command's stderr when it fails, even with --silent
+       --which                    Print the file where a task is defined
+       --yes                      Run tasks that require confirmation without prompting
 `
 
 	tpl := template.Must(template.New("help").Parse(message))
@@ -70,6 +101,18 @@ Global Options:
 	assert.Check(t, status == 0)
 }
 
+func TestRun_docs(t *testing.T) {
+	stdout, _, cleanup := setupTestSandbox(t)
+	defer cleanup()
+
+	args := []string{"tusk", "-f", "./testdata/tusk.yml", "--docs", "markdown"}
+	status, err := run(args)
+	assert.NilError(t, err)
+
+	assert.Check(t, cmp.Contains(stdout.String(), "## exit\n"))
+	assert.Check(t, status == 0)
+}
+
 func TestRun_exitCodeZero(t *testing.T) {
 	_, stderr, cleanup := setupTestSandbox(t)
 	defer cleanup()
@@ -105,6 +148,89 @@ exit status 5
 	assert.Check(t, cmp.Equal(status, 5))
 }
 
+func TestRun_continueOnError(t *testing.T) {
+	_, stderr, cleanup := setupTestSandbox(t)
+	defer cleanup()
+
+	dir := fs.NewDir(t, "gate", fs.WithFile("tusk.yml", `
+tasks:
+  gate:
+    continue-on-error: true
+    run:
+      - name: lint
+        command: exit 0
+      - name: test
+        command: exit 3
+      - name: docs
+        command: exit 0
+`))
+	defer dir.Remove()
+
+	args := []string{"tusk", "-f", dir.Join("tusk.yml"), "gate"}
+	status, err := run(args)
+	assert.Error(t, err, "1 of 3 steps failed")
+	assert.Check(t, cmp.Equal(status, 3))
+
+	assert.Check(t, cmp.Contains(stderr.String(), `Summary: gate
+ => passed lint
+ => failed test (exit status 3)
+ => passed docs
+`))
+}
+
+func TestRun_matrixExitCode(t *testing.T) {
+	_, _, cleanup := setupTestSandbox(t)
+	defer cleanup()
+
+	dir := fs.NewDir(t, "matrix", fs.WithFile("tusk.yml", `
+tasks:
+  deploy:
+    options:
+      env: {}
+    matrix:
+      env: [dev, prod]
+    run:
+      - when:
+          equal: {env: prod}
+        abort:
+          message: prod is frozen
+          exit-code: 4
+`))
+	defer dir.Remove()
+
+	args := []string{"tusk", "-f", dir.Join("tusk.yml"), "deploy"}
+	status, err := run(args)
+	assert.Error(t, err, "matrix env=prod: prod is frozen")
+	assert.Check(t, cmp.Equal(status, 4))
+}
+
+func TestRun_captureOutput(t *testing.T) {
+	_, stderr, cleanup := setupTestSandbox(t)
+	defer cleanup()
+
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	dir := fs.NewDir(t, "capture", fs.WithFile("tusk.yml", `
+tasks:
+  greet:
+    run: echo hello
+`))
+	defer dir.Remove()
+
+	transcript := dir.Join("transcript.log")
+	args := []string{"tusk", "-f", dir.Join("tusk.yml"), "--capture-output", transcript, "greet"}
+	status, err := run(args)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(status, 0))
+
+	assert.Check(t, cmp.Contains(stderr.String(), "\x1b["))
+
+	contents, err := ioutil.ReadFile(transcript)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(string(contents), "greet $ echo hello\nhello\n"))
+}
+
 func TestRun_incorrectUsage(t *testing.T) {
 	_, _, cleanup := setupTestSandbox(t)
 	defer cleanup()
@@ -2,6 +2,8 @@ package marshal
 
 import (
 	"errors"
+	"regexp"
+	"strconv"
 
 	yaml "gopkg.in/yaml.v2"
 )
@@ -26,15 +28,27 @@ type UnmarshalCandidate struct {
 
 // UnmarshalOneOf unmarshals candidates of different types until successful.
 // If any error other than a yaml.TypeError is thrown, that error is returned
-// immediately. If no candidates are valid, the error from the last candidate
-// passed will be returned.
+// immediately. If no candidates are valid and some only failed because of
+// fields not defined, the error from the candidate the value was closest to is
+// returned. Otherwise, the error from the last candidate passed will be
+// returned.
 func UnmarshalOneOf(candidates ...UnmarshalCandidate) error {
 	err := errors.New("no candidates passed")
+	var unknownFieldErr *yaml.TypeError
+	var unknownFieldLine int
 
 	for _, c := range candidates {
 		if err = c.Unmarshal(); err != nil {
 			// TypeErrors are expected; try the next candidate
-			if _, ok := err.(*yaml.TypeError); ok {
+			if typeErr, ok := err.(*yaml.TypeError); ok {
+				line, ok := lastUnknownFieldLine(typeErr)
+				if ok && isCloser(typeErr, line, unknownFieldErr, unknownFieldLine) {
+					// The errors share memory with ones from later candidates
+					unknownFieldErr = &yaml.TypeError{
+						Errors: append([]string(nil), typeErr.Errors...),
+					}
+					unknownFieldLine = line
+				}
 				continue
 			}
 
@@ -54,5 +68,50 @@ func UnmarshalOneOf(candidates ...UnmarshalCandidate) error {
 		return nil
 	}
 
+	if unknownFieldErr != nil {
+		return unknownFieldErr
+	}
+
 	return err
 }
+
+// unknownFieldPattern matches the errors for fields that are not defined.
+var unknownFieldPattern = regexp.MustCompile(`^line (\d+): field .+ not found in type `)
+
+// lastUnknownFieldLine returns the last line with a field that is not defined,
+// or false if any error is for another reason.
+func lastUnknownFieldLine(err *yaml.TypeError) (int, bool) {
+	last := 0
+	for _, message := range err.Errors {
+		match := unknownFieldPattern.FindStringSubmatch(message)
+		if match == nil {
+			return 0, false
+		}
+
+		line, convErr := strconv.Atoi(match[1])
+		if convErr != nil {
+			return 0, false
+		}
+		if line > last {
+			last = line
+		}
+	}
+
+	return last, len(err.Errors) > 0
+}
+
+// isCloser checks if a value is closer to the shape of a candidate than to the
+// best one so far, which is when fewer fields are not defined. For the same
+// number of fields, a candidate that failed on a later line matched more of
+// the value before failing.
+func isCloser(err *yaml.TypeError, line int, best *yaml.TypeError, bestLine int) bool {
+	if best == nil {
+		return true
+	}
+
+	if len(err.Errors) != len(best.Errors) {
+		return len(err.Errors) < len(best.Errors)
+	}
+
+	return line > bestLine
+}
@@ -110,3 +110,64 @@ func TestOneOf_success(t *testing.T) {
 		t.Errorf(`OneOf(failed, invalid, success): unexpected error: %s`, err)
 	}
 }
+
+func createErrorsCandidate(messages ...string) UnmarshalCandidate {
+	return UnmarshalCandidate{
+		Unmarshal: func() error { return &yaml.TypeError{Errors: messages} },
+	}
+}
+
+func TestOneOf_unknown_fields(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []UnmarshalCandidate
+		want       string
+	}{
+		{
+			"other errors",
+			[]UnmarshalCandidate{
+				createErrorsCandidate("line 1: field foo not found in type a"),
+				createErrorsCandidate("line 1: cannot unmarshal !!map into string"),
+			},
+			"line 1: field foo not found in type a",
+		},
+		{
+			"fewest fields",
+			[]UnmarshalCandidate{
+				createErrorsCandidate(
+					"line 1: field foo not found in type a",
+					"line 2: field bar not found in type a",
+				),
+				createErrorsCandidate("line 1: field baz not found in type b"),
+				createErrorsCandidate(
+					"line 3: field qux not found in type c",
+					"line 4: cannot unmarshal !!map into string",
+				),
+			},
+			"line 1: field baz not found in type b",
+		},
+		{
+			"latest line",
+			[]UnmarshalCandidate{
+				createErrorsCandidate("line 1: field foo not found in type a"),
+				createErrorsCandidate("line 3: field bar not found in type b"),
+				createErrorsCandidate("line 2: field baz not found in type c"),
+			},
+			"line 3: field bar not found in type b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := UnmarshalOneOf(tt.candidates...)
+			typeErr, ok := err.(*yaml.TypeError)
+			if !ok {
+				t.Fatalf("OneOf(): want type error, got %v", err)
+			}
+
+			if len(typeErr.Errors) != 1 || typeErr.Errors[0] != tt.want {
+				t.Errorf("OneOf(): want error %q, got %q", tt.want, typeErr.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package marshal
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// envPattern matches references to environment variables, such as ${env.HOME}
+// or ${env.EDITOR:-vi}, which uses a default if the variable is not set.
+var envPattern = regexp.MustCompile(`\$\{env\.([A-Za-z_][A-Za-z0-9_]*)(:-[^}]*)?\}`)
+
+// interpolateEnv replaces all references to environment variables with their
+// values. Variables that are not set are an error, unless a default is given,
+// which is also used if the variable is empty.
+func interpolateEnv(text []byte) ([]byte, error) {
+	text = escapePattern(text)
+
+	var err error
+	text = envPattern.ReplaceAllFunc(text, func(match []byte) []byte {
+		if err != nil {
+			return match
+		}
+
+		groups := envPattern.FindSubmatch(match)
+		name := string(groups[1])
+
+		value, ok := os.LookupEnv(name)
+		hasDefault := len(groups[2]) > 0
+
+		// As in the shell, defaults are used for empty values as well
+		if hasDefault && value == "" {
+			return groups[2][len(":-"):]
+		}
+
+		if ok {
+			return []byte(value)
+		}
+
+		err = fmt.Errorf(
+			"environment variable %s is not set; use ${env.%s:-} to default to empty",
+			name, name,
+		)
+		return match
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return unescapePattern(text), nil
+}
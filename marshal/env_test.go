@@ -0,0 +1,61 @@
+package marshal
+
+import (
+	"os"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+func TestInterpolate_env(t *testing.T) {
+	assert.NilError(t, os.Setenv("TUSK_TEST_ENV_SET", "value"))
+	defer os.Unsetenv("TUSK_TEST_ENV_SET") // nolint: errcheck
+	assert.NilError(t, os.Setenv("TUSK_TEST_ENV_EMPTY", ""))
+	defer os.Unsetenv("TUSK_TEST_ENV_EMPTY") // nolint: errcheck
+	assert.NilError(t, os.Unsetenv("TUSK_TEST_ENV_UNSET"))
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"set: ${env.TUSK_TEST_ENV_SET}", "set: value"},
+		{"set with default: ${env.TUSK_TEST_ENV_SET:-other}", "set with default: value"},
+		{"empty: [${env.TUSK_TEST_ENV_EMPTY}]", "empty: []"},
+		{"empty with default: ${env.TUSK_TEST_ENV_EMPTY:-other}", "empty with default: other"},
+		{"unset: ${env.TUSK_TEST_ENV_UNSET:-fallback value}", "unset: fallback value"},
+		{"unset empty: [${env.TUSK_TEST_ENV_UNSET:-}]", "unset empty: []"},
+		{"escaped: $${env.TUSK_TEST_ENV_UNSET}", "escaped: ${env.TUSK_TEST_ENV_UNSET}"},
+		{"option: ${env}", "option: ${env}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			input := tt.input
+			err := Interpolate(&input, map[string]string{})
+			assert.NilError(t, err)
+			assert.Check(t, cmp.Equal(tt.want, input))
+		})
+	}
+}
+
+func TestInterpolate_env_unset(t *testing.T) {
+	assert.NilError(t, os.Unsetenv("TUSK_TEST_ENV_UNSET"))
+
+	input := "unset: ${env.TUSK_TEST_ENV_UNSET}"
+	err := Interpolate(&input, map[string]string{})
+	assert.Error(t, err,
+		"environment variable TUSK_TEST_ENV_UNSET is not set; "+
+			"use ${env.TUSK_TEST_ENV_UNSET:-} to default to empty",
+	)
+}
+
+func TestInterpolate_env_and_options(t *testing.T) {
+	assert.NilError(t, os.Setenv("TUSK_TEST_ENV_SET", "value"))
+	defer os.Unsetenv("TUSK_TEST_ENV_SET") // nolint: errcheck
+
+	input := "${greeting} ${env.TUSK_TEST_ENV_SET}"
+	err := Interpolate(&input, map[string]string{"greeting": "hello", "env": "ignored"})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal("hello value", input))
+}
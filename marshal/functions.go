@@ -0,0 +1,300 @@
+package marshal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// functionPattern matches interpolation function calls, such as
+// ${name(arg, "string")}. String arguments may contain escaped quotes.
+var functionPattern = regexp.MustCompile(
+	`\$\{([\w-]+)\(((?:"(?:[^"\\]|\\.)*"|[^)"])*)\)\}`,
+)
+
+// functionArg is a single argument passed to an interpolation function.
+type functionArg struct {
+	// text is either the literal text passed, or the unquoted string.
+	text string
+	// quoted is whether the argument was passed as a quoted string.
+	quoted bool
+}
+
+// function is an interpolation function. If the function cannot be evaluated
+// with the values available, it should return false so that the expression
+// is left as-is, to be interpolated later.
+type function func(values map[string]string, args []functionArg) (string, bool, error)
+
+var functions = map[string]function{
+	"each":   each,
+	"file":   file,
+	"printf": printf,
+}
+
+// interpolateFunctions replaces all function calls with their results.
+func interpolateFunctions(text []byte, values map[string]string) ([]byte, error) {
+	text = escapePattern(text)
+
+	var err error
+	text = functionPattern.ReplaceAllFunc(text, func(match []byte) []byte {
+		if err != nil {
+			return match
+		}
+
+		groups := functionPattern.FindSubmatch(match)
+		name := string(groups[1])
+
+		f, ok := functions[name]
+		if !ok {
+			return match
+		}
+
+		args, perr := parseFunctionArgs(string(groups[2]))
+		if perr != nil {
+			err = errors.Wrapf(perr, "interpolating %s", match)
+			return match
+		}
+
+		result, ok, ferr := f(values, args)
+		if ferr != nil {
+			err = errors.Wrapf(ferr, "interpolating %s", match)
+			return match
+		}
+
+		if !ok {
+			return match
+		}
+
+		return []byte(result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return unescapePattern(text), nil
+}
+
+// parseFunctionArgs parses a comma-separated list of arguments.
+func parseFunctionArgs(text string) ([]functionArg, error) {
+	var args []functionArg
+
+	text = strings.TrimSpace(text)
+	for text != "" {
+		var arg functionArg
+
+		if strings.HasPrefix(text, `"`) {
+			end := closingQuote(text)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string: %s", text)
+			}
+
+			unquoted, err := strconv.Unquote(text[:end+1])
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid string %s", text[:end+1])
+			}
+
+			arg = functionArg{text: unquoted, quoted: true}
+			text = strings.TrimSpace(text[end+1:])
+		} else {
+			end := strings.Index(text, ",")
+			if end < 0 {
+				end = len(text)
+			}
+
+			arg = functionArg{text: strings.TrimSpace(text[:end])}
+			text = text[end:]
+		}
+
+		args = append(args, arg)
+
+		if text == "" {
+			break
+		}
+
+		if !strings.HasPrefix(text, ",") {
+			return nil, fmt.Errorf("expected comma before %s", text)
+		}
+		text = strings.TrimSpace(text[1:])
+	}
+
+	return args, nil
+}
+
+// closingQuote returns the index of the quote ending a quoted string.
+func closingQuote(text string) int {
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+
+	return -1
+}
+
+// variableFunctions are the functions whose bare arguments are variable
+// names. The path passed to file is not a variable, for example.
+var variableFunctions = map[string]bool{
+	"each":   true,
+	"printf": true,
+}
+
+// findFunctionVariables returns the bare arguments of all function calls that
+// take variables, which may be variable names.
+func findFunctionVariables(text []byte) []string {
+	var names []string
+
+	for _, groups := range functionPattern.FindAllSubmatch(text, -1) {
+		if !variableFunctions[string(groups[1])] {
+			continue
+		}
+
+		args, err := parseFunctionArgs(string(groups[2]))
+		if err != nil {
+			continue
+		}
+
+		for _, arg := range args {
+			if !arg.quoted && arg.text != "" {
+				names = append(names, arg.text)
+			}
+		}
+	}
+
+	return names
+}
+
+// each expands a comma-separated list into a series of shell words with a
+// prefix, such as ${each(tags, "--tag ")}.
+func each(values map[string]string, args []functionArg) (string, bool, error) {
+	if len(args) != 2 || args[0].quoted || !args[1].quoted {
+		return "", false, fmt.Errorf(
+			`each requires a variable name and a quoted prefix, such as each(name, "--flag ")`,
+		)
+	}
+
+	list, ok := values[args[0].text]
+	if !ok {
+		return "", false, nil
+	}
+
+	words := make([]string, 0, strings.Count(list, ",")+1)
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		words = append(words, args[1].text+shellQuote(item))
+	}
+
+	return strings.Join(words, " "), true, nil
+}
+
+// file reads the contents of a file, with surrounding whitespace trimmed,
+// such as ${file(VERSION)}. Relative paths are relative to the config file.
+func file(_ map[string]string, args []functionArg) (string, bool, error) {
+	if len(args) != 1 || args[0].text == "" {
+		return "", false, fmt.Errorf("file requires a single path, such as file(VERSION)")
+	}
+
+	contents, err := ioutil.ReadFile(args[0].text)
+	if err != nil {
+		return "", false, errors.Wrap(err, "reading file")
+	}
+
+	return strings.TrimSpace(string(contents)), true, nil
+}
+
+// printfVerbPattern matches a single verb in a printf format, with optional
+// flags, width, and precision.
+var printfVerbPattern = regexp.MustCompile(`%[-+# 0]*\d*(?:\.\d*)?(.|$)`)
+
+// printf formats values with a format string, such as ${printf("%05d", build)}.
+// Each verb must match the type of its argument: %d, %x, %o, and %b take
+// integers, %f, %e, and %g take numbers, %t takes booleans, and %s, %q, and
+// %v take any value.
+func printf(values map[string]string, args []functionArg) (string, bool, error) {
+	if len(args) == 0 || !args[0].quoted {
+		return "", false, fmt.Errorf(
+			`printf requires a quoted format and its arguments, such as printf("%%05d", name)`,
+		)
+	}
+
+	var verbs []string
+	for _, groups := range printfVerbPattern.FindAllStringSubmatch(args[0].text, -1) {
+		if groups[1] != "%" {
+			verbs = append(verbs, groups[1])
+		}
+	}
+
+	if len(verbs) != len(args)-1 {
+		return "", false, fmt.Errorf(
+			"printf format expects %d arguments, but %d were passed", len(verbs), len(args)-1,
+		)
+	}
+
+	operands := make([]interface{}, 0, len(verbs))
+	for i, arg := range args[1:] {
+		value := arg.text
+		if !arg.quoted {
+			var ok bool
+			if value, ok = values[arg.text]; !ok {
+				return "", false, nil
+			}
+		}
+
+		operand, err := printfOperand(verbs[i], value)
+		if err != nil {
+			return "", false, errors.Wrapf(err, "printf argument %d", i+1)
+		}
+		operands = append(operands, operand)
+	}
+
+	return fmt.Sprintf(args[0].text, operands...), true, nil
+}
+
+// printfOperand converts a value to the type required by a printf verb.
+func printfOperand(verb, value string) (interface{}, error) {
+	switch verb {
+	case "d", "x", "X", "o", "b":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%%%s requires an integer, got %q", verb, value)
+		}
+		return n, nil
+	case "f", "F", "e", "E", "g", "G":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%%%s requires a number, got %q", verb, value)
+		}
+		return n, nil
+	case "t":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%%t requires a boolean, got %q", value)
+		}
+		return b, nil
+	case "s", "q", "v":
+		return value, nil
+	default:
+		return nil, fmt.Errorf("unsupported verb %%%s", verb)
+	}
+}
+
+var shellSafePattern = regexp.MustCompile(`^[\w@%+=:./-]+$`)
+
+// shellQuote quotes a string for use as a single word in a POSIX shell.
+func shellQuote(s string) string {
+	if shellSafePattern.MatchString(s) {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
@@ -0,0 +1,180 @@
+package marshal
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func TestInterpolateFunctions_each(t *testing.T) {
+	vars := map[string]string{
+		"many":   "a,b, c",
+		"one":    "latest",
+		"empty":  "",
+		"spaces": "hello world,it's",
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`build ${each(many, "--tag ")}`, "build --tag a --tag b --tag c"},
+		{`build ${each(one, "--tag ")}`, "build --tag latest"},
+		{`build ${each(empty, "--tag ")}`, "build "},
+		{`${each(spaces, "-m=")}`, `-m='hello world' -m='it'"'"'s'`},
+		{`${each(one, "--tag=")}`, "--tag=latest"},
+		{`${each(one,"\"")}`, `"latest`},
+		{`${each(missing, "--tag ")}`, `${each(missing, "--tag ")}`},
+		{`${unknown(one)}`, `${unknown(one)}`},
+		{`$${each(one, "--tag ")}`, `$${each(one, "--tag ")}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			actual, err := mapInterpolate([]byte(tt.input), vars)
+			assert.NilError(t, err)
+
+			assert.Check(t, cmp.Equal(tt.want, string(actual)))
+		})
+	}
+}
+
+func TestInterpolateFunctions_each_invalid(t *testing.T) {
+	tests := []string{
+		`${each(one)}`,
+		`${each("one", "--tag ")}`,
+		`${each(one, --tag)}`,
+		`${each(one, "a" "b")}`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			_, err := mapInterpolate([]byte(input), map[string]string{"one": "a"})
+			assert.Check(t, err != nil)
+		})
+	}
+}
+
+func TestInterpolateFunctions_file(t *testing.T) {
+	dir := fs.NewDir(t, "tusk-file",
+		fs.WithFile("VERSION", "1.2.3\n"),
+		fs.WithDir("nested", fs.WithFile("name", "  tusk  ")),
+	)
+	defer dir.Remove()
+
+	wd, err := os.Getwd()
+	assert.NilError(t, err)
+	defer func() { assert.NilError(t, os.Chdir(wd)) }()
+	assert.NilError(t, os.Chdir(dir.Path()))
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`release ${file(VERSION)}`, "release 1.2.3"},
+		{`release ${file("VERSION")}`, "release 1.2.3"},
+		{`${file(nested/name)}-${file(VERSION)}`, "tusk-1.2.3"},
+		{`$${file(VERSION)}`, `$${file(VERSION)}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			actual, err := mapInterpolate([]byte(tt.input), map[string]string{})
+			assert.NilError(t, err)
+
+			assert.Check(t, cmp.Equal(tt.want, string(actual)))
+		})
+	}
+}
+
+func TestInterpolateFunctions_file_invalid(t *testing.T) {
+	dir := fs.NewDir(t, "tusk-file")
+	defer dir.Remove()
+
+	_, err := mapInterpolate([]byte(`${file()}`), map[string]string{})
+	assert.ErrorContains(t, err, "file requires a single path")
+
+	missing := dir.Join("MISSING")
+	_, err = mapInterpolate([]byte(fmt.Sprintf(`${file(%q)}`, missing)), map[string]string{})
+	assert.ErrorContains(t, err, "reading file")
+	assert.ErrorContains(t, err, missing)
+}
+
+func TestInterpolateFunctions_printf(t *testing.T) {
+	vars := map[string]string{
+		"build":   "42",
+		"name":    "api",
+		"ratio":   "0.5",
+		"enabled": "true",
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`${printf("%05d", build)}`, "00042"},
+		{`${printf("%-6s|", name)}`, "api   |"},
+		{`${printf("%s-%03d", name, build)}`, "api-042"},
+		{`${printf("%x", "255")}`, "ff"},
+		{`${printf("%.2f%%", ratio)}`, "0.50%"},
+		{`${printf("%t", enabled)}`, "true"},
+		{`${printf("no verbs")}`, "no verbs"},
+		{`${printf("%d", missing)}`, `${printf("%d", missing)}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			actual, err := mapInterpolate([]byte(tt.input), vars)
+			assert.NilError(t, err)
+
+			assert.Check(t, cmp.Equal(tt.want, string(actual)))
+		})
+	}
+}
+
+func TestInterpolateFunctions_printf_invalid(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{`${printf("%d", name)}`, `printf argument 1: %d requires an integer, got "api"`},
+		{`${printf("%f", name)}`, `printf argument 1: %f requires a number, got "api"`},
+		{`${printf("%t", name)}`, `printf argument 1: %t requires a boolean, got "api"`},
+		{`${printf("%y", name)}`, `printf argument 1: unsupported verb %y`},
+		{`${printf("%s %s", name)}`, "printf format expects 2 arguments, but 1 were passed"},
+		{`${printf("%s")}`, "printf format expects 1 arguments, but 0 were passed"},
+		{`${printf(name)}`, "printf requires a quoted format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := mapInterpolate([]byte(tt.input), map[string]string{"name": "api"})
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestFindPotentialVariables_functions(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{`${each(tags, "--tag ")}`, []string{"tags"}},
+		{`${foo} ${each(tags, "a, b")}`, []string{"foo", "tags"}},
+		{`${each("tags", "--tag ")}`, []string{}},
+		{`${printf("%05d", build)}`, []string{"build"}},
+		{`v${file(VERSION)}`, []string{}},
+		{`${unknown(name)}`, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := FindPotentialVariables([]byte(tt.input))
+			assert.Check(t, cmp.DeepEqual(tt.want, got))
+		})
+	}
+}
@@ -11,22 +11,80 @@ import (
 var escSeq = []byte("{UNLIKELY_ESCAPE_SEQUENCE}")
 
 // Interpolate an arbitrary YAML-marshallable interface.
+//
+// Values are substituted in each string of the YAML representation, rather
+// than in the YAML text itself, so that values containing newlines or YAML
+// syntax are kept as-is.
 func Interpolate(i interface{}, values map[string]string) error {
-	text, err := yaml.Marshal(i)
+	text, err := yaml.Marshal(yaml.MapSlice{{Key: "value", Value: i}})
 	if err != nil {
 		return err
 	}
 
-	text, err = mapInterpolate(text, values)
+	// Decoding into a MapSlice preserves the order of all nested maps
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(text, &doc); err != nil {
+		return err
+	}
+
+	interpolated, err := interpolateValue(doc[0].Value, values)
 	if err != nil {
 		return err
 	}
 
-	text = escape(text)
+	text, err = yaml.Marshal(interpolated)
+	if err != nil {
+		return err
+	}
 
 	return yaml.UnmarshalStrict(text, i)
 }
 
+// interpolateValue interpolates every string in a decoded YAML value,
+// including the keys of maps.
+func interpolateValue(value interface{}, values map[string]string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		text, err := mapInterpolate([]byte(v), values)
+		if err != nil {
+			return nil, err
+		}
+
+		return string(escape(text)), nil
+	case []interface{}:
+		items := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			interpolated, err := interpolateValue(item, values)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, interpolated)
+		}
+
+		return items, nil
+	case yaml.MapSlice:
+		items := make(yaml.MapSlice, 0, len(v))
+		for _, item := range v {
+			key, err := interpolateValue(item.Key, values)
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := interpolateValue(item.Value, values)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, yaml.MapItem{Key: key, Value: value})
+		}
+
+		return items, nil
+	default:
+		return value, nil
+	}
+}
+
 // FindPotentialVariables returns a list of potential interpolation target names.
 func FindPotentialVariables(text []byte) []string {
 	re := regexp.MustCompile(`\${([\w-]+)}`)
@@ -38,7 +96,7 @@ func FindPotentialVariables(text []byte) []string {
 		names = append(names, group[1])
 	}
 
-	return names
+	return append(names, findFunctionVariables(text)...)
 }
 
 // escape escapes all instances of $$ with $.
@@ -62,8 +120,17 @@ func interpolate(text []byte, name, value string) ([]byte, error) {
 
 // mapInterpolate runs interpolation over a map from variable name to value.
 func mapInterpolate(text []byte, m map[string]string) ([]byte, error) {
+	text, err := interpolateEnv(text)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err = interpolateFunctions(text, m)
+	if err != nil {
+		return nil, err
+	}
+
 	for variable, value := range m {
-		var err error
 		text, err = interpolate(text, variable, value)
 		if err != nil {
 			return nil, err
@@ -3,6 +3,7 @@ package marshal
 import (
 	"testing"
 
+	yaml "gopkg.in/yaml.v2"
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/assert/cmp"
 )
@@ -48,6 +49,45 @@ func TestInterpolate_struct(t *testing.T) {
 	assert.Check(t, cmp.Equal(want, input))
 }
 
+func TestInterpolate_yaml_syntax(t *testing.T) {
+	values := map[string]string{
+		"pair":      "a: b",
+		"lines":     "one\ntwo",
+		"quoted":    `it's "quoted"`,
+		"separator": "---",
+	}
+
+	input := []string{"${pair}", "x ${lines} y", "say ${quoted}", "${separator}"}
+	want := []string{"a: b", "x one\ntwo y", `say it's "quoted"`, "---"}
+
+	err := Interpolate(&input, values)
+	assert.NilError(t, err)
+
+	assert.Check(t, cmp.DeepEqual(want, input))
+}
+
+func TestInterpolate_preserves_order(t *testing.T) {
+	values := map[string]string{"name": "foo"}
+
+	input := yaml.MapSlice{
+		{Key: "z", Value: []interface{}{
+			yaml.MapSlice{{Key: "b", Value: "${name}"}, {Key: "a", Value: 1}},
+		}},
+		{Key: "${name}", Value: true},
+	}
+	want := yaml.MapSlice{
+		{Key: "z", Value: []interface{}{
+			yaml.MapSlice{{Key: "b", Value: "foo"}, {Key: "a", Value: 1}},
+		}},
+		{Key: "foo", Value: true},
+	}
+
+	err := Interpolate(&input, values)
+	assert.NilError(t, err)
+
+	assert.Check(t, cmp.DeepEqual(want, input))
+}
+
 func TestEscape(t *testing.T) {
 	tests := []struct {
 		input string
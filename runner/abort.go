@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rliebz/tusk/marshal"
+)
+
+// defaultAbortExitCode is the exit code used when aborting if none is given.
+const defaultAbortExitCode = 1
+
+// Abort stops a task with a message, such as when a precondition is not met.
+type Abort struct {
+	Message  string `yaml:"message"`
+	ExitCode *int   `yaml:"exit-code,omitempty"`
+}
+
+// UnmarshalYAML allows a plain string to represent an abort with a message.
+func (a *Abort) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var message string
+	messageCandidate := marshal.UnmarshalCandidate{
+		Unmarshal: func() error { return unmarshal(&message) },
+		Assign:    func() { *a = Abort{Message: message} },
+	}
+
+	type abortType Abort // Use new type to avoid recursion
+	var abortItem abortType
+	abortCandidate := marshal.UnmarshalCandidate{
+		Unmarshal: func() error { return unmarshal(&abortItem) },
+		Assign:    func() { *a = Abort(abortItem) },
+		Validate: func() error {
+			if abortItem.Message == "" {
+				return errors.New("abort must define a message")
+			}
+
+			if code := abortItem.ExitCode; code != nil && (*code < 1 || *code > 255) {
+				return fmt.Errorf(
+					"abort exit-code must be between 1 and 255, got %d", *code,
+				)
+			}
+
+			return nil
+		},
+	}
+
+	return marshal.UnmarshalOneOf(messageCandidate, abortCandidate)
+}
+
+// abortError is returned by tasks that abort.
+type abortError struct {
+	message  string
+	exitCode int
+}
+
+func (e *abortError) Error() string {
+	return e.message
+}
+
+// ExitStatus returns the exit status for the run as a whole.
+func (e *abortError) ExitStatus() int {
+	return e.exitCode
+}
+
+// isAborted returns whether an error was caused by a task aborting.
+func isAborted(err error) bool {
+	_, ok := err.(*abortError)
+	return ok
+}
+
+func (t *Task) runAbort(r *Run) error {
+	if r.Abort == nil {
+		return nil
+	}
+
+	exitCode := defaultAbortExitCode
+	if r.Abort.ExitCode != nil {
+		exitCode = *r.Abort.ExitCode
+	}
+
+	return &abortError{message: r.Abort.Message, exitCode: exitCode}
+}
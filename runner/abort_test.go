@@ -0,0 +1,147 @@
+package runner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func TestTask_Execute_abort(t *testing.T) {
+	tests := []struct {
+		name       string
+		condition  string
+		abort      string
+		wantErr    string
+		wantStatus int
+		wantLog    string
+	}{
+		{
+			name:       "fires",
+			condition:  "exit 0",
+			abort:      "{message: run setup first, exit-code: 3}",
+			wantErr:    "run setup first",
+			wantStatus: 3,
+			wantLog:    "before\nfinally\n",
+		},
+		{
+			name:       "default exit code",
+			condition:  "exit 0",
+			abort:      "run setup first",
+			wantErr:    "run setup first",
+			wantStatus: 1,
+			wantLog:    "before\nfinally\n",
+		},
+		{
+			name:      "does not fire",
+			condition: "exit 1",
+			abort:     "{message: run setup first, exit-code: 3}",
+			wantLog:   "before\nafter\nfinally\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fs.NewDir(t, "abort")
+			defer dir.Remove()
+			log := filepath.Join(dir.Path(), "log")
+
+			input := fmt.Sprintf(`
+run:
+  - echo before >> %[1]s
+  - when: {command: %[2]s}
+    abort: %[3]s
+  - echo after >> %[1]s
+finally:
+  - echo finally >> %[1]s
+`, log, tt.condition, tt.abort)
+
+			var task Task
+			assert.NilError(t, yaml.UnmarshalStrict([]byte(input), &task))
+
+			err := task.Execute(RunContext{})
+
+			contents, rerr := ioutil.ReadFile(log)
+			assert.NilError(t, rerr)
+			assert.Check(t, cmp.Equal(string(contents), tt.wantLog))
+
+			if tt.wantErr == "" {
+				assert.NilError(t, err)
+				return
+			}
+
+			assert.Error(t, err, tt.wantErr)
+			status, ok := ExitStatus(err)
+			assert.Assert(t, ok)
+			assert.Equal(t, status, tt.wantStatus)
+		})
+	}
+}
+
+func TestTask_Execute_abort_continue_on_error(t *testing.T) {
+	input := `
+continue-on-error: true
+run:
+  - exit 2
+  - abort: stop here
+  - exit 0
+`
+
+	var task Task
+	assert.NilError(t, yaml.UnmarshalStrict([]byte(input), &task))
+
+	err := task.Execute(RunContext{})
+	assert.Error(t, err, "stop here")
+}
+
+func TestAbort_UnmarshalYAML_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"no message", "exit-code: 2", "abort must define a message"},
+		{"exit code", "{message: stop, exit-code: 256}", "abort exit-code must be between 1 and 255, got 256"},
+		{"exit code zero", "{message: stop, exit-code: 0}", "abort exit-code must be between 1 and 255, got 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a Abort
+			err := yaml.UnmarshalStrict([]byte(tt.input), &a)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestRun_UnmarshalYAML_abort_with_command(t *testing.T) {
+	var r Run
+	err := yaml.UnmarshalStrict([]byte("{abort: stop, command: echo}"), &r)
+	assert.ErrorContains(t, err, "only one action can be defined in `run`")
+}
+
+func TestParseComplete_abort_interpolates(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  deploy:
+    options:
+      env:
+        default: prod
+    run:
+      abort:
+        message: cannot deploy to ${env}
+        exit-code: 4
+`)
+
+	cfg, err := ParseComplete(&Metadata{CfgText: cfgText}, "deploy", nil, nil)
+	assert.NilError(t, err)
+
+	exitCode := 4
+	abort := cfg.Tasks["deploy"].RunList[0].Abort
+	assert.Check(t, cmp.DeepEqual(abort, &Abort{Message: "cannot deploy to prod", ExitCode: &exitCode}))
+}
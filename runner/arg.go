@@ -1,8 +1,9 @@
 package runner
 
 import (
+	"fmt"
+
 	"github.com/pkg/errors"
-	"github.com/rliebz/tusk/marshal"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -10,7 +11,8 @@ import (
 type Arg struct {
 	ValueWithList `yaml:",inline"`
 
-	Usage string
+	Usage   string
+	Default *string `yaml:",omitempty"`
 
 	// Computed members not specified in yaml file
 	Name   string `yaml:"-"`
@@ -33,6 +35,47 @@ func (a *Arg) Evaluate() (string, error) {
 // Args represents an ordered set of arguments as specified in the config.
 type Args []*Arg
 
+// Required returns the number of args that must be passed, which are those
+// without a default value.
+func (a Args) Required() int {
+	required := 0
+	for _, arg := range a {
+		if arg.Default == nil {
+			required++
+		}
+	}
+
+	return required
+}
+
+// DescribeCount describes how many args can be passed.
+func (a Args) DescribeCount() string {
+	if required := a.Required(); required != len(a) {
+		return fmt.Sprintf("between %d and %d", required, len(a))
+	}
+
+	return fmt.Sprintf("exactly %d", len(a))
+}
+
+// values returns the value of each arg by name, using the default values of
+// any args not passed. It returns false if too few or too many are passed.
+func (a Args) values(passed []string) (map[string]string, bool) {
+	if len(passed) < a.Required() || len(passed) > len(a) {
+		return nil, false
+	}
+
+	values := make(map[string]string, len(a))
+	for i, arg := range a {
+		if i < len(passed) {
+			values[arg.Name] = passed[i]
+		} else {
+			values[arg.Name] = *arg.Default
+		}
+	}
+
+	return values, true
+}
+
 // UnmarshalYAML unmarshals an ordered set of options and assigns names.
 func (a *Args) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var ms yaml.MapSlice
@@ -40,7 +83,14 @@ func (a *Args) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return err
 	}
 
-	args, err := getArgsWithOrder(ms)
+	// Args are unmarshaled as part of the same document, so that errors refer
+	// to lines of the config file
+	var values map[string]*Arg
+	if err := unmarshal(&values); err != nil {
+		return err
+	}
+
+	args, err := getArgsWithOrder(ms, values)
 	if err != nil {
 		return err
 	}
@@ -61,22 +111,35 @@ func (a *Args) Lookup(name string) (*Arg, bool) {
 	return nil, false
 }
 
-// getArgsWithOrder returns both the arg map and the ordered names.
-func getArgsWithOrder(ms yaml.MapSlice) ([]*Arg, error) {
+// getArgsWithOrder returns the args in order with names assigned.
+func getArgsWithOrder(ms yaml.MapSlice, values map[string]*Arg) ([]*Arg, error) {
 	args := make([]*Arg, 0, len(ms))
-	assign := func(name string, text []byte) error {
-		var arg Arg
-		if err := yaml.UnmarshalStrict(text, &arg); err != nil {
-			return err
+	for _, item := range ms {
+		name, ok := item.Key.(string)
+		if !ok {
+			return nil, fmt.Errorf("%q is not a valid key name", item.Key)
 		}
 
+		arg := values[name]
+		if arg == nil {
+			arg = new(Arg)
+		}
 		arg.Name = name
 
-		args = append(args, &arg)
+		if arg.Default != nil {
+			err := arg.validateSpecified(*arg.Default, "default of argument "+name)
+			if err != nil {
+				return nil, err
+			}
+		} else if len(args) > 0 && args[len(args)-1].Default != nil {
+			return nil, fmt.Errorf(
+				"argument %q must have a default, since it follows an argument with one",
+				name,
+			)
+		}
 
-		return nil
+		args = append(args, arg)
 	}
 
-	_, err := marshal.ParseOrderedMap(ms, assign)
-	return args, err
+	return args, nil
 }
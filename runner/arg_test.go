@@ -65,12 +65,13 @@ func TestEvaluate_nil(t *testing.T) {
 func TestGetArgsWithOrder(t *testing.T) {
 	name := "foo"
 	usage := "use me"
-	ms := yaml.MapSlice{
-		{Key: name, Value: &Arg{Usage: usage}},
-		{Key: "bar", Value: &Arg{Usage: "other usage"}},
+	ms := yaml.MapSlice{{Key: name}, {Key: "bar"}}
+	values := map[string]*Arg{
+		name:  {Usage: usage},
+		"bar": {Usage: "other usage"},
 	}
 
-	args, err := getArgsWithOrder(ms)
+	args, err := getArgsWithOrder(ms, values)
 	if err != nil {
 		t.Fatalf("GetArgsWithOrder(ms) => unexpected error: %v", err)
 	}
@@ -103,13 +104,48 @@ func TestGetArgsWithOrder(t *testing.T) {
 	}
 }
 
-func TestGetArgsWithOrder_invalid(t *testing.T) {
-	ms := yaml.MapSlice{
-		{Key: "foo", Value: "not an arg"},
+func TestArgs_UnmarshalYAML_invalid(t *testing.T) {
+	var args Args
+	err := yaml.UnmarshalStrict([]byte("foo: not an arg"), &args)
+	if err == nil {
+		t.Error("yaml.UnmarshalStrict(args) => expected yaml parsing error")
 	}
+}
 
-	_, err := getArgsWithOrder(ms)
-	if err == nil {
-		t.Error("GetArgsWithOrder() => expected yaml parsing error")
+func TestArgs_UnmarshalYAML_defaults(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"default", `{target: {default: ./...}}`, ""},
+		{"default after required", `{env: {}, target: {default: ./...}}`, ""},
+		{
+			"required after default",
+			`{target: {default: ./...}, env: {}}`,
+			`argument "env" must have a default, since it follows an argument with one`,
+		},
+		{
+			"default not allowed",
+			`{env: {values: [dev, prod], default: staging}}`,
+			`value "staging" for default of argument env must be one of [dev prod]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args Args
+			err := yaml.UnmarshalStrict([]byte(tt.input), &args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("yaml.UnmarshalStrict(): unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("yaml.UnmarshalStrict(): want error %q, got %v", tt.wantErr, err)
+			}
+		})
 	}
 }
@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// optionCache stores the values of evaluated options for a single run.
+//
+// Sub-tasks are copied each time they are called, so without a cache, the
+// same option may be evaluated many times, re-running any commands used to
+// compute its value. Options are keyed by their full interpolated definition,
+// including any value passed, so an option is only reused if evaluating it
+// again would produce the same result.
+type optionCache map[string]string
+
+// key returns the cache key for an option given the values of other variables.
+func (c optionCache) key(o *Option, vars map[string]string) (string, error) {
+	definition, err := json.Marshal(o)
+	if err != nil {
+		return "", err
+	}
+
+	dependencies := o.Dependencies()
+	sort.Strings(dependencies)
+
+	values := make([]string, 0, len(dependencies))
+	for _, name := range dependencies {
+		values = append(values, name+"="+vars[name])
+	}
+
+	key, err := json.Marshal([]interface{}{o.Name, string(definition), values})
+	if err != nil {
+		return "", err
+	}
+
+	return string(key), nil
+}
@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ciProvider is a CI service that can be detected by an environment variable
+// it sets for every build.
+type ciProvider struct {
+	name   string
+	envVar string
+}
+
+// ciProviders are checked in order when detecting the current CI provider.
+var ciProviders = []ciProvider{
+	{"github", "GITHUB_ACTIONS"},
+	{"gitlab", "GITLAB_CI"},
+	{"circleci", "CIRCLECI"},
+	{"travis", "TRAVIS"},
+	{"jenkins", "JENKINS_URL"},
+	{"buildkite", "BUILDKITE"},
+	{"azure", "TF_BUILD"},
+	{"bitbucket", "BITBUCKET_BUILD_NUMBER"},
+	{"teamcity", "TEAMCITY_VERSION"},
+	{"appveyor", "APPVEYOR"},
+	{"drone", "DRONE"},
+}
+
+// detectCI returns whether tusk is running in CI, and the name of the CI
+// provider if it is a known one. Unknown providers are detected by the CI
+// environment variable, which most providers set.
+func detectCI() (provider string, ok bool) {
+	for _, p := range ciProviders {
+		if os.Getenv(p.envVar) != "" {
+			return p.name, true
+		}
+	}
+
+	switch strings.ToLower(os.Getenv("CI")) {
+	case "", "0", "false":
+		return "", false
+	default:
+		return "", true
+	}
+}
+
+// validateCIProviderName returns an error if a provider is not known.
+func validateCIProviderName(name string) error {
+	names := make([]string, 0, len(ciProviders))
+	for _, p := range ciProviders {
+		if p.name == name {
+			return nil
+		}
+
+		names = append(names, p.name)
+	}
+
+	return fmt.Errorf(
+		"unknown ci-provider %q, must be one of: %s", name, strings.Join(names, ", "),
+	)
+}
@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"os"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+)
+
+// setCIEnv clears the environment variables used to detect CI, then sets the
+// ones given. It returns a function that restores the original environment.
+func setCIEnv(t *testing.T, env map[string]string) func() {
+	t.Helper()
+
+	keys := []string{"CI"}
+	for _, p := range ciProviders {
+		keys = append(keys, p.envVar)
+	}
+
+	original := make(map[string]*string, len(keys))
+	for _, key := range keys {
+		if value, ok := os.LookupEnv(key); ok {
+			original[key] = &value
+		} else {
+			original[key] = nil
+		}
+
+		assert.NilError(t, os.Unsetenv(key))
+	}
+
+	for key, value := range env {
+		assert.NilError(t, os.Setenv(key, value))
+	}
+
+	return func() {
+		for key, value := range original {
+			if value == nil {
+				os.Unsetenv(key) // nolint: errcheck
+			} else {
+				os.Setenv(key, *value) // nolint: errcheck
+			}
+		}
+	}
+}
+
+func TestDetectCI(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantProvider string
+		wantCI       bool
+	}{
+		{"local", nil, "", false},
+		{"ci false", map[string]string{"CI": "false"}, "", false},
+		{"generic", map[string]string{"CI": "true"}, "", true},
+		{"github", map[string]string{"CI": "true", "GITHUB_ACTIONS": "true"}, "github", true},
+		{"gitlab", map[string]string{"GITLAB_CI": "true"}, "gitlab", true},
+		{"jenkins", map[string]string{"JENKINS_URL": "https://ci.example.com"}, "jenkins", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer setCIEnv(t, tt.env)()
+
+			provider, ok := detectCI()
+			assert.Equal(t, provider, tt.wantProvider)
+			assert.Equal(t, ok, tt.wantCI)
+		})
+	}
+}
+
+func TestWhen_Validate_ci(t *testing.T) {
+	var runList RunList
+	err := yaml.UnmarshalStrict([]byte(`
+- when: {ci-provider: [github, gitlab]}
+  command: hosted
+- when: {ci: true}
+  command: ci
+- when: {ci: false}
+  command: local
+`), &runList)
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{"local", nil, []string{"local"}},
+		{"generic", map[string]string{"CI": "1"}, []string{"ci"}},
+		{"github", map[string]string{"GITHUB_ACTIONS": "true"}, []string{"hosted", "ci"}},
+		{"circleci", map[string]string{"CIRCLECI": "true"}, []string{"ci"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer setCIEnv(t, tt.env)()
+
+			var got []string
+			for _, r := range runList {
+				if err := r.When.Validate(nil); err != nil {
+					assert.Assert(t, IsFailedCondition(err), "unexpected error: %v", err)
+					continue
+				}
+
+				got = append(got, r.Command[0].Exec)
+			}
+
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestWhen_UnmarshalYAML_unknown_ci_provider(t *testing.T) {
+	var w When
+	err := yaml.UnmarshalStrict([]byte(`ci-provider: gthub`), &w)
+	assert.ErrorContains(t, err, `unknown ci-provider "gthub", must be one of: github, gitlab`)
+}
@@ -1,8 +1,11 @@
 package runner
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/rliebz/tusk/marshal"
 	"github.com/rliebz/tusk/ui"
@@ -18,21 +21,31 @@ var execCommand = exec.Command
 
 // Command is a command passed to the shell.
 type Command struct {
-	Exec  string `yaml:"exec"`
-	Print string `yaml:"print"`
-	Dir   string `yaml:"dir"`
+	Exec   string             `yaml:"exec"`
+	Print  string             `yaml:"print"`
+	Dir    marshal.StringList `yaml:"dir,omitempty"`
+	User   string             `yaml:"user"`
+	Filter string             `yaml:"filter"`
+	Echo   *bool              `yaml:"echo,omitempty"`
+	Stdin  *Stdin             `yaml:"stdin,omitempty"`
+
+	DirFallback  string `yaml:"dir-fallback,omitempty"`
+	LineBuffered bool   `yaml:"line-buffered,omitempty"`
 }
 
+// silentPrefix is stripped from the start of a command to keep it from being
+// echoed, as in a Makefile.
+const silentPrefix = "@"
+
 // UnmarshalYAML allows strings to be interpreted as Do actions.
 func (c *Command) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var do string
 	doCandidate := marshal.UnmarshalCandidate{
 		Unmarshal: func() error { return unmarshal(&do) },
 		Assign: func() {
-			*c = Command{
-				Exec:  do,
-				Print: do,
-			}
+			*c = Command{Exec: do}
+			c.stripSilentPrefix()
+			c.Print = c.Exec
 		},
 	}
 
@@ -42,6 +55,7 @@ func (c *Command) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		Unmarshal: func() error { return unmarshal(&commandItem) },
 		Assign: func() {
 			*c = Command(commandItem)
+			c.stripSilentPrefix()
 			if c.Print == "" {
 				c.Print = c.Exec
 			}
@@ -51,18 +65,106 @@ func (c *Command) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return marshal.UnmarshalOneOf(doCandidate, commandCandidate)
 }
 
+// stripSilentPrefix removes a leading @ from the command, turning off echo.
+func (c *Command) stripSilentPrefix() {
+	if !strings.HasPrefix(c.Exec, silentPrefix) {
+		return
+	}
+
+	c.Exec = strings.TrimPrefix(c.Exec, silentPrefix)
+	echo := false
+	c.Echo = &echo
+}
+
+// echo returns whether the command is printed before it is run.
+func (c *Command) echo() bool {
+	return c.Echo == nil || *c.Echo
+}
+
 // execCommand executes a shell command.
-func (c *Command) exec() error {
-	shell := getShell()
-	cmd := execCommand(shell, "-c", c.Exec)
-	cmd.Dir = c.Dir
-	cmd.Stdin = os.Stdin
+func (c *Command) exec(ctx RunContext) (err error) {
+	cmd, err := ctx.shellCommand(*c)
+	if err != nil {
+		return err
+	}
+	defer ctx.dumpEnvOnFailure(cmd, &err)
+	stdin, closeStdin, err := c.openStdin()
+	if err != nil {
+		return err
+	}
+	defer closeStdin()
+	cmd.Stdin = stdin
 	if ui.Verbosity > ui.VerbosityLevelSilent {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+		cmd.Stderr = ui.Stderr
+	}
+	if stdout := ctx.commandStdout(); stdout != nil {
+		cmd.Stdout = stdout
+	}
+	output := ctx.holdOutput(cmd)
+	defer output.printOnFailure(c.Print, &err)
+	streamed := cmd.Stderr != nil
+
+	run := func() error { return ctx.runCommand(cmd) }
+	if c.Filter != "" && cmd.Stdout != nil {
+		run = func() error { return c.runFiltered(ctx, cmd) }
+	}
+
+	log, err := ctx.openStepLog()
+	if err != nil {
+		return err
+	}
+	if log != nil {
+		defer log.Close() // nolint: errcheck
+		defer printStepLogOnFailure(log.Name(), &err)
+		cmd.Stdout = teeTo(cmd.Stdout, log)
+		cmd.Stderr = teeTo(cmd.Stderr, log)
+	}
+
+	stderr := ctx.captureStderr(cmd)
+
+	// The tail is only useful when stderr was not already shown
+	if !ctx.VerboseErrors || streamed {
+		return stderr.check(run())
+	}
+
+	tail := newTailWriter(stderrTailLines)
+	if cmd.Stderr == nil {
+		cmd.Stderr = tail
+	} else {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, tail)
+	}
+
+	err = run()
+	if err != nil {
+		ui.PrintCommandStderr(tail.Lines())
+	}
+
+	return stderr.check(err)
+}
+
+// dir returns the working directory to run the command in. When multiple
+// directories are listed, the first that exists is used, followed by the
+// fallback if one is set.
+func (c *Command) dir() (string, error) {
+	if len(c.Dir) == 0 {
+		return c.DirFallback, nil
+	}
+
+	if len(c.Dir) == 1 && c.DirFallback == "" {
+		return c.Dir[0], nil
+	}
+
+	for _, dir := range c.Dir {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
+		}
+	}
+
+	if c.DirFallback != "" {
+		return c.DirFallback, nil
 	}
 
-	return cmd.Run()
+	return "", fmt.Errorf("none of the directories exist: %s", strings.Join(c.Dir, ", "))
 }
 
 // CommandList is a list of commands with custom yaml unamrshaling.
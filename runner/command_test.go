@@ -1,7 +1,11 @@
 package runner
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -9,7 +13,11 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/rliebz/tusk/marshal"
+	"github.com/rliebz/tusk/ui"
 	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
 )
 
 func TestCommand_UnmarshalYAML(t *testing.T) {
@@ -48,7 +56,44 @@ func TestCommand_UnmarshalYAML(t *testing.T) {
 			Command{
 				Exec:  "dovalue",
 				Print: "printvalue",
-				Dir:   "dirvalue",
+				Dir:   marshal.StringList{"dirvalue"},
+			},
+		},
+		{
+			"silent-short-command",
+			`"@example"`,
+			Command{
+				Exec:  "example",
+				Print: "example",
+				Echo:  new(bool),
+			},
+		},
+		{
+			"silent-command-with-print",
+			`{exec: "@something", print: echo example}`,
+			Command{
+				Exec:  "something",
+				Print: "echo example",
+				Echo:  new(bool),
+			},
+		},
+		{
+			"echo-false",
+			`{exec: example, echo: false}`,
+			Command{
+				Exec:  "example",
+				Print: "example",
+				Echo:  new(bool),
+			},
+		},
+		{
+			"dir-candidates",
+			`{exec: example, dir: [build, out], dir-fallback: .}`,
+			Command{
+				Exec:        "example",
+				Print:       "example",
+				Dir:         marshal.StringList{"build", "out"},
+				DirFallback: ".",
 			},
 		},
 	}
@@ -124,7 +169,7 @@ func TestCommand_exec(t *testing.T) {
 
 	command := Command{
 		Exec: wantCommand,
-		Dir:  "..",
+		Dir:  marshal.StringList{".."},
 	}
 
 	execCommand = func(name string, arg ...string) *exec.Cmd {
@@ -140,11 +185,154 @@ func TestCommand_exec(t *testing.T) {
 	}
 	defer func() { execCommand = exec.Command }()
 
-	if err := command.exec(); err != nil {
+	if err := command.exec(RunContext{}); err != nil {
 		t.Fatal(err)
 	}
 }
 
+func TestCommand_dir(t *testing.T) {
+	dir := fs.NewDir(t, "command-dir",
+		fs.WithDir("second"),
+		fs.WithDir("third"),
+		fs.WithFile("file", ""),
+	)
+	defer dir.Remove()
+
+	tests := []struct {
+		name     string
+		dirs     []string
+		fallback string
+		want     string
+	}{
+		{"none", nil, "", ""},
+		{"single missing", []string{dir.Join("missing")}, "", dir.Join("missing")},
+		{
+			"first exists",
+			[]string{dir.Join("missing"), dir.Join("file"), dir.Join("second"), dir.Join("third")},
+			"",
+			dir.Join("second"),
+		},
+		{"fallback", []string{dir.Join("missing")}, dir.Path(), dir.Path()},
+		{"fallback only", nil, dir.Path(), dir.Path()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := Command{Dir: tt.dirs, DirFallback: tt.fallback}
+
+			got, err := command.dir()
+			assert.NilError(t, err)
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestCommand_dir_none_exist(t *testing.T) {
+	command := Command{Dir: marshal.StringList{"missing", "also-missing"}}
+
+	_, err := command.dir()
+	assert.ErrorContains(t, err, "none of the directories exist: missing, also-missing")
+
+	err = command.exec(RunContext{})
+	assert.ErrorContains(t, err, "none of the directories exist")
+}
+
+func TestCommand_exec_verbose_errors(t *testing.T) {
+	defer func(l *log.Logger, ll ui.VerbosityLevel) {
+		ui.LoggerStderr = l
+		ui.Verbosity = ll
+	}(ui.LoggerStderr, ui.Verbosity)
+
+	buf := new(bytes.Buffer)
+	ui.LoggerStderr = log.New(buf, "", 0)
+	ui.Verbosity = ui.VerbosityLevelSilent
+
+	command := Command{Exec: "echo first >&2; echo second >&2; exit 1"}
+
+	err := command.exec(RunContext{})
+	assert.ErrorContains(t, err, "exit status 1")
+	assert.Equal(t, buf.String(), "")
+
+	err = command.exec(RunContext{VerboseErrors: true})
+	assert.ErrorContains(t, err, "exit status 1")
+	assert.Check(t, strings.Contains(buf.String(), "last 2 lines"))
+	assert.Check(t, strings.Contains(buf.String(), "first"))
+	assert.Check(t, strings.Contains(buf.String(), "second"))
+}
+
+func TestCommand_exec_verbose_errors_streamed(t *testing.T) {
+	defer func(l *log.Logger, w io.Writer, ll ui.VerbosityLevel) {
+		ui.LoggerStderr = l
+		ui.Stderr = w
+		ui.Verbosity = ll
+	}(ui.LoggerStderr, ui.Stderr, ui.Verbosity)
+
+	buf := new(bytes.Buffer)
+	ui.LoggerStderr = log.New(buf, "", 0)
+	ui.Stderr = buf
+	ui.Verbosity = ui.VerbosityLevelQuiet
+
+	command := Command{Exec: "echo first >&2; exit 1"}
+
+	err := command.exec(RunContext{VerboseErrors: true})
+	assert.ErrorContains(t, err, "exit status 1")
+	assert.Equal(t, buf.String(), "first\n")
+}
+
+func TestCommand_exec_filter(t *testing.T) {
+	dir := fs.NewDir(t, "filter")
+	defer dir.Remove()
+
+	stdout, err := os.Create(filepath.Join(dir.Path(), "stdout"))
+	assert.NilError(t, err)
+	defer stdout.Close() // nolint: errcheck
+
+	defer func(w io.Writer) { ui.Stdout = w }(ui.Stdout)
+	ui.Stdout = stdout
+
+	tests := []struct {
+		name    string
+		command Command
+		wantErr string
+		want    string
+	}{
+		{
+			name:    "filtered",
+			command: Command{Exec: "echo keep; echo drop; echo keep too", Filter: "grep keep"},
+			want:    "keep\nkeep too\n",
+		},
+		{
+			name:    "primary fails",
+			command: Command{Exec: "echo keep; exit 3", Filter: "grep keep"},
+			wantErr: "exit status 3",
+			want:    "keep\n",
+		},
+		{
+			name:    "filter fails",
+			command: Command{Exec: "echo drop", Filter: "grep keep"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NilError(t, stdout.Truncate(0))
+			_, err := stdout.Seek(0, 0)
+			assert.NilError(t, err)
+
+			err = tt.command.exec(RunContext{})
+			if tt.wantErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+
+			got, err := ioutil.ReadFile(stdout.Name())
+			assert.NilError(t, err)
+			assert.Equal(t, string(got), tt.want)
+		})
+	}
+}
+
 func TestCommandList_UnmarshalYAML(t *testing.T) {
 	tests := []struct {
 		name string
@@ -1,12 +1,29 @@
 package runner
 
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
 // Config is a struct representing the format for configuration settings.
 type Config struct {
 	Name  string `yaml:"name"`
 	Usage string `yaml:"usage"`
 
-	Tasks   map[string]*Task `yaml:"tasks"`
-	Options Options          `yaml:"options,omitempty"`
+	MinTuskVersion string `yaml:"min-tusk-version,omitempty"`
+	OnFailure      string `yaml:"on-failure,omitempty"`
+	Strict         *bool  `yaml:"strict,omitempty"`
+
+	Templates Templates              `yaml:"templates,omitempty"`
+	Snippets  map[string]CommandList `yaml:"snippets,omitempty"`
+
+	Tasks      map[string]*Task     `yaml:"tasks"`
+	Namespaces map[string]Namespace `yaml:"namespaces,omitempty"`
+	Options    Options              `yaml:"options,omitempty"`
+
+	// Fields not defined that were ignored, when not parsed strictly
+	ignoredFields []string
 }
 
 // UnmarshalYAML unmarshals and assigns names to options and tasks.
@@ -16,9 +33,59 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return err
 	}
 
+	if c.MinTuskVersion != "" {
+		if _, err := parseVersion(c.MinTuskVersion); err != nil {
+			return errors.Wrap(err, "min-tusk-version")
+		}
+	}
+
+	if err := c.addNamespaces(); err != nil {
+		return err
+	}
+
 	for name, t := range c.Tasks {
 		t.Name = name
+
+		if err := t.useSnippets(c.Snippets); err != nil {
+			return err
+		}
+	}
+
+	if err := c.checkConflictNames(); err != nil {
+		return err
+	}
+
+	if c.OnFailure != "" {
+		if _, ok := c.Tasks[c.OnFailure]; !ok {
+			return fmt.Errorf("on-failure task %q does not exist", c.OnFailure)
+		}
 	}
 
 	return nil
 }
+
+// allowNetwork allows all options to read values over the network.
+func (c *Config) allowNetwork() {
+	for _, o := range c.Options {
+		o.allowNetwork = true
+	}
+
+	for _, t := range c.Tasks {
+		for _, o := range t.Options {
+			o.allowNetwork = true
+		}
+	}
+}
+
+// setTaskOutputs allows all options to read values from the output of tasks.
+func (c *Config) setTaskOutputs(outputs *taskOutputs) {
+	for _, o := range c.Options {
+		o.taskOutputs = outputs
+	}
+
+	for _, t := range c.Tasks {
+		for _, o := range t.Options {
+			o.taskOutputs = outputs
+		}
+	}
+}
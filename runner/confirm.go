@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/pkg/errors"
+	"github.com/rliebz/tusk/marshal"
+	"github.com/rliebz/tusk/ui"
+)
+
+// isTerminal allows overwriting during tests.
+var isTerminal = func() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stdout.Fd())
+}
+
+// confirmInput allows overwriting during tests.
+var confirmInput io.Reader = os.Stdin
+
+// Confirm is a prompt that must be accepted before a task is run.
+type Confirm struct {
+	Prompt string
+	Phrase string `yaml:",omitempty"`
+}
+
+// UnmarshalYAML allows a prompt to be used on its own.
+func (c *Confirm) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var prompt string
+	promptCandidate := marshal.UnmarshalCandidate{
+		Unmarshal: func() error { return unmarshal(&prompt) },
+		Assign:    func() { *c = Confirm{Prompt: prompt} },
+	}
+
+	type confirmType Confirm // Use new type to avoid recursion
+	var confirmItem confirmType
+	confirmCandidate := marshal.UnmarshalCandidate{
+		Unmarshal: func() error { return unmarshal(&confirmItem) },
+		Validate: func() error {
+			if confirmItem.Prompt == "" {
+				return errors.New("confirm must specify a prompt")
+			}
+
+			return nil
+		},
+		Assign: func() { *c = Confirm(confirmItem) },
+	}
+
+	return marshal.UnmarshalOneOf(promptCandidate, confirmCandidate)
+}
+
+// confirm asks for confirmation before the task is run, returning an error if
+// the task should not proceed. Without a terminal, the run context must
+// accept confirmations in advance.
+func (t *Task) confirm(ctx RunContext) error {
+	if t.Confirm == nil || ctx.AssumeYes || ctx.CheckOnly {
+		return nil
+	}
+
+	if !isTerminal() {
+		return fmt.Errorf(
+			"task %q requires confirmation; use --yes to run it without a terminal",
+			t.Name,
+		)
+	}
+
+	hint := "[y/N]"
+	if t.Confirm.Phrase != "" {
+		hint = fmt.Sprintf("Type %q to continue", t.Confirm.Phrase)
+	}
+
+	if _, err := fmt.Fprintf(ui.Stdout, "%s %s: ", t.Confirm.Prompt, hint); err != nil {
+		return err
+	}
+
+	answer, err := ctx.readLine(confirmInput)
+	if err != nil {
+		return err
+	}
+
+	if !t.Confirm.accepts(answer) {
+		return fmt.Errorf("task %q was not confirmed", t.Name)
+	}
+
+	return nil
+}
+
+// accepts returns whether an answer confirms the prompt.
+func (c *Confirm) accepts(answer string) bool {
+	answer = strings.TrimSpace(answer)
+	if c.Phrase != "" {
+		return answer == c.Phrase
+	}
+
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// readLine reads a single line one byte at a time, so that no input meant for
+// commands run afterwards is consumed.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return string(line), nil
+}
@@ -0,0 +1,96 @@
+package runner
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rliebz/tusk/ui"
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestConfirm_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Confirm
+	}{
+		{`Are you sure?`, Confirm{Prompt: "Are you sure?"}},
+		{`{prompt: Deploy to prod, phrase: prod}`, Confirm{Prompt: "Deploy to prod", Phrase: "prod"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var got Confirm
+			assert.NilError(t, yaml.UnmarshalStrict([]byte(tt.input), &got))
+			assert.DeepEqual(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfirm_UnmarshalYAML_no_prompt(t *testing.T) {
+	var got Confirm
+	err := yaml.UnmarshalStrict([]byte(`phrase: prod`), &got)
+	assert.ErrorContains(t, err, "confirm must specify a prompt")
+}
+
+func TestTask_Execute_confirm(t *testing.T) {
+	defer func(f func() bool) { isTerminal = f }(isTerminal)
+	defer func(r io.Reader) { confirmInput = r }(confirmInput)
+	defer func(w io.Writer) { ui.Stdout = w }(ui.Stdout)
+	ui.Stdout = ioutil.Discard
+
+	tests := []struct {
+		name     string
+		terminal bool
+		yes      bool
+		phrase   string
+		input    string
+		wantErr  string
+	}{
+		{name: "confirmed", terminal: true, input: "y\n"},
+		{name: "confirmed yes", terminal: true, input: "Yes\n"},
+		{name: "declined", terminal: true, input: "n\n", wantErr: "was not confirmed"},
+		{name: "no answer", terminal: true, input: "", wantErr: "was not confirmed"},
+		{name: "phrase", terminal: true, phrase: "prod", input: "prod\n"},
+		{name: "wrong phrase", terminal: true, phrase: "prod", input: "y\n", wantErr: "was not confirmed"},
+		{name: "terminal with yes", terminal: true, yes: true},
+		{name: "no terminal", wantErr: "use --yes"},
+		{name: "no terminal with yes", yes: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fs.NewDir(t, "confirm")
+			defer dir.Remove()
+
+			isTerminal = func() bool { return tt.terminal }
+			confirmInput = strings.NewReader(tt.input)
+
+			command := filepath.Join(dir.Path(), "command")
+			task := Task{
+				Name:    "deploy",
+				Confirm: &Confirm{Prompt: "Deploy?", Phrase: tt.phrase},
+				RunList: RunList{
+					&Run{Command: CommandList{{Exec: "touch " + command}}},
+				},
+			}
+
+			err := task.Execute(RunContext{AssumeYes: tt.yes})
+			_, statErr := os.Stat(command)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Check(t, os.IsNotExist(statErr), "want command to not run")
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Check(t, statErr == nil, "want command to run")
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/rliebz/tusk/marshal"
+)
+
+// defaultContainerRuntime is used to run containers if no runtime is set.
+const defaultContainerRuntime = "docker"
+
+// Container defines an image to run the commands of a task in.
+type Container struct {
+	Image   string
+	Volumes marshal.StringList `yaml:",omitempty"`
+	Runtime string             `yaml:",omitempty"`
+}
+
+// UnmarshalYAML allows an image to be used on its own.
+func (c *Container) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var image string
+	imageCandidate := marshal.UnmarshalCandidate{
+		Unmarshal: func() error { return unmarshal(&image) },
+		Assign:    func() { *c = Container{Image: image} },
+	}
+
+	type containerType Container // Use new type to avoid recursion
+	var containerItem containerType
+	containerCandidate := marshal.UnmarshalCandidate{
+		Unmarshal: func() error { return unmarshal(&containerItem) },
+		Validate: func() error {
+			if containerItem.Image == "" {
+				return errors.New("container must specify an image")
+			}
+
+			return nil
+		},
+		Assign: func() { *c = Container(containerItem) },
+	}
+
+	return marshal.UnmarshalOneOf(imageCandidate, containerCandidate)
+}
+
+// shellCommand returns a command that runs a script using the shell. If the
+// task running uses a container, the script is run inside of it instead.
+func (r *RunContext) shellCommand(c Command) (*exec.Cmd, error) {
+	dir, err := c.dir()
+	if err != nil {
+		return nil, err
+	}
+
+	if r.container == nil {
+		name, args, err := c.shellArgs(r.FailOnLineBuffering)
+		if err != nil {
+			return nil, err
+		}
+		cmd := execCommand(name, args...)
+		cmd.Dir = dir
+		cmd.Env = r.commandEnv()
+		return cmd, setUser(cmd, c.User)
+	}
+
+	if c.User != "" {
+		return nil, errors.New("commands run in a container cannot set a user")
+	}
+
+	if c.LineBuffered {
+		return nil, errors.New("commands run in a container cannot be line-buffered")
+	}
+
+	cmd, err := r.container.command(c.Exec, dir, r.containerEnv())
+	if err != nil {
+		return nil, err
+	}
+	cmd.Env = r.commandEnv()
+
+	return cmd, nil
+}
+
+// command returns a command that runs a script in a new container. The
+// working directory is mounted at the same path, and the environment
+// variables listed are passed through from the runtime.
+func (c *Container) command(script, dir string, env []string) (*exec.Cmd, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	workdir := wd
+	if dir != "" {
+		if workdir, err = filepath.Abs(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	args := []string{"run", "--rm", "--interactive", "--volume", wd + ":" + wd}
+	for _, volume := range c.Volumes {
+		args = append(args, "--volume", volume)
+	}
+	args = append(args, "--workdir", workdir)
+	for _, key := range env {
+		args = append(args, "--env", key)
+	}
+	args = append(args, c.Image, defaultShell, "-c", script)
+
+	runtime := c.Runtime
+	if runtime == "" {
+		runtime = defaultContainerRuntime
+	}
+
+	return execCommand(runtime, args...), nil
+}
+
+// containerEnv returns the names of the environment variables to pass to a
+// container, which are those set explicitly or exported by options. The rest
+// of the environment of tusk is not passed, since it describes the host.
+func (r *RunContext) containerEnv() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	add := func(key string) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range r.setEnvironmentKeys() {
+		if _, ok := os.LookupEnv(key); ok {
+			add(key)
+		}
+	}
+	for key := range r.exports {
+		add(key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
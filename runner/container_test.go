@@ -0,0 +1,157 @@
+package runner
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rliebz/tusk/marshal"
+	"github.com/rliebz/tusk/ui"
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestContainer_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want Container
+	}{
+		{
+			"short-form",
+			`golang:1.22`,
+			Container{Image: "golang:1.22"},
+		},
+		{
+			"long-form",
+			`{image: golang:1.22, volumes: /cache:/cache, runtime: podman}`,
+			Container{
+				Image:   "golang:1.22",
+				Volumes: []string{"/cache:/cache"},
+				Runtime: "podman",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Container
+
+			if err := yaml.UnmarshalStrict([]byte(tt.yaml), &got); err != nil {
+				t.Fatalf("failed to unmarshal: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatched values:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestContainer_UnmarshalYAML_no_image(t *testing.T) {
+	var got Container
+	err := yaml.UnmarshalStrict([]byte(`volumes: [/cache:/cache]`), &got)
+	assert.ErrorContains(t, err, "container must specify an image")
+}
+
+func TestRunContext_shellCommand_container(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := "TUSK_TEST_CONTAINER_SET"
+	if err = os.Setenv(key, "set"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv(key) // nolint: errcheck
+
+	ctx := RunContext{
+		container: &Container{
+			Image:   "golang:1.22",
+			Volumes: []string{"/cache:/cache"},
+			Runtime: "podman",
+		},
+		exports:        map[string]string{"TUSK_TEST_CONTAINER_EXPORT": "exported"},
+		setEnvironment: map[string]struct{}{key: {}, "TUSK_TEST_CONTAINER_UNSET": {}},
+	}
+
+	cmd, err := ctx.shellCommand(Command{Exec: "go version", Dir: marshal.StringList{"sub"}})
+	assert.NilError(t, err)
+
+	want := []string{
+		"podman", "run", "--rm", "--interactive",
+		"--volume", wd + ":" + wd,
+		"--volume", "/cache:/cache",
+		"--workdir", filepath.Join(wd, "sub"),
+		"--env", "TUSK_TEST_CONTAINER_EXPORT",
+		"--env", key,
+		"golang:1.22", "sh", "-c", "go version",
+	}
+	if diff := cmp.Diff(want, cmd.Args); diff != "" {
+		t.Errorf("mismatched args:\n%s", diff)
+	}
+
+	assert.Check(t, cmd.Dir == "", "want the runtime to run in the working directory")
+	assert.Check(t, hasEntry(cmd.Env, "TUSK_TEST_CONTAINER_EXPORT=exported"), "env: %v", cmd.Env)
+}
+
+func TestRunContext_shellCommand_container_user(t *testing.T) {
+	ctx := RunContext{container: &Container{Image: "alpine"}}
+
+	_, err := ctx.shellCommand(Command{Exec: "true", User: "nobody"})
+	assert.ErrorContains(t, err, "cannot set a user")
+}
+
+func TestTask_Execute_container(t *testing.T) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker is not installed")
+	}
+	if err := exec.Command("docker", "info").Run(); err != nil {
+		t.Skip("docker is not available")
+	}
+
+	defer func(w io.Writer) { ui.Stdout = w }(ui.Stdout)
+	var buf bytes.Buffer
+	ui.Stdout = &buf
+
+	task := Task{
+		Container: &Container{Image: "alpine"},
+		Exports:   map[string]string{"TUSK_TEST_CONTAINER": "passed"},
+		RunList: RunList{
+			&Run{Command: CommandList{{Exec: "cat /etc/alpine-release"}}},
+			&Run{Command: CommandList{{Exec: `echo "$TUSK_TEST_CONTAINER"`}}},
+		},
+	}
+
+	assert.NilError(t, task.Execute(RunContext{}))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, len(lines), 2, "output: %s", buf.String())
+	assert.Equal(t, lines[1], "passed")
+
+	task = Task{
+		Container: &Container{Image: "alpine"},
+		RunList:   RunList{&Run{Command: CommandList{{Exec: "exit 3"}}}},
+	}
+
+	err := task.Execute(RunContext{})
+	exitErr, ok := err.(*exec.ExitError)
+	assert.Assert(t, ok, "want exit error, got %v", err)
+	assert.Equal(t, exitErr.ExitCode(), 3)
+}
+
+func hasEntry(env []string, entry string) bool {
+	for _, e := range env {
+		if e == entry {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,13 +1,112 @@
 package runner
 
+import (
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rliebz/tusk/ui"
+)
+
+// minimalPath is the PATH used for commands that do not inherit the
+// environment, unless it is set explicitly.
+const minimalPath = "/usr/local/bin:/usr/bin:/bin:/usr/sbin:/sbin"
+
 // RunContext contains contextual information about a run.
 type RunContext struct {
+	// AssumeYes accepts any confirmations required by tasks without prompting.
+	AssumeYes bool
+
+	// CheckOnly evaluates conditions without executing commands.
+	CheckOnly bool
+
+	// FailOnBudget returns an error for tasks that exceed their time budget,
+	// rather than printing a warning.
+	FailOnBudget bool
+
+	// FailOnLineBuffering returns an error for line-buffered commands when
+	// stdbuf is not installed, rather than running them with a warning.
+	FailOnLineBuffering bool
+
+	// EnvDumpOnFailure prints the environment of commands that fail.
+	EnvDumpOnFailure bool
+
+	// NoCleanupOnInterrupt skips the finally steps of tasks when the run is
+	// interrupted.
+	NoCleanupOnInterrupt bool
+
+	// MaxParallel is the most children of a parallel block that run at once.
+	// Zero means there is no limit.
+	MaxParallel int
+
+	// NoEnvInherit runs commands with only the environment variables that are
+	// set explicitly, rather than the full environment of tusk.
+	NoEnvInherit bool
+
+	// ParallelOrder is the order to launch the children of parallel blocks in,
+	// which is either declared or random.
+	ParallelOrder string
+
+	// Seed determines the order of parallel children when it is random.
+	Seed int64
+
+	// SummaryOnly holds back the output of each command, printing it only if
+	// the command fails, and prints a summary of the steps of the task run.
+	SummaryOnly bool
+
+	// VerboseErrors prints the tail of stderr for commands that fail.
+	VerboseErrors bool
+
+	// setEnvironment is shared by all copies of the context, since environment
+	// variables set by sub-tasks persist for the rest of the run.
+	setEnvironment map[string]struct{}
+
+	// exports are the environment variables exported by the options of the
+	// task currently running.
+	exports map[string]string
+
+	// secrets are the values of secret options of the task currently running,
+	// which are masked when printed.
+	secrets []string
+
+	// container is the container of the task currently running, if any.
+	container *Container
+
+	// step is the position of the run item currently running in its task.
+	step int
+
+	// stepLogs is shared by all copies of the context, so that steps are
+	// numbered in order across sub-tasks.
+	stepLogs *stepLogs
+
+	// interrupts is shared by all copies of the context, so that an interrupt
+	// stops every task in the run.
+	interrupts *interrupts
+
+	// failOnStderr is set while running a step that fails if any command
+	// writes to stderr.
+	failOnStderr bool
+
+	// finalizing is set while the finally steps of a task are running.
+	finalizing bool
+
+	// inMatrix is set while running a combination of a matrix, which prints
+	// its own summary.
+	inMatrix bool
+
+	// stdout captures the output of commands instead of printing it, such as
+	// when the output of a task is used as an option value.
+	stdout io.Writer
+
 	taskStack []*Task
 }
 
 // PushTask adds a sub-task to the task stack.
 func (r *RunContext) PushTask(t *Task) {
-	r.taskStack = append(r.taskStack, t)
+	// Copy the stack, since copies of the context may run concurrently
+	r.taskStack = append(r.taskStack[:len(r.taskStack):len(r.taskStack)], t)
 }
 
 // Tasks returns the list of tasks in the stack, in order.
@@ -18,3 +117,130 @@ func (r *RunContext) Tasks() []string {
 	}
 	return output
 }
+
+// commandStdout returns where the output of commands is written, or nil if it
+// is discarded.
+func (r *RunContext) commandStdout() io.Writer {
+	if r.stdout != nil {
+		return r.stdout
+	}
+
+	if ui.Verbosity > ui.VerbosityLevelSilent {
+		return ui.Stdout
+	}
+
+	return nil
+}
+
+// environmentMu guards the environment variables recorded by run contexts,
+// since steps may set them concurrently.
+var environmentMu sync.Mutex
+
+// markEnvironment records that an environment variable was set explicitly.
+func (r *RunContext) markEnvironment(key string) {
+	environmentMu.Lock()
+	defer environmentMu.Unlock()
+
+	if r.setEnvironment != nil {
+		r.setEnvironment[key] = struct{}{}
+	}
+}
+
+// setEnvironmentKeys returns the names of the environment variables set
+// explicitly, sorted by name.
+func (r *RunContext) setEnvironmentKeys() []string {
+	environmentMu.Lock()
+	defer environmentMu.Unlock()
+
+	keys := make([]string, 0, len(r.setEnvironment))
+	for key := range r.setEnvironment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// commandEnv returns the environment for running commands, sorted by name so
+// that commands see the same environment on every run.
+func (r *RunContext) commandEnv() []string {
+	if !r.NoEnvInherit {
+		return sortEnv(append(os.Environ(), r.exportedEnv()...))
+	}
+
+	keys := r.setEnvironmentKeys()
+
+	env := make([]string, 0, len(keys)+1)
+	hasPath := false
+	for _, key := range keys {
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			continue
+		}
+
+		if key == "PATH" {
+			hasPath = true
+		}
+
+		env = append(env, key+"="+value)
+	}
+
+	if !hasPath {
+		env = append(env, "PATH="+minimalPath)
+	}
+
+	return sortEnv(append(env, r.exportedEnv()...))
+}
+
+// sortEnv returns a copy of an environment sorted by variable name. When a
+// variable is listed more than once, the last value is used.
+func sortEnv(env []string) []string {
+	byKey := make(map[string]string, len(env))
+	keys := make([]string, 0, len(env))
+	for _, entry := range env {
+		key := envKey(entry)
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		byKey[key] = entry
+	}
+	sort.Strings(keys)
+
+	sorted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		sorted = append(sorted, byKey[key])
+	}
+
+	return sorted
+}
+
+// envKey returns the name of the variable in an environment entry. Names may
+// begin with "=", as with the per-drive directories on Windows.
+func envKey(entry string) string {
+	i := strings.Index(entry, "=")
+	if i == 0 {
+		i = strings.Index(entry[1:], "=") + 1
+	}
+	if i <= 0 {
+		return entry
+	}
+
+	return entry[:i]
+}
+
+// exportedEnv returns the environment variables exported by options, sorted
+// by name. These are listed after others, so that they take priority.
+func (r *RunContext) exportedEnv() []string {
+	keys := make([]string, 0, len(r.exports))
+	for key := range r.exports {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	env := make([]string, 0, len(keys))
+	for _, key := range keys {
+		env = append(env, key+"="+r.exports[key])
+	}
+
+	return env
+}
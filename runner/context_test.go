@@ -2,6 +2,8 @@ package runner
 
 import (
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -21,3 +23,48 @@ func TestContext_Tasks(t *testing.T) {
 		t.Errorf("want %v, got %v", expected, actual)
 	}
 }
+
+func TestSortEnv(t *testing.T) {
+	env := []string{"B=2", "A1=1", "A=old", "=C:=C:\\", "A=new"}
+
+	expected := []string{"=C:=C:\\", "A=new", "A1=1", "B=2"}
+	actual := sortEnv(env)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("want %v, got %v", expected, actual)
+	}
+}
+
+func TestContext_shellCommand_sorted_env(t *testing.T) {
+	for _, noInherit := range []bool{false, true} {
+		ctx := RunContext{
+			NoEnvInherit: noInherit,
+			exports:      map[string]string{"TUSK_TEST_B": "b", "TUSK_TEST_A": "a", "PATH": "/bin"},
+		}
+
+		first, err := ctx.shellCommand(Command{Exec: "true"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !sort.SliceIsSorted(first.Env, func(i, j int) bool {
+			return envKey(first.Env[i]) < envKey(first.Env[j])
+		}) {
+			t.Errorf("NoEnvInherit=%t: want sorted env, got %v", noInherit, first.Env)
+		}
+
+		for _, entry := range first.Env {
+			if strings.HasPrefix(entry, "PATH=") && entry != "PATH=/bin" {
+				t.Errorf("NoEnvInherit=%t: want exported PATH to take priority, got %s", noInherit, entry)
+			}
+		}
+
+		second, err := ctx.shellCommand(Command{Exec: "true"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(first.Env, second.Env) {
+			t.Errorf("NoEnvInherit=%t: want stable env, got %v and %v", noInherit, first.Env, second.Env)
+		}
+	}
+}
@@ -1,9 +1,10 @@
 package runner
 
 import (
-	"encoding/json"
+	"strings"
 
 	"github.com/rliebz/tusk/marshal"
+	yaml "gopkg.in/yaml.v2"
 )
 
 // FindAllOptions returns a list of options relevant for a given
@@ -81,15 +82,51 @@ type dependencyGetter interface {
 }
 
 func getDependencies(item dependencyGetter) ([]string, error) {
-	// TODO: Remove json dependency by implementing stringer interface
-	// json is used to print computed fields that should not be yaml parseable
-	marshaled, err := json.Marshal(item)
+	text, err := rawText(item)
 	if err != nil {
 		return nil, err
 	}
 
-	names := marshal.FindPotentialVariables(marshaled)
+	names := marshal.FindPotentialVariables(text)
 	names = append(names, item.Dependencies()...)
 
 	return names, nil
 }
+
+// rawText returns the text of every string in the yaml representation of an
+// item, which is what interpolation is applied to. The strings are read back
+// from the yaml so that quoting and escaping do not hide any references, such
+// as the quoted arguments of interpolation functions.
+func rawText(item interface{}) ([]byte, error) {
+	marshaled, err := yaml.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	if err := yaml.Unmarshal(marshaled, &decoded); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	var collect func(interface{})
+	collect = func(value interface{}) {
+		switch v := value.(type) {
+		case string:
+			b.WriteString(v)
+			b.WriteString("\n")
+		case []interface{}:
+			for _, item := range v {
+				collect(item)
+			}
+		case map[interface{}]interface{}:
+			for key, item := range v {
+				collect(key)
+				collect(item)
+			}
+		}
+	}
+	collect(decoded)
+
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/rliebz/tusk/ui"
+)
+
+// dumpEnvOnFailure prints the environment a command was run with if it failed
+// and the run context requires it. The values of secret options are masked.
+func (r *RunContext) dumpEnvOnFailure(cmd *exec.Cmd, err *error) {
+	if !r.EnvDumpOnFailure || *err == nil || IsInterrupted(*err) {
+		return
+	}
+
+	ui.PrintCommandEnvironment(maskEnv(cmd.Env, r.secrets))
+}
+
+// maskEnv returns a copy of an environment with any secrets masked, sorted in
+// the same order that commands receive it.
+func maskEnv(env, secrets []string) []string {
+	masked := make([]string, 0, len(env))
+	for _, entry := range env {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) == 2 {
+			entry = parts[0] + "=" + maskString(parts[1], secrets)
+		}
+
+		masked = append(masked, entry)
+	}
+	return sortEnv(masked)
+}
@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/rliebz/tusk/ui"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+func TestTask_Execute_env_dump_on_failure(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		dump     bool
+		wantDump bool
+	}{
+		{name: "failure", command: "exit 1", dump: true, wantDump: true},
+		{name: "success", command: "exit 0", dump: true, wantDump: false},
+		{name: "not enabled", command: "exit 1", dump: false, wantDump: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(level ui.VerbosityLevel) {
+				ui.LoggerStderr.SetOutput(os.Stderr)
+				ui.Verbosity = level
+			}(ui.Verbosity)
+
+			buf := new(bytes.Buffer)
+			ui.LoggerStderr.SetOutput(buf)
+			ui.Verbosity = ui.VerbosityLevelQuiet
+
+			cfg, err := ParseComplete(&Metadata{CfgText: []byte(`
+tasks:
+  deploy:
+    options:
+      token:
+        secret: true
+        default: hunter2
+        export-as: DEPLOY_TOKEN
+      region:
+        default: us-east-1
+        export-as: DEPLOY_REGION
+    run: "` + tt.command + `"
+`)}, "deploy", nil, map[string]string{})
+			assert.NilError(t, err)
+
+			ctx := RunContext{EnvDumpOnFailure: tt.dump}
+			_ = cfg.Tasks["deploy"].Execute(ctx)
+
+			if !tt.wantDump {
+				assert.Check(t, !bytes.Contains(buf.Bytes(), []byte("Environment")), buf.String())
+				return
+			}
+
+			assert.Check(t, cmp.Contains(buf.String(), "Environment: "))
+			assert.Check(t, cmp.Contains(buf.String(), " => DEPLOY_REGION=us-east-1\n"))
+			assert.Check(t, cmp.Contains(buf.String(), " => DEPLOY_TOKEN=****\n"))
+			assert.Check(t, !bytes.Contains(buf.Bytes(), []byte("hunter2")), buf.String())
+		})
+	}
+}
+
+func TestMaskEnv(t *testing.T) {
+	env := []string{"B=secret-value", "A=plain", "C"}
+	got := maskEnv(env, []string{"secret"})
+	assert.DeepEqual(t, got, []string{"A=plain", "B=****-value", "C"})
+}
@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteEnvFile writes the resolved values of the options used by a task as
+// NAME=value lines. Options are named by their export-as name if they have
+// one, or by their name in upper case with dashes replaced by underscores.
+//
+// Shared options must be passed in, since they are not part of the task. If
+// maskSecrets is set, secret and redacted options are written masked.
+func (t *Task) WriteEnvFile(w io.Writer, shared Options, maskSecrets bool) error {
+	redacted := make(map[string]bool, len(t.RedactOptions))
+	for _, name := range t.RedactOptions {
+		redacted[name] = true
+	}
+
+	lines := make(map[string]string)
+	add := func(o *Option) error {
+		value, ok := t.Vars[o.Name]
+		if !ok {
+			return nil
+		}
+
+		if maskSecrets && (o.Secret || redacted[o.Name]) {
+			value = secretMask
+		}
+
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("value of option %q cannot be written on a single line", o.Name)
+		}
+
+		lines[envFileName(o)] = value
+		return nil
+	}
+
+	for _, o := range t.allOptions(shared) {
+		if err := add(o); err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, 0, len(lines))
+	for name := range lines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", name, lines[name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// allOptions returns the shared options that apply to a task, followed by the
+// task's own options.
+func (t *Task) allOptions(shared Options) []*Option {
+	options := make([]*Option, 0, len(shared)+len(t.Options))
+	for _, o := range shared {
+		// Args that share a name with shared options take priority
+		if _, ok := t.Args.Lookup(o.Name); ok {
+			continue
+		}
+		if _, ok := t.Options.Lookup(o.Name); ok {
+			continue
+		}
+
+		options = append(options, o)
+	}
+
+	return append(options, t.Options...)
+}
+
+// envFileName returns the name of an option in an env-file.
+func envFileName(o *Option) string {
+	if o.ExportAs != "" {
+		return o.ExportAs
+	}
+
+	return strings.ToUpper(strings.ReplaceAll(o.Name, "-", "_"))
+}
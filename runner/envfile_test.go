@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestTask_WriteEnvFile(t *testing.T) {
+	shared := Options{
+		{Name: "env"},
+		{Name: "unused"},
+		{Name: "name"},
+	}
+
+	task := Task{
+		Args: Args{{Name: "name"}},
+		Options: Options{
+			{Name: "dry-run"},
+			{Name: "token", Secret: true},
+			{Name: "password"},
+			{Name: "region", ExportAs: "AWS_REGION"},
+		},
+		RedactOptions: []string{"password"},
+		Vars: map[string]string{
+			"env":      "prod",
+			"name":     "arg value",
+			"dry-run":  "true",
+			"token":    "abc123",
+			"password": "hunter2",
+			"region":   "us-east-1",
+		},
+	}
+
+	tests := []struct {
+		name        string
+		maskSecrets bool
+		want        string
+	}{
+		{
+			"unmasked",
+			false,
+			"AWS_REGION=us-east-1\nDRY_RUN=true\nENV=prod\nPASSWORD=hunter2\nTOKEN=abc123\n",
+		},
+		{
+			"masked",
+			true,
+			"AWS_REGION=us-east-1\nDRY_RUN=true\nENV=prod\nPASSWORD=****\nTOKEN=****\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.NilError(t, task.WriteEnvFile(&buf, shared, tt.maskSecrets))
+			assert.Equal(t, buf.String(), tt.want)
+		})
+	}
+}
+
+func TestTask_WriteEnvFile_multiline(t *testing.T) {
+	task := Task{
+		Options: Options{{Name: "message"}},
+		Vars:    map[string]string{"message": "one\ntwo"},
+	}
+
+	var buf bytes.Buffer
+	err := task.WriteEnvFile(&buf, nil, false)
+	assert.ErrorContains(t, err, `value of option "message" cannot be written on a single line`)
+}
@@ -2,6 +2,9 @@ package runner
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
 )
 
 // IsFailedCondition checks if an error was because of a failed condition.
@@ -64,3 +67,51 @@ func newUnspecifiedError(clauseName string) error {
 	formatted := fmt.Sprintf("clause %q is not defined", clauseName)
 	return &unspecifiedClauseError{formatted}
 }
+
+// multiError is a list of errors that occurred together.
+type multiError []error
+
+func (e multiError) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return fmt.Sprintf("%d errors occurred: %s", len(e), strings.Join(messages, "; "))
+}
+
+// combineErrors returns a single error for all errors that are not nil, or
+// nil if there are none. A single error is returned as-is.
+func combineErrors(errs ...error) error {
+	var combined multiError
+	for _, err := range errs {
+		if err != nil {
+			combined = append(combined, err)
+		}
+	}
+
+	switch len(combined) {
+	case 0:
+		return nil
+	case 1:
+		return combined[0]
+	default:
+		return combined
+	}
+}
+
+// ExitStatus returns the exit status for an error that determines its own,
+// such as a task that continued on error. Errors wrapped with context are
+// checked by their cause.
+func ExitStatus(err error) (int, bool) {
+	se, ok := errors.Cause(err).(exitStatuser)
+	if !ok {
+		return 0, false
+	}
+
+	return se.ExitStatus(), true
+}
+
+type exitStatuser interface {
+	ExitStatus() int
+}
@@ -0,0 +1,181 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Explain writes a step-by-step trace of how an option's value is determined,
+// in the same order of priority used by Evaluate. The value the option was
+// evaluated to is given, so that commands are not run again to compute it.
+func (o *Option) Explain(w io.Writer, vars map[string]string, value string) error {
+	e := explainer{w: w}
+
+	e.printf("option %q:\n", o.Name)
+
+	source, err := o.explainSource(&e, vars)
+	if err != nil {
+		return err
+	}
+
+	if o.Secret {
+		value = secretMask
+	}
+	e.printf("value: %q (from %s)\n", value, source)
+
+	return e.err
+}
+
+func (o *Option) explainSource(e *explainer, vars map[string]string) (string, error) {
+	if o.Private {
+		e.step("private, so it cannot be passed by command line or environment")
+	} else {
+		if o.Passed != "" {
+			e.step("passed by command line")
+			return "command line", nil
+		}
+		e.step("not passed by command line")
+
+		switch {
+		case o.Environment == "":
+			e.step("no environment variable defined")
+		case os.Getenv(o.Environment) != "":
+			e.step("environment variable %s is set", o.Environment)
+			return "environment variable " + o.Environment, nil
+		default:
+			e.step("environment variable %s is not set", o.Environment)
+		}
+	}
+
+	if o.Required {
+		return "", fmt.Errorf("no value passed for required option: %s", o.Name)
+	}
+
+	for i, candidate := range o.DefaultValues {
+		source := fmt.Sprintf("default %d", i+1)
+
+		if err := candidate.When.Validate(vars); err != nil {
+			if !IsFailedCondition(err) {
+				return "", err
+			}
+			e.step("%s skipped: %s", source, err)
+			continue
+		}
+
+		if len(candidate.When) == 0 {
+			e.step("%s has no conditions", source)
+		} else {
+			e.step("%s conditions met:", source)
+			if err := e.yaml(candidate.When); err != nil {
+				return "", err
+			}
+		}
+
+		switch {
+		case candidate.Command != "":
+			e.step("%s uses the output of command: %s", source, candidate.Command)
+		case candidate.URL != "":
+			e.step("%s uses the body of url: %s", source, candidate.URL)
+		}
+
+		return source, nil
+	}
+
+	if len(o.DefaultValues) > 0 {
+		e.step("no default conditions met")
+	} else {
+		e.step("no defaults defined")
+	}
+
+	return "zero value", nil
+}
+
+// explainer writes numbered steps, keeping track of the first error.
+type explainer struct {
+	w     io.Writer
+	steps int
+	err   error
+}
+
+func (e *explainer) printf(format string, a ...interface{}) {
+	if e.err != nil {
+		return
+	}
+
+	_, e.err = fmt.Fprintf(e.w, format, a...)
+}
+
+func (e *explainer) step(format string, a ...interface{}) {
+	e.steps++
+	e.printf("  %d. %s\n", e.steps, fmt.Sprintf(format, a...))
+}
+
+// yaml writes the definition of an item, indented beneath the last step.
+func (e *explainer) yaml(in interface{}) error {
+	out, err := yaml.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		e.printf("       %s\n", line)
+	}
+
+	return nil
+}
+
+// WriteChangedOptions writes the options used by a task whose values were
+// passed by command line or environment variable and differ from what their
+// defaults would give, along with where each value came from.
+//
+// Shared options must be passed in, since they are not part of the task.
+func (t *Task) WriteChangedOptions(w io.Writer, shared Options) error {
+	for _, o := range t.allOptions(shared) {
+		value, ok := t.Vars[o.Name]
+		if !ok || o.Private {
+			continue
+		}
+
+		var source string
+		switch {
+		case o.Passed != "":
+			source = "command line"
+		case o.Environment != "" && os.Getenv(o.Environment) != "":
+			source = "environment variable " + o.Environment
+		default:
+			continue
+		}
+
+		description := "no default"
+		if !o.Required {
+			defaultValue, err := o.getDefaultValue(t.Vars)
+			if err != nil {
+				return err
+			}
+			if defaultValue == value {
+				continue
+			}
+
+			if o.Secret {
+				defaultValue = secretMask
+			}
+			description = fmt.Sprintf("default %q", defaultValue)
+		}
+
+		if o.Secret {
+			value = secretMask
+		}
+
+		if _, err := fmt.Fprintf(
+			w, "%s: %q (from %s, %s)\n", o.Name, value, source, description,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
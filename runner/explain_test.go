@@ -0,0 +1,143 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestOption_Explain(t *testing.T) {
+	envVar := "TUSK_TEST_EXPLAIN"
+	if err := os.Setenv(envVar, "from-env"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv(envVar) // nolint: errcheck
+
+	tests := []struct {
+		desc   string
+		option Option
+		value  string
+		want   string
+	}{
+		{
+			"passed",
+			Option{Name: "foo", Passed: "bar", Environment: envVar},
+			"bar",
+			`option "foo":
+  1. passed by command line
+value: "bar" (from command line)
+`,
+		},
+		{
+			"environment",
+			Option{Name: "foo", Environment: envVar},
+			"from-env",
+			`option "foo":
+  1. not passed by command line
+  2. environment variable TUSK_TEST_EXPLAIN is set
+value: "from-env" (from environment variable TUSK_TEST_EXPLAIN)
+`,
+		},
+		{
+			"command default",
+			Option{
+				Name:          "foo",
+				Private:       true,
+				DefaultValues: ValueList{{Command: "echo bar"}},
+			},
+			"bar",
+			`option "foo":
+  1. private, so it cannot be passed by command line or environment
+  2. default 1 has no conditions
+  3. default 1 uses the output of command: echo bar
+value: "bar" (from default 1)
+`,
+		},
+		{
+			"zero value",
+			Option{Name: "foo", Type: "bool", Secret: true},
+			"false",
+			`option "foo":
+  1. not passed by command line
+  2. no environment variable defined
+  3. no defaults defined
+value: "****" (from zero value)
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := tt.option.Explain(&buf, nil, tt.value)
+			assert.NilError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestOption_Explain_required(t *testing.T) {
+	option := Option{Name: "foo", Required: true}
+
+	var buf bytes.Buffer
+	err := option.Explain(&buf, nil, "")
+	assert.ErrorContains(t, err, "no value passed for required option: foo")
+}
+
+func TestTask_WriteChangedOptions(t *testing.T) {
+	envVar := "TUSK_TEST_CHANGED_OPTIONS"
+	if err := os.Setenv(envVar, "from-env"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv(envVar) // nolint: errcheck
+
+	cfgText := []byte(`
+options:
+  region:
+    default: us-east-1
+tasks:
+  deploy:
+    options:
+      env:
+        default: dev
+      level:
+        default: info
+        environment: ` + envVar + `
+      replicas:
+        type: int
+        default: 2
+      unchanged:
+        default: same
+      token:
+        secret: true
+        default: none
+      name:
+        required: true
+    run: echo ${env} ${level} ${replicas} ${unchanged} ${token} ${name} ${region}
+`)
+
+	flags := map[string]string{
+		"env":       "prod",
+		"replicas":  "2",
+		"token":     "hunter2",
+		"name":      "web",
+		"region":    "eu-west-1",
+		"unchanged": "same",
+	}
+
+	cfg, err := ParseComplete(&Metadata{CfgText: cfgText}, "deploy", nil, flags)
+	assert.NilError(t, err)
+
+	var buf bytes.Buffer
+	assert.NilError(t, cfg.Tasks["deploy"].WriteChangedOptions(&buf, cfg.Options))
+
+	want := `region: "eu-west-1" (from command line, default "us-east-1")
+env: "prod" (from command line, default "dev")
+level: "from-env" (from environment variable ` + envVar + `, default "info")
+token: "****" (from command line, default "****")
+name: "web" (from command line, no default)
+`
+	assert.Equal(t, want, buf.String())
+}
@@ -0,0 +1,44 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/rliebz/tusk/ui"
+)
+
+// runFiltered runs a command with its stdout piped through the filter command.
+// The exit status of the filter is ignored, so the error returned is always
+// that of the original command.
+func (c *Command) runFiltered(ctx RunContext, cmd *exec.Cmd) error {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	filter := execCommand(getShell(), "-c", c.Filter)
+	filter.Dir = cmd.Dir
+	filter.Env = cmd.Env
+	filter.SysProcAttr = cmd.SysProcAttr
+	filter.Stdin = r
+	filter.Stdout = cmd.Stdout
+	filter.Stderr = ui.Stderr
+
+	if err := filter.Start(); err != nil {
+		r.Close() // nolint: errcheck
+		w.Close() // nolint: errcheck
+		return err
+	}
+
+	// Tusk must close its copy of the read end, so that the command is not
+	// left blocking if the filter exits early.
+	r.Close() // nolint: errcheck
+
+	cmd.Stdout = w
+	err = ctx.runCommand(cmd)
+
+	w.Close()     // nolint: errcheck
+	filter.Wait() // nolint: errcheck
+
+	return err
+}
@@ -0,0 +1,184 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FromTask is a value read from the JSON output of another task.
+type FromTask struct {
+	Task     string `yaml:"task"`
+	JSONPath string `yaml:"json-path"`
+}
+
+// taskOutputs runs tasks to read option values from their output. Each task is
+// run at most once, and its output is reused for any other values read from it.
+type taskOutputs struct {
+	meta    *Metadata
+	outputs map[string][]byte
+	running []string
+}
+
+// newTaskOutputs returns a task runner for a config.
+func newTaskOutputs(meta *Metadata) *taskOutputs {
+	return &taskOutputs{
+		meta:    meta,
+		outputs: make(map[string][]byte),
+	}
+}
+
+// value returns the value at a path in the output of a task.
+func (to *taskOutputs) value(option string, from *FromTask) (string, error) {
+	if to == nil {
+		return "", fmt.Errorf("option %s cannot read values from tasks", option)
+	}
+
+	output, err := to.output(from.Task)
+	if err != nil {
+		return "", errors.Wrapf(
+			err, "could not compute value for option %s from task %q", option, from.Task,
+		)
+	}
+
+	value, err := extractJSONPath(output, from.JSONPath)
+	if err != nil {
+		return "", errors.Wrapf(
+			err, "could not compute value for option %s from task %q at %q",
+			option, from.Task, from.JSONPath,
+		)
+	}
+
+	return value, nil
+}
+
+// output returns the stdout of a task, running it if it has not run yet.
+func (to *taskOutputs) output(name string) ([]byte, error) {
+	if output, ok := to.outputs[name]; ok {
+		return output, nil
+	}
+
+	for i, running := range to.running {
+		if running == name {
+			cycle := append(to.running[i:len(to.running):len(to.running)], name)
+			return nil, fmt.Errorf("cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	to.running = append(to.running, name)
+	defer func() { to.running = to.running[:len(to.running)-1] }()
+
+	_, t, err := parseTask(to.meta, to, name, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if t == nil {
+		return nil, fmt.Errorf("task %q does not exist", name)
+	}
+
+	// The task is run even when checking, as with commands in option defaults,
+	// since its output is needed to evaluate the option
+	var stdout bytes.Buffer
+	ctx := to.meta.RunContext()
+	ctx.CheckOnly = false
+	ctx.stdout = &stdout
+	if err := t.Execute(ctx); err != nil {
+		return nil, err
+	}
+
+	to.outputs[name] = stdout.Bytes()
+
+	return to.outputs[name], nil
+}
+
+// extractJSONPath returns the value at a path such as .items[0].name in a JSON
+// document. Strings are returned as-is, and any other value is returned as
+// JSON.
+func extractJSONPath(text []byte, path string) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(text))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", errors.Wrap(err, "parsing output as JSON")
+	}
+
+	segments, err := splitJSONPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	for _, segment := range segments {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			item, ok := v[segment]
+			if !ok {
+				return "", fmt.Errorf("key %q not found", segment)
+			}
+			value = item
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil {
+				return "", fmt.Errorf("cannot read key %q of a list", segment)
+			}
+			if index < 0 || index >= len(v) {
+				return "", fmt.Errorf("index %d out of range for list of length %d", index, len(v))
+			}
+			value = v[index]
+		default:
+			return "", fmt.Errorf("cannot read %q of a %T", segment, value)
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", nil
+	default:
+		out, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	}
+}
+
+// splitJSONPath splits a path such as .items[0].name into its keys and
+// indexes. An empty path or "." refers to the whole document.
+func splitJSONPath(path string) ([]string, error) {
+	if path != "" && !strings.HasPrefix(path, ".") {
+		return nil, fmt.Errorf("json-path must start with \".\"")
+	}
+
+	var segments []string
+	for _, part := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		if part == "" {
+			continue
+		}
+
+		key := part
+		var indexes []string
+		if i := strings.Index(part, "["); i >= 0 {
+			key = part[:i]
+			for _, index := range strings.Split(part[i+1:], "[") {
+				if !strings.HasSuffix(index, "]") {
+					return nil, fmt.Errorf("invalid json-path %q", path)
+				}
+				indexes = append(indexes, strings.TrimSuffix(index, "]"))
+			}
+		}
+
+		if key != "" {
+			segments = append(segments, key)
+		}
+		segments = append(segments, indexes...)
+	}
+
+	return segments, nil
+}
@@ -0,0 +1,190 @@
+package runner
+
+import (
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseComplete_from_task(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  setup:
+    options:
+      env:
+        default: prod
+    run: |
+      echo '{"region": "us-east-1", "zones": [{"name": "a"}], "env": "${env}"}'
+  deploy:
+    options:
+      region:
+        default:
+          from-task: {task: setup, json-path: .region}
+      zone:
+        default:
+          from-task: {task: setup, json-path: ".zones[0].name"}
+      env:
+        default:
+          from-task: {task: setup, json-path: .env}
+    run: echo ${region} ${zone} ${env}
+`)
+
+	cfg, err := ParseComplete(&Metadata{CfgText: cfgText}, "deploy", nil, map[string]string{})
+	assert.NilError(t, err)
+
+	vars := cfg.Tasks["deploy"].Vars
+	assert.Equal(t, vars["region"], "us-east-1")
+	assert.Equal(t, vars["zone"], "a")
+	assert.Equal(t, vars["env"], "prod")
+}
+
+func TestParseComplete_from_task_check(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  setup:
+    run: |
+      echo '{"region": "us-east-1"}'
+  deploy:
+    options:
+      region:
+        default:
+          from-task: {task: setup, json-path: .region}
+    run: echo ${region}
+`)
+
+	meta := &Metadata{CfgText: cfgText, CheckOnly: true}
+	cfg, err := ParseComplete(meta, "deploy", nil, map[string]string{})
+	assert.NilError(t, err)
+
+	assert.Equal(t, cfg.Tasks["deploy"].Vars["region"], "us-east-1")
+}
+
+func TestParseComplete_from_task_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name: "missing key",
+			input: `
+tasks:
+  setup:
+    run: |
+      echo '{"region": "us-east-1"}'
+  deploy:
+    options:
+      zone:
+        default:
+          from-task: {task: setup, json-path: .zone}
+    run: echo ${zone}
+`,
+			wantErr: `could not compute value for option zone from task "setup" at ".zone": ` +
+				`key "zone" not found`,
+		},
+		{
+			name: "not json",
+			input: `
+tasks:
+  setup:
+    run: echo hello
+  deploy:
+    options:
+      zone:
+        default:
+          from-task: {task: setup, json-path: .zone}
+    run: echo ${zone}
+`,
+			wantErr: `could not compute value for option zone from task "setup" at ".zone": ` +
+				`parsing output as JSON`,
+		},
+		{
+			name: "missing task",
+			input: `
+tasks:
+  deploy:
+    options:
+      zone:
+        default:
+          from-task: {task: setup, json-path: .zone}
+    run: echo ${zone}
+`,
+			wantErr: `task "setup" does not exist`,
+		},
+		{
+			name: "cycle",
+			input: `
+tasks:
+  one:
+    options:
+      a:
+        default:
+          from-task: {task: two, json-path: .b}
+    run: |
+      echo '{"a": "${a}"}'
+  two:
+    options:
+      b:
+        default:
+          from-task: {task: one, json-path: .a}
+    run: |
+      echo '{"b": "${b}"}'
+`,
+			wantErr: "cycle detected: two -> one -> two",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := "deploy"
+			if tt.name == "cycle" {
+				task = "one"
+			}
+
+			_, err := ParseComplete(&Metadata{CfgText: []byte(tt.input)}, task, nil, map[string]string{})
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValue_UnmarshalYAML_from_task_conflicts(t *testing.T) {
+	var v Value
+	err := yaml.UnmarshalStrict([]byte(`{from-task: {task: setup}, command: echo}`), &v)
+	assert.ErrorContains(t, err, "from-task (setup) cannot be defined with a value, command, or url")
+}
+
+func TestExtractJSONPath(t *testing.T) {
+	text := []byte(`{"a": {"b": [1, {"c": "d"}], "n": 1.50, "t": true, "z": null}}`)
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr string
+	}{
+		{path: ".a.b[1].c", want: "d"},
+		{path: ".a.b[0]", want: "1"},
+		{path: ".a.n", want: "1.50"},
+		{path: ".a.t", want: "true"},
+		{path: ".a.z", want: ""},
+		{path: ".a.b", want: `[1,{"c":"d"}]`},
+		{path: ".", want: `{"a":{"b":[1,{"c":"d"}],"n":1.50,"t":true,"z":null}}`},
+		{path: ".a.b[2]", wantErr: "index 2 out of range for list of length 2"},
+		{path: ".a.b.c", wantErr: `cannot read key "c" of a list`},
+		{path: ".a.t.c", wantErr: `cannot read "c" of a bool`},
+		{path: "a", wantErr: `json-path must start with "."`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := extractJSONPath(text, tt.path)
+			if tt.wantErr != "" {
+				assert.Error(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/rliebz/tusk/ui"
+)
+
+// stepsFailedError is returned by tasks that continue on error, once every
+// step has run and at least one has failed.
+type stepsFailedError struct {
+	failed int
+	total  int
+	status int
+}
+
+func (e *stepsFailedError) Error() string {
+	return fmt.Sprintf("%d of %d steps failed", e.failed, e.total)
+}
+
+// ExitStatus returns the exit status for the run as a whole.
+func (e *stepsFailedError) ExitStatus() int {
+	return e.status
+}
+
+// runAll runs every item in the run list, even if some of them fail, and
+// prints a summary of each item once they have all run.
+func (t *Task) runAll(ctx RunContext) error {
+	results := make([]ui.StepResult, 0, len(t.RunList))
+	var failures []error
+
+	for i, r := range t.RunList {
+		ctx.step = i + 1
+		result := t.runForResult(ctx, r)
+
+		if ctx.interrupted() {
+			return ErrInterrupted
+		}
+		if isAborted(result.Err) {
+			return result.Err
+		}
+
+		if result.Err != nil {
+			failures = append(failures, result.Err)
+		}
+		results = append(results, result)
+	}
+
+	ui.PrintStepSummary(t.Name, results)
+
+	if len(failures) == 0 {
+		return nil
+	}
+
+	return &stepsFailedError{
+		failed: len(failures),
+		total:  len(results),
+		status: aggregateStatus(failures),
+	}
+}
+
+// runForResult runs a run item, returning its outcome for a summary.
+func (t *Task) runForResult(ctx RunContext, r *Run) ui.StepResult {
+	result := ui.StepResult{Name: r.label()}
+
+	ok, err := r.shouldRun(t.Vars)
+	switch {
+	case err != nil:
+		result.Err = err
+	case !ok:
+		result.Skipped = true
+	default:
+		result.Err = t.runStep(ctx, r, stateRunning)
+	}
+
+	return result
+}
+
+// aggregateStatus returns the exit status for a list of failures, which is the
+// status of the failure if there is only one, and 1 otherwise.
+func aggregateStatus(failures []error) int {
+	if len(failures) != 1 {
+		return 1
+	}
+
+	if exitErr, ok := failures[0].(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+
+	return 1
+}
@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/rliebz/tusk/ui"
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+func TestTask_Execute_continue_on_error(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantErr     string
+		wantStatus  int
+		wantSummary string
+	}{
+		{
+			name: "all pass",
+			input: `
+continue-on-error: true
+run:
+  - name: lint
+    command: exit 0
+  - exit 0
+`,
+			wantSummary: " => passed lint\n => passed exit 0\n",
+		},
+		{
+			name: "one fails",
+			input: `
+continue-on-error: true
+run:
+  - name: lint
+    command: exit 4
+  - name: test
+    command: exit 0
+`,
+			wantErr:     "1 of 2 steps failed",
+			wantStatus:  4,
+			wantSummary: " => failed lint (exit status 4)\n => passed test\n",
+		},
+		{
+			name: "several fail",
+			input: `
+continue-on-error: true
+run:
+  - name: lint
+    command: exit 4
+  - name: test
+    command: exit 2
+  - name: docs
+    when: {os: fake}
+    command: exit 0
+`,
+			wantErr:    "2 of 3 steps failed",
+			wantStatus: 1,
+			wantSummary: " => failed lint (exit status 4)\n" +
+				" => failed test (exit status 2)\n" +
+				" => skipped docs\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(level ui.VerbosityLevel) {
+				ui.LoggerStderr.SetOutput(os.Stderr)
+				ui.Verbosity = level
+			}(ui.Verbosity)
+
+			buf := new(bytes.Buffer)
+			ui.LoggerStderr.SetOutput(buf)
+			ui.Verbosity = ui.VerbosityLevelNormal
+
+			var task Task
+			assert.NilError(t, yaml.UnmarshalStrict([]byte(tt.input), &task))
+			task.Name = "gate"
+
+			err := task.Execute(RunContext{})
+			assert.Check(t, cmp.Contains(buf.String(), "Summary: gate\n"+tt.wantSummary))
+
+			if tt.wantErr == "" {
+				assert.NilError(t, err)
+				return
+			}
+
+			assert.Error(t, err, tt.wantErr)
+			status, ok := ExitStatus(err)
+			assert.Assert(t, ok)
+			assert.Equal(t, status, tt.wantStatus)
+		})
+	}
+}
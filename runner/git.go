@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/rliebz/tusk/marshal"
+)
+
+// CleanGit determines whether a task requires a clean git working directory.
+//
+// CleanGit can be defined as either a boolean or an object, which allows the
+// check to be skipped when the task is run outside of a git repository.
+type CleanGit struct {
+	Required         bool `yaml:"-"`
+	AllowOutsideRepo bool `yaml:"allow-outside-repo"`
+}
+
+// UnmarshalYAML allows either a boolean or an object to define the check.
+func (c *CleanGit) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value bool
+	boolCandidate := marshal.UnmarshalCandidate{
+		Unmarshal: func() error { return unmarshal(&value) },
+		Assign:    func() { *c = CleanGit{Required: value} },
+	}
+
+	type cleanGitType CleanGit // Use new type to avoid recursion
+	var cleanGitItem cleanGitType
+	cleanGitCandidate := marshal.UnmarshalCandidate{
+		Unmarshal: func() error { return unmarshal(&cleanGitItem) },
+		Assign: func() {
+			*c = CleanGit(cleanGitItem)
+			c.Required = true
+		},
+	}
+
+	return marshal.UnmarshalOneOf(boolCandidate, cleanGitCandidate)
+}
+
+// checkCleanGit returns an error listing any uncommitted changes in the
+// working directory if the task requires it to be clean.
+func (t *Task) checkCleanGit() error {
+	if !t.RequireCleanGit.Required {
+		return nil
+	}
+
+	if !inGitRepo() {
+		if t.RequireCleanGit.AllowOutsideRepo {
+			return nil
+		}
+
+		return fmt.Errorf(
+			"task %q requires a clean git working directory, but is not in a git repository",
+			t.Name,
+		)
+	}
+
+	out, err := execCommand("git", "status", "--porcelain").Output()
+	if err != nil {
+		return fmt.Errorf("failed to get git status: %s", describeExitError(err))
+	}
+
+	status := strings.TrimRight(string(out), "\n")
+	if status == "" {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"task %q requires a clean git working directory, found changes:\n%s",
+		t.Name, status,
+	)
+}
+
+// inGitRepo returns whether the working directory is inside a git repository.
+func inGitRepo() bool {
+	out, err := execCommand("git", "rev-parse", "--is-inside-work-tree").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// describeExitError returns the error output of a failed command if there is
+// any, or the error itself otherwise.
+func describeExitError(err error) string {
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return strings.TrimSpace(string(exitErr.Stderr))
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,117 @@
+package runner
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestCleanGit_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		input string
+		want  CleanGit
+	}{
+		{`true`, CleanGit{Required: true}},
+		{`false`, CleanGit{}},
+		{`{allow-outside-repo: true}`, CleanGit{Required: true, AllowOutsideRepo: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var got CleanGit
+			assert.NilError(t, yaml.UnmarshalStrict([]byte(tt.input), &got))
+			assert.DeepEqual(t, tt.want, got)
+		})
+	}
+}
+
+func TestTask_checkCleanGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	tests := []struct {
+		name     string
+		repo     bool
+		dirty    bool
+		cleanGit CleanGit
+		wantErr  string
+	}{
+		{name: "not required", repo: true, dirty: true},
+		{name: "clean", repo: true, cleanGit: CleanGit{Required: true}},
+		{
+			name:     "dirty",
+			repo:     true,
+			dirty:    true,
+			cleanGit: CleanGit{Required: true},
+			wantErr:  "task \"release\" requires a clean git working directory, found changes:\n?? dirty.txt",
+		},
+		{
+			name:     "outside repo",
+			cleanGit: CleanGit{Required: true},
+			wantErr:  "not in a git repository",
+		},
+		{
+			name:     "outside repo allowed",
+			cleanGit: CleanGit{Required: true, AllowOutsideRepo: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fs.NewDir(t, "clean-git", fs.WithFile("committed.txt", "committed"))
+			defer dir.Remove()
+
+			wd, err := os.Getwd()
+			assert.NilError(t, err)
+			defer func() { assert.NilError(t, os.Chdir(wd)) }()
+			assert.NilError(t, os.Chdir(dir.Path()))
+
+			// Keep git from finding a repository in a parent directory
+			defer func(value string, ok bool) {
+				if ok {
+					os.Setenv("GIT_CEILING_DIRECTORIES", value) // nolint: errcheck
+				} else {
+					os.Unsetenv("GIT_CEILING_DIRECTORIES") // nolint: errcheck
+				}
+			}(os.LookupEnv("GIT_CEILING_DIRECTORIES"))
+			assert.NilError(t, os.Setenv("GIT_CEILING_DIRECTORIES", dir.Path()+"/.."))
+
+			if tt.repo {
+				runGit(t, "init", "--quiet")
+				runGit(t, "add", "committed.txt")
+				runGit(
+					t, "-c", "user.name=tusk", "-c", "user.email=tusk@example.com",
+					"commit", "--quiet", "--message", "initial",
+				)
+			}
+
+			if tt.dirty {
+				assert.NilError(t, ioutil.WriteFile("dirty.txt", []byte("dirty"), 0644))
+			}
+
+			task := Task{Name: "release", RequireCleanGit: tt.cleanGit}
+			err = task.checkCleanGit()
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+		})
+	}
+}
+
+// runGit runs a git command in the working directory.
+func runGit(t *testing.T, args ...string) {
+	t.Helper()
+
+	out, err := exec.Command("git", args...).CombinedOutput()
+	assert.NilError(t, err, "git %v: %s", args, out)
+}
@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Variables available to interpolate in before-each and after-each hooks.
+const (
+	stepNameVar  = "step-name"
+	stepIndexVar = "step-index"
+)
+
+// Environment variables set for the commands of before-each and after-each
+// hooks.
+const (
+	stepNameEnv  = "TUSK_STEP_NAME"
+	stepIndexEnv = "TUSK_STEP_INDEX"
+)
+
+// runStep runs the actions of a run item, wrapped by the task's before-each
+// and after-each hooks. The after-each hook runs even if the step fails, but
+// an error from the step takes precedence over one from the hook. Hooks are
+// not run around finally items.
+func (t *Task) runStep(ctx RunContext, r *Run, s executionState) (err error) {
+	if s != stateRunning || (len(t.BeforeEach) == 0 && len(t.AfterEach) == 0) {
+		return t.runActions(ctx, r, s)
+	}
+
+	index := strconv.Itoa(ctx.step)
+	hookCtx := ctx
+	hookCtx.exports = make(map[string]string, len(ctx.exports)+2)
+	for key, value := range ctx.exports {
+		hookCtx.exports[key] = value
+	}
+	hookCtx.exports[stepNameEnv] = r.label()
+	hookCtx.exports[stepIndexEnv] = index
+
+	// The shell expands the step name from the environment, so that it is
+	// never run as part of the command
+	execReplacer := strings.NewReplacer(
+		"${"+stepNameVar+"}", "${"+stepNameEnv+"}",
+		"${"+stepIndexVar+"}", index,
+	)
+	printReplacer := strings.NewReplacer(
+		"${"+stepNameVar+"}", r.label(),
+		"${"+stepIndexVar+"}", index,
+	)
+
+	defer func() {
+		after := &Run{Command: interpolateHook(t.AfterEach, execReplacer, printReplacer)}
+		if herr := t.runCommands(hookCtx, after, s); err == nil {
+			err = herr
+		}
+	}()
+
+	before := &Run{Command: interpolateHook(t.BeforeEach, execReplacer, printReplacer)}
+	if err := t.runCommands(hookCtx, before, s); err != nil {
+		return err
+	}
+
+	return t.runActions(ctx, r, s)
+}
+
+// interpolateHook returns a copy of the commands in a hook with the name and
+// index of the current step filled in. These are replaced directly rather
+// than with the usual interpolation, since step names are often commands
+// which may not be valid yaml. The command that is run and the one that is
+// printed are replaced separately.
+func interpolateHook(hook CommandList, execReplacer, printReplacer *strings.Replacer) CommandList {
+	commands := make(CommandList, 0, len(hook))
+	for _, c := range hook {
+		c.Exec = execReplacer.Replace(c.Exec)
+		c.Print = printReplacer.Replace(c.Print)
+		commands = append(commands, c)
+	}
+
+	return commands
+}
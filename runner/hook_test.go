@@ -0,0 +1,122 @@
+package runner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func TestTask_Execute_hooks(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		wantErr  string
+		wantLog  []string
+	}{
+		{
+			name:    "stops at failure",
+			wantErr: "exit status 3",
+			wantLog: []string{
+				"before 1 first", "first", "after 1 first",
+				"before 2 fails", "after 2 fails",
+			},
+		},
+		{
+			name:     "continue on error",
+			settings: "continue-on-error: true",
+			wantErr:  "1 of 3 steps failed",
+			wantLog: []string{
+				"before 1 first", "first", "after 1 first",
+				"before 2 fails", "after 2 fails",
+				"before 3 third", "third", "after 3 third",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fs.NewDir(t, "hooks")
+			defer dir.Remove()
+			log := filepath.Join(dir.Path(), "log")
+
+			input := fmt.Sprintf(`
+%s
+before-each: echo "before ${step-index} ${step-name}" >> %[2]s
+after-each: echo "after ${step-index} ${step-name}" >> %[2]s
+run:
+  - name: first
+    command: echo first >> %[2]s
+  - name: fails
+    command: exit 3
+  - name: third
+    command: echo third >> %[2]s
+finally:
+  - echo finally >> %[2]s
+`, tt.settings, log)
+
+			var task Task
+			assert.NilError(t, yaml.UnmarshalStrict([]byte(input), &task))
+
+			err := task.Execute(RunContext{})
+			assert.Error(t, err, tt.wantErr)
+
+			contents, err := ioutil.ReadFile(log)
+			assert.NilError(t, err)
+
+			want := strings.Join(append(tt.wantLog, "finally"), "\n") + "\n"
+			assert.Check(t, cmp.Equal(string(contents), want))
+		})
+	}
+}
+
+func TestTask_Execute_hooks_failing(t *testing.T) {
+	dir := fs.NewDir(t, "hooks")
+	defer dir.Remove()
+	log := filepath.Join(dir.Path(), "log")
+
+	input := fmt.Sprintf(`
+before-each: exit 2
+after-each: echo "after ${step-index}" >> %[1]s
+run: echo step >> %[1]s
+`, log)
+
+	var task Task
+	assert.NilError(t, yaml.UnmarshalStrict([]byte(input), &task))
+
+	err := task.Execute(RunContext{})
+	assert.Error(t, err, "exit status 2")
+
+	contents, err := ioutil.ReadFile(log)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(string(contents), "after 1\n"))
+}
+
+func TestTask_Execute_hooks_step_name_not_run(t *testing.T) {
+	dir := fs.NewDir(t, "hooks")
+	defer dir.Remove()
+	log := filepath.Join(dir.Path(), "log")
+
+	// The step has no name, so its command is used as the step name
+	input := fmt.Sprintf(`
+before-each: echo "${step-name}" >> %[1]s; echo "$TUSK_STEP_INDEX $TUSK_STEP_NAME" >> %[1]s
+run: true "$(touch %[1]s.injected)"
+`, log)
+
+	var task Task
+	assert.NilError(t, yaml.UnmarshalStrict([]byte(input), &task))
+
+	assert.NilError(t, task.Execute(RunContext{}))
+
+	contents, err := ioutil.ReadFile(log)
+	assert.NilError(t, err)
+
+	step := fmt.Sprintf(`true "$(touch %s.injected)"`, log)
+	assert.Check(t, cmp.Equal(string(contents), step+"\n1 "+step+"\n"))
+}
@@ -0,0 +1,190 @@
+package runner
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInterrupted is returned when a run is stopped by an interrupt.
+var ErrInterrupted = errors.New("interrupted")
+
+// IsInterrupted checks if an error was because the run was interrupted.
+func IsInterrupted(err error) bool {
+	if err == ErrInterrupted {
+		return true
+	}
+
+	if errs, ok := err.(multiError); ok {
+		for _, err := range errs {
+			if err == ErrInterrupted {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// interrupts tracks whether a run has been interrupted, along with the
+// commands currently running so that the interrupt can be forwarded to them.
+type interrupts struct {
+	mu        sync.Mutex
+	received  chan struct{}
+	processes map[*os.Process]struct{}
+}
+
+func newInterrupts() *interrupts {
+	return &interrupts{
+		received:  make(chan struct{}),
+		processes: make(map[*os.Process]struct{}),
+	}
+}
+
+// interrupt marks the run as interrupted and forwards the interrupt to every
+// command running. When tusk is run from a terminal, the terminal has already
+// sent the interrupt to the commands, so it is not sent a second time.
+func (i *interrupts) interrupt() {
+	forward := !isTerminal()
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if forward {
+		for p := range i.processes {
+			p.Signal(os.Interrupt) // nolint: errcheck
+		}
+	}
+
+	select {
+	case <-i.received:
+	default:
+		close(i.received)
+	}
+}
+
+// interrupted returns whether an interrupt has been received.
+func (i *interrupts) interrupted() bool {
+	select {
+	case <-i.received:
+		return true
+	default:
+		return false
+	}
+}
+
+// start starts a command and tracks it until wait is called.
+func (i *interrupts) start(cmd *exec.Cmd) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	i.processes[cmd.Process] = struct{}{}
+	return nil
+}
+
+// wait waits for a command started by start to exit.
+func (i *interrupts) wait(cmd *exec.Cmd) error {
+	err := cmd.Wait()
+
+	i.mu.Lock()
+	delete(i.processes, cmd.Process)
+	i.mu.Unlock()
+
+	return err
+}
+
+// HandleInterrupts handles interrupts for the rest of the run, so that the
+// finally steps of each task still run after the command running is stopped.
+// The returned function restores the default behavior.
+func (r *RunContext) HandleInterrupts() (stop func()) {
+	if r.interrupts == nil {
+		r.interrupts = newInterrupts()
+	}
+
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, os.Interrupt)
+
+	go func(i *interrupts) {
+		for {
+			select {
+			case <-c:
+				i.interrupt()
+			case <-done:
+				return
+			}
+		}
+	}(r.interrupts)
+
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
+
+// interrupted returns whether the run has been interrupted. Once finally steps
+// have started, they are run to completion regardless.
+func (r *RunContext) interrupted() bool {
+	return r.interrupts != nil && !r.finalizing && r.interrupts.interrupted()
+}
+
+// readLine reads a single line, returning early if the run is interrupted
+// while waiting for input.
+func (r *RunContext) readLine(in io.Reader) (string, error) {
+	if r.interrupts == nil || r.finalizing {
+		return readLine(in)
+	}
+
+	type result struct {
+		line string
+		err  error
+	}
+
+	c := make(chan result, 1)
+	go func() {
+		line, err := readLine(in)
+		c <- result{line, err}
+	}()
+
+	select {
+	case res := <-c:
+		return res.line, res.err
+	case <-r.interrupts.received:
+		return "", ErrInterrupted
+	}
+}
+
+// startCommand starts a command, tracking it if interrupts are handled.
+func (r *RunContext) startCommand(cmd *exec.Cmd) error {
+	if r.interrupts == nil {
+		return cmd.Start()
+	}
+
+	return r.interrupts.start(cmd)
+}
+
+// waitCommand waits for a command started by startCommand to exit.
+func (r *RunContext) waitCommand(cmd *exec.Cmd) error {
+	if r.interrupts == nil {
+		return cmd.Wait()
+	}
+
+	return r.interrupts.wait(cmd)
+}
+
+// runCommand starts a command and waits for it to exit.
+func (r *RunContext) runCommand(cmd *exec.Cmd) error {
+	if err := r.startCommand(cmd); err != nil {
+		return err
+	}
+
+	return r.waitCommand(cmd)
+}
@@ -0,0 +1,122 @@
+//go:build !windows
+// +build !windows
+
+package runner
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestTask_Execute_interrupt(t *testing.T) {
+	tests := []struct {
+		name        string
+		noCleanup   bool
+		wantFinally bool
+	}{
+		{name: "runs finally", wantFinally: true},
+		{name: "no cleanup", noCleanup: true, wantFinally: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(f func() bool) { isTerminal = f }(isTerminal)
+			isTerminal = func() bool { return false }
+
+			dir := fs.NewDir(t, "interrupt")
+			defer dir.Remove()
+
+			var task Task
+			err := yaml.UnmarshalStrict([]byte(`
+run:
+  - exec sleep 10
+  - touch `+dir.Join("next")+`
+finally: touch `+dir.Join("finally")+`
+`), &task)
+			assert.NilError(t, err)
+
+			ctx := (&Metadata{NoCleanupOnInterrupt: tt.noCleanup}).RunContext()
+			defer ctx.HandleInterrupts()()
+
+			errc := make(chan error, 1)
+			go func() { errc <- task.Execute(ctx) }()
+
+			waitForCommand(t, ctx.interrupts)
+			assert.NilError(t, syscall.Kill(os.Getpid(), syscall.SIGINT))
+
+			select {
+			case err = <-errc:
+			case <-time.After(5 * time.Second):
+				t.Fatal("task did not stop after being interrupted")
+			}
+
+			assert.Equal(t, ErrInterrupted, err)
+			assert.Check(t, !fileExists(dir.Join("next")), "run item after interrupt was run")
+			assert.Equal(t, tt.wantFinally, fileExists(dir.Join("finally")))
+		})
+	}
+}
+
+func TestTask_Execute_interrupt_confirm(t *testing.T) {
+	defer func(f func() bool, in io.Reader) {
+		isTerminal = f
+		confirmInput = in
+	}(isTerminal, confirmInput)
+	isTerminal = func() bool { return true }
+
+	// Nothing is written, so reading the answer blocks until interrupted
+	r, w, err := os.Pipe()
+	assert.NilError(t, err)
+	defer r.Close() // nolint: errcheck
+	defer w.Close() // nolint: errcheck
+	confirmInput = r
+
+	task := Task{Name: "deploy", Confirm: &Confirm{Prompt: "Deploy?"}}
+	ctx := (&Metadata{}).RunContext()
+	defer ctx.HandleInterrupts()()
+
+	errc := make(chan error, 1)
+	go func() { errc <- task.Execute(ctx) }()
+
+	time.Sleep(50 * time.Millisecond)
+	assert.NilError(t, syscall.Kill(os.Getpid(), syscall.SIGINT))
+
+	select {
+	case err = <-errc:
+	case <-time.After(5 * time.Second):
+		t.Fatal("confirmation did not stop after being interrupted")
+	}
+
+	assert.Equal(t, ErrInterrupted, err)
+}
+
+// waitForCommand waits until a command is running.
+func waitForCommand(t *testing.T, i *interrupts) {
+	t.Helper()
+
+	for start := time.Now(); time.Since(start) < 5*time.Second; {
+		i.mu.Lock()
+		n := len(i.processes)
+		i.mu.Unlock()
+
+		if n > 0 {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("command did not start")
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// layeredDefaultsPath returns the path of the file of option defaults for a
+// task, which is found beside the config file.
+func layeredDefaultsPath(dir, taskName string) string {
+	return filepath.Join(dir, fmt.Sprintf("tusk.%s.yml", taskName))
+}
+
+// layerDefaults replaces the defaults of a task's options with the values in
+// the task's defaults file, if one exists. Values passed by flag or set in the
+// environment still take priority.
+func (c *Config) layerDefaults(dir, taskName string) error {
+	t, ok := c.Tasks[taskName]
+	if !ok {
+		return nil
+	}
+
+	path := layeredDefaultsPath(dir, taskName)
+	text, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var defaults map[string]string
+	if err := yaml.UnmarshalStrict(text, &defaults); err != nil {
+		return errors.Wrapf(err, "decoding defaults file %s", path)
+	}
+
+	found, err := FindAllOptions(t, c)
+	if err != nil {
+		return err
+	}
+	options := Options(found)
+
+	for name, value := range defaults {
+		o, ok := options.Lookup(name)
+		if !ok {
+			return fmt.Errorf(
+				"defaults file %s sets %q, which is not an option of task %q",
+				path, name, taskName,
+			)
+		}
+
+		o.DefaultValues = ValueList{{Value: value}}
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+var layeredCfgText = []byte(`
+options:
+  region:
+    default: us-east-1
+tasks:
+  deploy:
+    options:
+      env:
+        default: dev
+      replicas:
+        default: "1"
+    run: echo ${env} ${replicas} ${region}
+  test:
+    options:
+      env:
+        default: dev
+    run: echo ${env}
+`)
+
+func TestParseComplete_layered_defaults(t *testing.T) {
+	dir := fs.NewDir(t, "layered",
+		fs.WithFile("tusk.deploy.yml", "env: staging\nreplicas: 3\nregion: eu-west-1\n"),
+	)
+	defer dir.Remove()
+
+	tests := []struct {
+		name     string
+		taskName string
+		layered  bool
+		flags    map[string]string
+		want     map[string]string
+	}{
+		{
+			name:     "applies to task",
+			taskName: "deploy",
+			layered:  true,
+			want:     map[string]string{"env": "staging", "replicas": "3", "region": "eu-west-1"},
+		},
+		{
+			name:     "overridden by flags",
+			taskName: "deploy",
+			layered:  true,
+			flags:    map[string]string{"env": "prod"},
+			want:     map[string]string{"env": "prod", "replicas": "3", "region": "eu-west-1"},
+		},
+		{
+			name:     "other task",
+			taskName: "test",
+			layered:  true,
+			want:     map[string]string{"env": "dev"},
+		},
+		{
+			name:     "not enabled",
+			taskName: "deploy",
+			want:     map[string]string{"env": "dev", "replicas": "1", "region": "us-east-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := &Metadata{
+				CfgText:         layeredCfgText,
+				Directory:       dir.Path(),
+				LayeredDefaults: tt.layered,
+			}
+
+			cfg, err := ParseComplete(meta, tt.taskName, nil, tt.flags)
+			assert.NilError(t, err)
+
+			vars := cfg.Tasks[tt.taskName].Vars
+			for name, want := range tt.want {
+				assert.Check(t, vars[name] == want, "%s: want %q, got %q", name, want, vars[name])
+			}
+		})
+	}
+}
+
+func TestParseComplete_layered_defaults_unknown_option(t *testing.T) {
+	dir := fs.NewDir(t, "layered",
+		fs.WithFile("tusk.test.yml", "replicas: 3\n"),
+	)
+	defer dir.Remove()
+
+	meta := &Metadata{
+		CfgText:         layeredCfgText,
+		Directory:       dir.Path(),
+		LayeredDefaults: true,
+	}
+
+	_, err := ParseComplete(meta, "test", nil, nil)
+	assert.ErrorContains(t, err, `sets "replicas", which is not an option of task "test"`)
+}
@@ -0,0 +1,169 @@
+package runner
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// unknownFieldPattern matches the errors from strict unmarshaling for fields
+// that are not defined.
+var unknownFieldPattern = regexp.MustCompile(`^line (\d+): field (.+) not found in type \S+$`)
+
+// unknownField is a field reported by strict unmarshaling as not defined.
+type unknownField struct {
+	line int
+	key  string
+}
+
+// unmarshalLenient unmarshals text strictly, except that fields which are not
+// defined are removed instead of failing. The paths of the fields removed are
+// returned, so that they can be reported.
+func unmarshalLenient(text []byte, out interface{}) ([]string, error) {
+	err := yaml.UnmarshalStrict(text, out)
+	if err == nil {
+		return nil, nil
+	}
+	if _, ok := unknownFields(err); !ok {
+		return nil, err
+	}
+
+	// Fields are found by line, so the text is normalized to match the output
+	// of marshaling each part of the document
+	var root yaml.MapSlice
+	if err := yaml.Unmarshal(text, &root); err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for {
+		normalized, err := yaml.Marshal(root)
+		if err != nil {
+			return nil, err
+		}
+
+		target := reflect.New(reflect.TypeOf(out).Elem())
+		err = yaml.UnmarshalStrict(normalized, target.Interface())
+		if err == nil {
+			reflect.ValueOf(out).Elem().Set(target.Elem())
+			return removed, nil
+		}
+
+		fields, ok := unknownFields(err)
+		if !ok {
+			return nil, err
+		}
+
+		// Later lines are removed first, so that earlier lines do not move
+		sort.Slice(fields, func(i, j int) bool { return fields[i].line > fields[j].line })
+		paths := make([]string, len(fields))
+		for i, field := range fields {
+			value, path, ok := removeField(root, 1, field)
+			if !ok {
+				return nil, err
+			}
+
+			root = value.(yaml.MapSlice)
+			paths[len(fields)-1-i] = path
+		}
+		removed = append(removed, paths...)
+	}
+}
+
+// unknownFields returns the fields that are not defined from an error, or
+// false if the error is for any other reason.
+func unknownFields(err error) ([]unknownField, bool) {
+	typeErr, ok := err.(*yaml.TypeError)
+	if !ok {
+		return nil, false
+	}
+
+	fields := make([]unknownField, 0, len(typeErr.Errors))
+	for _, message := range typeErr.Errors {
+		match := unknownFieldPattern.FindStringSubmatch(message)
+		if match == nil {
+			return nil, false
+		}
+
+		line, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, false
+		}
+
+		fields = append(fields, unknownField{line: line, key: match[2]})
+	}
+
+	return fields, true
+}
+
+// removeField removes a field from a node, given the line the node starts on
+// when marshaled. It returns the node without the field, along with the path
+// to the field removed.
+func removeField(node interface{}, start int, field unknownField) (interface{}, string, bool) {
+	switch n := node.(type) {
+	case yaml.MapSlice:
+		for i, item := range n {
+			lines, err := countLines(yaml.MapSlice{item})
+			if err != nil {
+				return node, "", false
+			}
+
+			key := fmt.Sprint(item.Key)
+			switch {
+			case field.line == start && key == field.key:
+				return append(n[:i:i], n[i+1:]...), key, true
+			case field.line > start && field.line < start+lines:
+				// Values that span lines start on the line after the key
+				value, path, ok := removeField(item.Value, start+1, field)
+				if !ok {
+					return node, "", false
+				}
+
+				n[i].Value = value
+				if strings.HasPrefix(path, "[") {
+					return n, key + path, true
+				}
+				return n, key + "." + path, true
+			}
+
+			start += lines
+		}
+	case []interface{}:
+		for i, item := range n {
+			lines, err := countLines([]interface{}{item})
+			if err != nil {
+				return node, "", false
+			}
+
+			if field.line >= start && field.line < start+lines {
+				// Items start on the same line as the list marker
+				value, path, ok := removeField(item, start, field)
+				if !ok {
+					return node, "", false
+				}
+
+				n[i] = value
+				return n, fmt.Sprintf("[%d].%s", i, path), true
+			}
+
+			start += lines
+		}
+	}
+
+	return node, "", false
+}
+
+// countLines returns the number of lines a node takes up when marshaled.
+func countLines(node interface{}) (int, error) {
+	text, err := yaml.Marshal(node)
+	if err != nil {
+		return 0, err
+	}
+
+	return strings.Count(string(text), "\n"), nil
+}
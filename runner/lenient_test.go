@@ -0,0 +1,92 @@
+package runner
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+var unknownFieldsConfig = `
+future: true
+options:
+  foo:
+    future: true
+tasks:
+  mytask:
+    future: true
+    args:
+      bar:
+        future: true
+    run:
+      - command:
+          exec: echo ${bar}
+          future: true
+      - future: true
+        command: echo second
+`
+
+func TestParse_unknown_fields_strict(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"default", unknownFieldsConfig},
+		{"strict", "strict: true\n" + unknownFieldsConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.text))
+			assert.ErrorContains(t, err, "field future not found")
+		})
+	}
+}
+
+func TestParse_unknown_fields_lenient(t *testing.T) {
+	cfg, _, err := parse([]byte("strict: false\n" + unknownFieldsConfig))
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, cfg.ignoredFields, []string{
+		"future",
+		"options.foo.future",
+		"tasks.mytask.future",
+		"tasks.mytask.args.bar.future",
+		"tasks.mytask.run[0].command.future",
+		"tasks.mytask.run[1].future",
+	})
+
+	task := cfg.Tasks["mytask"]
+	assert.Equal(t, task.Args[0].Name, "bar")
+	assert.Equal(t, len(task.RunList), 2)
+	assert.Equal(t, task.RunList[0].Command[0].Exec, "echo ${bar}")
+	assert.Equal(t, task.RunList[1].Command[0].Exec, "echo second")
+	assert.Equal(t, cfg.Options[0].Name, "foo")
+}
+
+func TestParse_unknown_fields_lenient_other_errors(t *testing.T) {
+	text := `
+strict: false
+tasks:
+  mytask:
+    future: true
+    usage: [not a string]
+`
+	_, _, err := parse([]byte(text))
+	assert.ErrorContains(t, err, "cannot unmarshal")
+}
+
+func TestParse_lenient_without_unknown_fields(t *testing.T) {
+	cfg, _, err := parse([]byte("strict: false\ntasks:\n  mytask:\n    run: echo hi\n"))
+	assert.NilError(t, err)
+	assert.Equal(t, len(cfg.ignoredFields), 0)
+}
+
+func TestParseComplete_unknown_fields_fail_on(t *testing.T) {
+	meta := &Metadata{
+		CfgText:            []byte("strict: false\n" + unknownFieldsConfig),
+		FailOnUnknownField: true,
+	}
+
+	_, err := ParseComplete(meta, "mytask", []string{"baz"}, map[string]string{})
+	assert.Error(t, err, "unknown field future")
+}
@@ -0,0 +1,44 @@
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/rliebz/tusk/ui"
+)
+
+// stdbufCommand is the program used to run commands with line-buffered output.
+const stdbufCommand = "stdbuf"
+
+// lookPath allows overwriting during tests.
+var lookPath = exec.LookPath
+
+// shellArgs returns the program and arguments that run a command in the
+// shell. Line-buffered commands are run through stdbuf, which applies to
+// every program the shell starts that uses the C standard library for output.
+// Where stdbuf is not installed, the command is run as usual with a warning,
+// unless strict is set, in which case an error is returned instead.
+func (c *Command) shellArgs(strict bool) (string, []string, error) {
+	args := []string{"-c", c.Exec}
+	if !c.LineBuffered {
+		return getShell(), args, nil
+	}
+
+	stdbuf, err := lookPath(stdbufCommand)
+	if err != nil {
+		if strict {
+			return "", nil, fmt.Errorf(
+				"%s is not installed, cannot line-buffer command: %s",
+				stdbufCommand, c.Print,
+			)
+		}
+
+		ui.Warn(fmt.Sprintf(
+			"%s is not installed, running command without line buffering: %s",
+			stdbufCommand, c.Print,
+		))
+		return getShell(), args, nil
+	}
+
+	return stdbuf, append([]string{"-oL", "-eL", getShell()}, args...), nil
+}
@@ -0,0 +1,129 @@
+package runner
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/rliebz/tusk/ui"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+func TestCommand_shellArgs(t *testing.T) {
+	defer func() { lookPath = exec.LookPath }()
+	shell := getShell()
+
+	tests := []struct {
+		name     string
+		command  Command
+		found    bool
+		strict   bool
+		wantName string
+		wantArgs []string
+		wantErr  string
+	}{
+		{
+			name:     "default",
+			command:  Command{Exec: "make"},
+			found:    true,
+			wantName: shell,
+			wantArgs: []string{"-c", "make"},
+		},
+		{
+			name:     "line-buffered",
+			command:  Command{Exec: "make", LineBuffered: true},
+			found:    true,
+			wantName: "/usr/bin/stdbuf",
+			wantArgs: []string{"-oL", "-eL", shell, "-c", "make"},
+		},
+		{
+			name:     "stdbuf not installed",
+			command:  Command{Exec: "make", LineBuffered: true},
+			wantName: shell,
+			wantArgs: []string{"-c", "make"},
+		},
+		{
+			name:    "stdbuf not installed strict",
+			command: Command{Exec: "make", Print: "make", LineBuffered: true},
+			strict:  true,
+			wantErr: "stdbuf is not installed, cannot line-buffer command: make",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookPath = func(file string) (string, error) {
+				if !tt.found {
+					return "", errors.New("not found")
+				}
+				return "/usr/bin/" + file, nil
+			}
+
+			name, args, err := tt.command.shellArgs(tt.strict)
+			if tt.wantErr != "" {
+				assert.Error(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Check(t, cmp.Equal(name, tt.wantName))
+			assert.Check(t, cmp.DeepEqual(args, tt.wantArgs))
+		})
+	}
+}
+
+func TestContainer_shellCommand_line_buffered(t *testing.T) {
+	ctx := RunContext{container: &Container{Image: "alpine"}}
+
+	_, err := ctx.shellCommand(Command{Exec: "true", LineBuffered: true})
+	assert.ErrorContains(t, err, "cannot be line-buffered")
+}
+
+// firstWriteWriter signals the first time it is written to.
+type firstWriteWriter struct {
+	written chan struct{}
+}
+
+func (w *firstWriteWriter) Write(p []byte) (int, error) {
+	select {
+	case <-w.written:
+	default:
+		close(w.written)
+	}
+
+	return len(p), nil
+}
+
+func TestCommand_exec_line_buffered(t *testing.T) {
+	if _, err := exec.LookPath(stdbufCommand); err != nil {
+		t.Skip("stdbuf is not installed")
+	}
+	if _, err := exec.LookPath("sed"); err != nil {
+		t.Skip("sed is not installed")
+	}
+
+	defer func(w io.Writer) { ui.Stdout = w }(ui.Stdout)
+	w := &firstWriteWriter{written: make(chan struct{})}
+	ui.Stdout = w
+
+	// sed buffers its output in blocks when it is not writing to a terminal,
+	// so without line buffering nothing is written until it exits.
+	c := Command{
+		Exec:         "{ echo one; sleep 2; echo two; } | sed 's/^/> /'",
+		LineBuffered: true,
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- c.exec(RunContext{}) }()
+
+	select {
+	case <-w.written:
+	case <-time.After(time.Second):
+		t.Fatal("no output was written while the command was running")
+	}
+
+	assert.NilError(t, <-done)
+}
@@ -0,0 +1,326 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rliebz/tusk/marshal"
+	"github.com/rliebz/tusk/ui"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Matrix is an ordered list of options and the values to run a task with, so
+// that the task is run once for every combination of values.
+type Matrix []MatrixAxis
+
+// MatrixAxis is an option and the values to run a task with.
+type MatrixAxis struct {
+	Option string
+	Values marshal.StringList
+}
+
+// UnmarshalYAML unmarshals a map of option names to values in order.
+func (m *Matrix) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var ms yaml.MapSlice
+	if err := unmarshal(&ms); err != nil {
+		return err
+	}
+
+	// Values are read as strings, so that versions such as 1.20 are kept as-is
+	var values map[string]marshal.StringList
+	if err := unmarshal(&values); err != nil {
+		return err
+	}
+
+	matrix := make(Matrix, 0, len(ms))
+	for _, item := range ms {
+		name, ok := item.Key.(string)
+		if !ok {
+			return fmt.Errorf("%q is not a valid key name", item.Key)
+		}
+
+		axis := MatrixAxis{Option: name, Values: values[name]}
+		if err := axis.validate(); err != nil {
+			return err
+		}
+
+		matrix = append(matrix, axis)
+	}
+
+	*m = matrix
+
+	return nil
+}
+
+// MarshalYAML marshals the matrix as a map of option names to values.
+func (m Matrix) MarshalYAML() (interface{}, error) {
+	ms := make(yaml.MapSlice, 0, len(m))
+	for _, axis := range m {
+		ms = append(ms, yaml.MapItem{Key: axis.Option, Value: axis.Values})
+	}
+
+	return ms, nil
+}
+
+func (a MatrixAxis) validate() error {
+	if len(a.Values) == 0 {
+		return fmt.Errorf("matrix option %q must have at least one value", a.Option)
+	}
+
+	return nil
+}
+
+// parseMatrix parses a matrix passed by command line, which is a
+// space-separated list of options of the form name=value1,value2.
+func parseMatrix(text string) (Matrix, error) {
+	var matrix Matrix
+	for _, field := range strings.Fields(text) {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid matrix %q, must be of the form option=value1,value2", field)
+		}
+
+		axis := MatrixAxis{Option: parts[0]}
+		for _, value := range strings.Split(parts[1], ",") {
+			if value != "" {
+				axis.Values = append(axis.Values, value)
+			}
+		}
+		if err := axis.validate(); err != nil {
+			return nil, err
+		}
+
+		matrix = matrix.with(axis)
+	}
+
+	return matrix, nil
+}
+
+// with returns the matrix with an axis added, replacing any axis for the same
+// option in place.
+func (m Matrix) with(axis MatrixAxis) Matrix {
+	combined := append(Matrix{}, m...)
+	for i := range combined {
+		if combined[i].Option == axis.Option {
+			combined[i] = axis
+			return combined
+		}
+	}
+
+	return append(combined, axis)
+}
+
+// combinations returns every combination of values in the matrix, with the
+// values of the last option changing fastest.
+func (m Matrix) combinations() []MatrixValues {
+	combinations := []MatrixValues{nil}
+	for _, axis := range m {
+		next := make([]MatrixValues, 0, len(combinations)*len(axis.Values))
+		for _, combination := range combinations {
+			for _, value := range axis.Values {
+				values := append(combination[:len(combination):len(combination)], MatrixValue{
+					Option: axis.Option,
+					Value:  value,
+				})
+				next = append(next, values)
+			}
+		}
+		combinations = next
+	}
+
+	return combinations
+}
+
+// MatrixValue is the value of a single option in a combination.
+type MatrixValue struct {
+	Option string
+	Value  string
+}
+
+// MatrixValues are the values of each option in a combination.
+type MatrixValues []MatrixValue
+
+// String returns the values as a space-separated list of name=value.
+func (v MatrixValues) String() string {
+	parts := make([]string, 0, len(v))
+	for _, value := range v {
+		parts = append(parts, value.Option+"="+value.Value)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// MatrixRun is a task parsed with a single combination of matrix values.
+type MatrixRun struct {
+	Values MatrixValues
+	Task   *Task
+}
+
+// ParseMatrix parses a config and task once for every combination of the
+// task's matrix, which is the matrix defined by the task overridden by any
+// options in the matrix passed by command line. The config returned is the
+// one parsed for the first combination.
+//
+// If there is no matrix, the config is parsed as with ParseComplete and no
+// runs are returned.
+func ParseMatrix(
+	meta *Metadata,
+	taskName string,
+	args []string,
+	flags map[string]string,
+) (*Config, []MatrixRun, error) {
+	matrix, err := findMatrix(meta, taskName, flags)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(matrix) == 0 {
+		cfg, err := ParseComplete(meta, taskName, args, flags)
+		return cfg, nil, err
+	}
+
+	var first *Config
+	var runs []MatrixRun
+	for _, values := range matrix.combinations() {
+		combined := make(map[string]string, len(flags)+len(values))
+		for name, value := range flags {
+			combined[name] = value
+		}
+		for _, value := range values {
+			combined[value.Option] = value.Value
+		}
+
+		var cfg *Config
+		if first == nil {
+			cfg, err = ParseComplete(meta, taskName, args, combined)
+			first = cfg
+		} else {
+			cfg, _, err = parseTask(meta, newTaskOutputs(meta), taskName, args, combined, nil)
+		}
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "matrix %s", values)
+		}
+
+		runs = append(runs, MatrixRun{Values: values, Task: cfg.Tasks[taskName]})
+	}
+
+	return first, runs, nil
+}
+
+// findMatrix returns the matrix for a task, checking that each option in it is
+// used by the task and is not also passed by command line.
+func findMatrix(meta *Metadata, taskName string, flags map[string]string) (Matrix, error) {
+	cfg, _, err := parse(meta.CfgText)
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := cfg.Tasks[taskName]
+	if !ok {
+		return nil, nil
+	}
+
+	matrix := t.Matrix
+	for _, axis := range meta.Matrix {
+		matrix = matrix.with(axis)
+	}
+	if len(matrix) == 0 {
+		return nil, nil
+	}
+
+	options, err := FindAllOptions(t, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, axis := range matrix {
+		if _, ok := flags[axis.Option]; ok {
+			return nil, fmt.Errorf(
+				"option %q cannot be passed when it is set by the matrix", axis.Option,
+			)
+		}
+
+		if !hasOption(options, axis.Option) {
+			return nil, fmt.Errorf(
+				"matrix option %q is not an option of task %q", axis.Option, taskName,
+			)
+		}
+	}
+
+	return matrix, nil
+}
+
+func hasOption(options []*Option, name string) bool {
+	for _, o := range options {
+		if o.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matrixResult is the outcome of a single run in a matrix.
+type matrixResult struct {
+	err     error
+	elapsed time.Duration
+}
+
+// RunMatrix executes each run in a matrix, either in order or concurrently,
+// and prints a summary of the results. Every run is executed even if some
+// fail, and their errors are combined.
+//
+// Since environment variables are shared by the whole process, runs of tasks
+// that use set-environment cannot be executed concurrently.
+func RunMatrix(ctx RunContext, runs []MatrixRun, parallel bool) error {
+	if parallel && len(runs) > 0 && runs[0].Task.setsEnvironment() {
+		return fmt.Errorf(
+			"task %q uses set-environment, so its matrix cannot be run in parallel",
+			runs[0].Task.Name,
+		)
+	}
+
+	ctx.inMatrix = true
+	results := make([]matrixResult, len(runs))
+	execute := func(i int) {
+		start := now()
+		err := runs[i].Task.Execute(ctx)
+		if err != nil && !IsInterrupted(err) {
+			err = errors.Wrapf(err, "matrix %s", runs[i].Values)
+		}
+		results[i] = matrixResult{err, now().Sub(start)}
+	}
+
+	if parallel {
+		ctx.runParallel(len(runs), execute)
+	} else {
+		for i := range runs {
+			execute(i)
+			if IsInterrupted(results[i].err) {
+				results = results[:i+1]
+				break
+			}
+		}
+	}
+
+	errs := make([]error, 0, len(results))
+	for i, result := range results {
+		status := "passed"
+		switch {
+		case IsInterrupted(result.err):
+			status = "interrupted"
+		case result.err != nil:
+			status = "failed"
+		}
+		ui.Summary(fmt.Sprintf(
+			"matrix %s: %s in %s",
+			runs[i].Values, status, result.elapsed.Round(time.Millisecond),
+		))
+
+		errs = append(errs, result.err)
+	}
+
+	return combineErrors(errs...)
+}
@@ -0,0 +1,259 @@
+package runner
+
+import (
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/rliebz/tusk/marshal"
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func TestMatrix_UnmarshalYAML(t *testing.T) {
+	var m Matrix
+	err := yaml.UnmarshalStrict([]byte(`{os: [linux, darwin], go: [1.20, 1.22], arch: amd64}`), &m)
+	assert.NilError(t, err)
+
+	want := Matrix{
+		{Option: "os", Values: marshal.StringList{"linux", "darwin"}},
+		{Option: "go", Values: marshal.StringList{"1.20", "1.22"}},
+		{Option: "arch", Values: marshal.StringList{"amd64"}},
+	}
+	assert.DeepEqual(t, want, m)
+}
+
+func TestMatrix_UnmarshalYAML_empty_values(t *testing.T) {
+	var m Matrix
+	err := yaml.UnmarshalStrict([]byte(`{os: []}`), &m)
+	assert.Error(t, err, `matrix option "os" must have at least one value`)
+}
+
+func TestParseMatrix_flag(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Matrix
+		wantErr string
+	}{
+		{input: "", want: nil},
+		{
+			input: "go=1.21,1.22 os=linux",
+			want: Matrix{
+				{Option: "go", Values: marshal.StringList{"1.21", "1.22"}},
+				{Option: "os", Values: marshal.StringList{"linux"}},
+			},
+		},
+		{
+			input: "go=1.21 go=1.22",
+			want:  Matrix{{Option: "go", Values: marshal.StringList{"1.22"}}},
+		},
+		{input: "go", wantErr: `invalid matrix "go", must be of the form option=value1,value2`},
+		{input: "=1.21", wantErr: `invalid matrix "=1.21", must be of the form option=value1,value2`},
+		{input: "go=,", wantErr: `matrix option "go" must have at least one value`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseMatrix(tt.input)
+			if tt.wantErr != "" {
+				assert.Error(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.want, got)
+		})
+	}
+}
+
+var matrixCfg = []byte(`
+tasks:
+  test:
+    options:
+      go:
+        default: "1.19"
+      os:
+        required: true
+      race:
+        type: bool
+    matrix:
+      go: [1.21, 1.22]
+    run: echo go=${go} os=${os}
+`)
+
+func TestParseMatrix(t *testing.T) {
+	meta := &Metadata{
+		CfgText: matrixCfg,
+		Matrix:  Matrix{{Option: "os", Values: marshal.StringList{"linux", "darwin"}}},
+	}
+
+	cfg, runs, err := ParseMatrix(meta, "test", nil, map[string]string{"race": "true"})
+	assert.NilError(t, err)
+	assert.Check(t, cfg.Tasks["test"] != nil)
+	assert.Equal(t, 4, len(runs))
+
+	wantValues := []string{
+		"go=1.21 os=linux",
+		"go=1.21 os=darwin",
+		"go=1.22 os=linux",
+		"go=1.22 os=darwin",
+	}
+
+	for i, run := range runs {
+		assert.Equal(t, wantValues[i], run.Values.String())
+
+		want := map[string]string{
+			"go":   run.Values[0].Value,
+			"os":   run.Values[1].Value,
+			"race": "true",
+		}
+		assert.DeepEqual(t, want, run.Task.Vars)
+		assert.Equal(t, "echo "+wantValues[i], run.Task.RunList[0].Command[0].Exec)
+	}
+}
+
+func TestParseMatrix_none(t *testing.T) {
+	meta := &Metadata{CfgText: matrixCfg}
+
+	cfg, runs, err := ParseMatrix(meta, "other", nil, map[string]string{})
+	assert.NilError(t, err)
+	assert.Check(t, cfg != nil)
+	assert.Equal(t, 0, len(runs))
+}
+
+func TestParseMatrix_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		matrix  Matrix
+		flags   map[string]string
+		wantErr string
+	}{
+		{
+			name:    "unknown option",
+			matrix:  Matrix{{Option: "arch", Values: marshal.StringList{"amd64"}}},
+			wantErr: `matrix option "arch" is not an option of task "test"`,
+		},
+		{
+			name:    "option passed",
+			matrix:  Matrix{{Option: "os", Values: marshal.StringList{"linux"}}},
+			flags:   map[string]string{"go": "1.20"},
+			wantErr: `option "go" cannot be passed when it is set by the matrix`,
+		},
+		{
+			name:    "missing required option",
+			wantErr: "matrix go=1.21: no value passed for required option: os",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := &Metadata{CfgText: matrixCfg, Matrix: tt.matrix}
+			_, _, err := ParseMatrix(meta, "test", nil, tt.flags)
+			assert.Error(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestRunMatrix(t *testing.T) {
+	dir := fs.NewDir(t, "matrix")
+	defer dir.Remove()
+
+	meta := &Metadata{
+		CfgText: []byte(`
+tasks:
+  test:
+    options:
+      go: {}
+      os: {}
+    matrix:
+      go: [1.21, 1.22]
+      os: [linux, darwin]
+    run:
+      - echo ${go}-${os} >> ` + dir.Join("runs") + `
+      - test ${go}-${os} != 1.22-linux
+`),
+	}
+
+	for _, parallel := range []bool{false, true} {
+		assert.NilError(t, ioutil.WriteFile(dir.Join("runs"), nil, 0644))
+
+		_, runs, err := ParseMatrix(meta, "test", nil, map[string]string{})
+		assert.NilError(t, err)
+
+		err = RunMatrix(RunContext{}, runs, parallel)
+		assert.ErrorContains(t, err, "matrix go=1.22 os=linux: exit status 1")
+
+		out, err := ioutil.ReadFile(dir.Join("runs"))
+		assert.NilError(t, err)
+
+		got := strings.Fields(string(out))
+		sort.Strings(got)
+		want := []string{"1.21-darwin", "1.21-linux", "1.22-darwin", "1.22-linux"}
+		assert.DeepEqual(t, want, got)
+	}
+}
+
+func TestRunMatrix_exit_status(t *testing.T) {
+	exitCode := 4
+	runs := []MatrixRun{
+		{Values: MatrixValues{{Option: "n", Value: "1"}}, Task: &Task{RunList: RunList{
+			&Run{Command: CommandList{{Exec: "exit 0", Print: "exit 0"}}},
+		}}},
+		{Values: MatrixValues{{Option: "n", Value: "2"}}, Task: &Task{RunList: RunList{
+			&Run{Abort: &Abort{Message: "stopped", ExitCode: &exitCode}},
+		}}},
+	}
+
+	err := RunMatrix(RunContext{}, runs, false)
+	assert.Error(t, err, "matrix n=2: stopped")
+
+	status, ok := ExitStatus(err)
+	assert.Check(t, ok)
+	assert.Check(t, cmp.Equal(status, 4))
+}
+
+func TestRunMatrix_parallel_set_environment(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  setup:
+    run:
+      - set-environment: {STAGE: ready}
+  direct:
+    options:
+      v: {}
+    matrix:
+      v: [a, b]
+    run:
+      - set-environment: {STAGE: "${v}"}
+  nested:
+    options:
+      v: {}
+    matrix:
+      v: [a, b]
+    run:
+      - task: setup
+  unset:
+    options:
+      v: {}
+    matrix:
+      v: [a, b]
+    run: echo ${v}
+    finally:
+      - unset: STAGE
+`)
+
+	for _, name := range []string{"direct", "nested", "unset"} {
+		t.Run(name, func(t *testing.T) {
+			_, runs, err := ParseMatrix(&Metadata{CfgText: cfgText}, name, nil, map[string]string{})
+			assert.NilError(t, err)
+
+			err = RunMatrix(RunContext{}, runs, true)
+			assert.Error(t, err,
+				`task "`+name+`" uses set-environment, so its matrix cannot be run in parallel`,
+			)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package runner
+
+import (
+	"fmt"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// mergeDocuments combines multiple yaml documents into a single document,
+// where values in later documents take priority.
+//
+// Options are merged field by field, so a later document can override a
+// single part of an option, such as its default. Tasks are replaced entirely,
+// including tasks within a namespace.
+// Snippets cannot be replaced, so defining one in multiple documents is an
+// error.
+func mergeDocuments(docs []yaml.MapSlice) (merged yaml.MapSlice, warnings []string, err error) {
+	for _, doc := range docs {
+		for _, item := range doc {
+			existing, ok := lookupKey(merged, item.Key)
+			if !ok {
+				merged = setKey(merged, item.Key, item.Value)
+				continue
+			}
+
+			switch item.Key {
+			case "options":
+				merged = setKey(merged, item.Key, mergeOptions(existing, item.Value))
+			case "tasks":
+				tasks, taskWarnings := mergeTasks(existing, item.Value, "")
+				merged = setKey(merged, item.Key, tasks)
+				warnings = append(warnings, taskWarnings...)
+			case "namespaces":
+				namespaces, taskWarnings := mergeNamespaces(existing, item.Value)
+				merged = setKey(merged, item.Key, namespaces)
+				warnings = append(warnings, taskWarnings...)
+			case "snippets":
+				snippets, err := mergeSnippets(existing, item.Value)
+				if err != nil {
+					return nil, nil, err
+				}
+				merged = setKey(merged, item.Key, snippets)
+			default:
+				merged = setKey(merged, item.Key, item.Value)
+			}
+		}
+	}
+
+	return merged, warnings, nil
+}
+
+func mergeOptions(base, overlay interface{}) interface{} {
+	baseOptions, ok := base.(yaml.MapSlice)
+	if !ok {
+		return overlay
+	}
+
+	overlayOptions, ok := overlay.(yaml.MapSlice)
+	if !ok {
+		return overlay
+	}
+
+	for _, item := range overlayOptions {
+		existing, _ := lookupKey(baseOptions, item.Key)
+		existingFields, isMap := existing.(yaml.MapSlice)
+		overlayFields, isOverlayMap := item.Value.(yaml.MapSlice)
+		if !isMap || !isOverlayMap {
+			baseOptions = setKey(baseOptions, item.Key, item.Value)
+			continue
+		}
+
+		for _, field := range overlayFields {
+			existingFields = setKey(existingFields, field.Key, field.Value)
+		}
+		baseOptions = setKey(baseOptions, item.Key, existingFields)
+	}
+
+	return baseOptions
+}
+
+// mergeTasks replaces the tasks in base with those in overlay, warning about
+// each one replaced. Task names in warnings are given the prefix passed.
+func mergeTasks(base, overlay interface{}, prefix string) (interface{}, []string) {
+	baseTasks, ok := base.(yaml.MapSlice)
+	if !ok {
+		return overlay, nil
+	}
+
+	overlayTasks, ok := overlay.(yaml.MapSlice)
+	if !ok {
+		return overlay, nil
+	}
+
+	var warnings []string
+	for _, item := range overlayTasks {
+		if _, ok := lookupKey(baseTasks, item.Key); ok {
+			warnings = append(warnings, fmt.Sprintf(
+				"task %q is defined in multiple documents, using the last definition",
+				fmt.Sprint(prefix, item.Key),
+			))
+		}
+
+		baseTasks = setKey(baseTasks, item.Key, item.Value)
+	}
+
+	return baseTasks, warnings
+}
+
+// mergeNamespaces merges the tasks of each namespace defined in both base and
+// overlay.
+func mergeNamespaces(base, overlay interface{}) (interface{}, []string) {
+	baseNamespaces, ok := base.(yaml.MapSlice)
+	if !ok {
+		return overlay, nil
+	}
+
+	overlayNamespaces, ok := overlay.(yaml.MapSlice)
+	if !ok {
+		return overlay, nil
+	}
+
+	var warnings []string
+	for _, item := range overlayNamespaces {
+		existing, _ := lookupKey(baseNamespaces, item.Key)
+		existingFields, isMap := existing.(yaml.MapSlice)
+		overlayFields, isOverlayMap := item.Value.(yaml.MapSlice)
+		if !isMap || !isOverlayMap {
+			baseNamespaces = setKey(baseNamespaces, item.Key, item.Value)
+			continue
+		}
+
+		for _, field := range overlayFields {
+			value := field.Value
+			if field.Key == "tasks" {
+				existingTasks, _ := lookupKey(existingFields, field.Key)
+				prefix := fmt.Sprint(item.Key, namespaceSeparator)
+
+				var taskWarnings []string
+				value, taskWarnings = mergeTasks(existingTasks, field.Value, prefix)
+				warnings = append(warnings, taskWarnings...)
+			}
+
+			existingFields = setKey(existingFields, field.Key, value)
+		}
+		baseNamespaces = setKey(baseNamespaces, item.Key, existingFields)
+	}
+
+	return baseNamespaces, warnings
+}
+
+func mergeSnippets(base, overlay interface{}) (interface{}, error) {
+	baseSnippets, ok := base.(yaml.MapSlice)
+	if !ok {
+		return overlay, nil
+	}
+
+	overlaySnippets, ok := overlay.(yaml.MapSlice)
+	if !ok {
+		return overlay, nil
+	}
+
+	for _, item := range overlaySnippets {
+		if _, ok := lookupKey(baseSnippets, item.Key); ok {
+			return nil, fmt.Errorf("snippet %q is defined in multiple documents", item.Key)
+		}
+
+		baseSnippets = setKey(baseSnippets, item.Key, item.Value)
+	}
+
+	return baseSnippets, nil
+}
+
+// lookupKey returns the value for a key in a map slice.
+func lookupKey(ms yaml.MapSlice, key interface{}) (interface{}, bool) {
+	for _, item := range ms {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// setKey sets the value for a key in a map slice, preserving its position if
+// the key already exists.
+func setKey(ms yaml.MapSlice, key, value interface{}) yaml.MapSlice {
+	for i := range ms {
+		if ms[i].Key == key {
+			ms[i].Value = value
+			return ms
+		}
+	}
+
+	return append(ms, yaml.MapItem{Key: key, Value: value})
+}
@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParse_multiple_documents(t *testing.T) {
+	cfgText := []byte(`
+name: base
+options:
+  env:
+    usage: The environment to deploy to
+    default: dev
+  region:
+    default: local
+tasks:
+  deploy:
+    run: echo base
+  test:
+    run: echo test
+---
+options:
+  env:
+    default: prod
+tasks:
+  deploy:
+    run: echo overlay
+`)
+
+	cfg, warnings, err := parse(cfgText)
+	assert.NilError(t, err)
+
+	assert.Equal(t, cfg.Name, "base")
+
+	assert.Equal(t, len(cfg.Options), 2)
+	assert.Equal(t, cfg.Options[0].Name, "env")
+	assert.Equal(t, cfg.Options[0].Usage, "The environment to deploy to")
+	assert.Equal(t, cfg.Options[0].DefaultValues[0].Value, "prod")
+	assert.Equal(t, cfg.Options[1].Name, "region")
+	assert.Equal(t, cfg.Options[1].DefaultValues[0].Value, "local")
+
+	assert.Equal(t, cfg.Tasks["deploy"].RunList[0].Command[0].Exec, "echo overlay")
+	assert.Equal(t, cfg.Tasks["test"].RunList[0].Command[0].Exec, "echo test")
+
+	assert.DeepEqual(t, warnings, []string{
+		`task "deploy" is defined in multiple documents, using the last definition`,
+	})
+}
+
+func TestParseComplete_fail_on_duplicate_task(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  deploy:
+    run: echo base
+---
+tasks:
+  deploy:
+    run: echo overlay
+`)
+
+	meta := &Metadata{CfgText: cfgText, FailOnBudget: true}
+	cfg, err := ParseComplete(meta, "deploy", []string{}, map[string]string{})
+	assert.NilError(t, err)
+	assert.Equal(t, cfg.Tasks["deploy"].RunList[0].Command[0].Exec, "echo overlay")
+
+	meta = &Metadata{CfgText: cfgText, FailOnDuplicateTask: true}
+	_, err = ParseComplete(meta, "deploy", []string{}, map[string]string{})
+	assert.Error(t, err, `task "deploy" is defined in multiple documents, using the last definition`)
+}
+
+func TestParse_multiple_documents_invalid(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  deploy:
+    run: echo base
+---
+tasks: [
+`)
+
+	_, err := Parse(cfgText)
+	assert.ErrorContains(t, err, "decoding document 2")
+
+	cfgText = []byte(`
+tasks:
+  deploy:
+    run: echo base
+---
+tasks:
+  deploy:
+    not-a-field: true
+`)
+
+	_, err = Parse(cfgText)
+	assert.ErrorContains(t, err, "not-a-field")
+}
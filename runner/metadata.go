@@ -1,21 +1,56 @@
 package runner
 
 import (
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"strings"
 
 	"github.com/rliebz/tusk/ui"
 )
 
 // Metadata contains global configuration settings.
 type Metadata struct {
-	CfgText             []byte
-	Directory           string
-	InstallCompletion   string
-	UninstallCompletion string
-	PrintHelp           bool
-	PrintVersion        bool
-	Verbosity           ui.VerbosityLevel
+	AllowNetwork         bool
+	Artifacts            bool
+	CaptureOutput        string
+	CfgPath              string
+	CfgText              []byte
+	CheckOnly            bool
+	Directory            string
+	Docs                 string
+	EnvDumpOnFailure     bool
+	ExplainOption        string
+	ExportOptions        string
+	FailOnBudget         bool
+	FailOnDuplicateTask  bool
+	FailOnLineBuffering  bool
+	FailOnUndeclared     bool
+	FailOnUnknownField   bool
+	IgnoreVersion        bool
+	InstallCompletion    string
+	LayeredDefaults      bool
+	ListChangedOptions   bool
+	MaskSecrets          bool
+	Matrix               Matrix
+	MatrixParallel       bool
+	MaxParallel          int
+	NoCleanupOnInterrupt bool
+	NoEnvInherit         bool
+	NoInteractive        bool
+	OnFailure            string
+	ParallelOrder        string
+	Seed                 int64
+	UninstallCompletion  string
+	PrintHelp            bool
+	PrintVersion         bool
+	RunDir               string
+	SummaryOnly          bool
+	Verbosity            ui.VerbosityLevel
+	VerboseErrors        bool
+	Version              string
+	Which                bool
+	Yes                  bool
 }
 
 // Set sets the metadata based on options.
@@ -41,15 +76,142 @@ func (m *Metadata) Set(o OptGetter) error {
 		}
 	}
 
+	failOn, err := parseFailOn(o.String("fail-on"))
+	if err != nil {
+		return err
+	}
+
+	m.AllowNetwork = o.Bool("allow-network")
+	m.Artifacts = o.Bool("artifacts")
+	m.CaptureOutput = o.String("capture-output")
+	m.CfgPath = fullPath
+	m.CheckOnly = o.Bool("check")
+	m.Docs = o.String("docs")
+	m.EnvDumpOnFailure = o.Bool("env-dump-on-failure")
+	m.ExplainOption = o.String("explain-option")
+	if exportOptions := o.String("export-options"); exportOptions != "" {
+		// Resolve the path before changing to the config file's directory
+		if m.ExportOptions, err = filepath.Abs(exportOptions); err != nil {
+			return err
+		}
+	}
+	m.FailOnBudget = o.Bool("fail-on-budget") || failOn[warningBudget]
+	m.FailOnDuplicateTask = failOn[warningDuplicateTask]
+	m.FailOnLineBuffering = failOn[warningLineBuffering]
+	m.FailOnUndeclared = failOn[warningUndeclared]
+	m.FailOnUnknownField = failOn[warningUnknownField]
+	m.IgnoreVersion = o.Bool("ignore-version")
 	m.InstallCompletion = o.String("install-completion")
+	m.LayeredDefaults = o.Bool("layered-defaults")
+	m.ListChangedOptions = o.Bool("list-changed-options")
+	m.MaskSecrets = o.Bool("mask-secrets")
+	if m.Matrix, err = parseMatrix(o.String("matrix")); err != nil {
+		return err
+	}
+	m.MatrixParallel = o.Bool("matrix-parallel")
+	if m.MaxParallel, err = parseMaxParallel(o.String("max-parallel")); err != nil {
+		return err
+	}
+	m.NoCleanupOnInterrupt = o.Bool("no-cleanup-on-interrupt")
+	m.NoEnvInherit = o.Bool("no-env-inherit")
+	m.NoInteractive = o.Bool("no-interactive")
+	m.OnFailure = o.String("on-failure")
+	m.ParallelOrder, m.Seed, err = parseParallelOrder(o.String("parallel-order"), o.String("seed"))
+	if err != nil {
+		return err
+	}
 	m.UninstallCompletion = o.String("uninstall-completion")
 	m.Directory = filepath.Dir(fullPath)
 	m.PrintHelp = o.Bool("help")
 	m.PrintVersion = o.Bool("version")
+	if runDir := o.String("run-dir"); runDir != "" {
+		// Resolve the path before changing to the config file's directory
+		if m.RunDir, err = filepath.Abs(runDir); err != nil {
+			return err
+		}
+	}
+	m.SummaryOnly = o.Bool("summary-only")
 	m.Verbosity = getVerbosity(o)
+	m.VerboseErrors = o.Bool("verbose-errors")
+	m.Which = o.Bool("which")
+	m.Yes = o.Bool("yes")
 	return nil
 }
 
+// RunContext returns a new run context based on the metadata settings.
+func (m *Metadata) RunContext() RunContext {
+	ctx := RunContext{
+		AssumeYes:            m.Yes,
+		CheckOnly:            m.CheckOnly,
+		EnvDumpOnFailure:     m.EnvDumpOnFailure,
+		FailOnBudget:         m.FailOnBudget,
+		FailOnLineBuffering:  m.FailOnLineBuffering,
+		MaxParallel:          m.MaxParallel,
+		NoCleanupOnInterrupt: m.NoCleanupOnInterrupt,
+		NoEnvInherit:         m.NoEnvInherit,
+		ParallelOrder:        m.ParallelOrder,
+		Seed:                 m.Seed,
+		SummaryOnly:          m.SummaryOnly,
+		VerboseErrors:        m.VerboseErrors,
+		setEnvironment:       make(map[string]struct{}),
+		interrupts:           newInterrupts(),
+	}
+
+	if m.RunDir != "" {
+		ctx.stepLogs = &stepLogs{dir: m.RunDir}
+	}
+
+	return ctx
+}
+
+// Warning categories that can be treated as failures using --fail-on.
+const (
+	warningBudget        = "budget"
+	warningDuplicateTask = "duplicate-task"
+	warningLineBuffering = "line-buffering"
+	warningUndeclared    = "undeclared-variable"
+	warningUnknownField  = "unknown-field"
+)
+
+var warningCategories = []string{
+	warningBudget,
+	warningDuplicateTask,
+	warningLineBuffering,
+	warningUndeclared,
+	warningUnknownField,
+}
+
+// parseFailOn parses a comma-separated list of warning categories.
+func parseFailOn(text string) (map[string]bool, error) {
+	failOn := make(map[string]bool)
+	if text == "" {
+		return failOn, nil
+	}
+
+	for _, category := range strings.Split(text, ",") {
+		category = strings.TrimSpace(category)
+
+		found := false
+		for _, known := range warningCategories {
+			if category == known {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf(
+				"unknown warning category %q for --fail-on, must be one of: %s",
+				category, strings.Join(warningCategories, ", "),
+			)
+		}
+
+		failOn[category] = true
+	}
+
+	return failOn, nil
+}
+
 // OptGetter pulls various options based on a name.
 // These options will generally come from the command line.
 type OptGetter interface {
@@ -61,7 +223,7 @@ func getVerbosity(c OptGetter) ui.VerbosityLevel {
 	switch {
 	case c.Bool("silent"):
 		return ui.VerbosityLevelSilent
-	case c.Bool("quiet"):
+	case c.Bool("quiet"), c.Bool("summary-only"):
 		return ui.VerbosityLevelQuiet
 	case c.Bool("verbose"):
 		return ui.VerbosityLevelVerbose
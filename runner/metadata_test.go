@@ -3,9 +3,11 @@ package runner
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/rliebz/tusk/marshal"
 	"github.com/rliebz/tusk/ui"
 	"gotest.tools/v3/fs"
 )
@@ -68,6 +70,7 @@ func TestMetadata_Set(t *testing.T) {
 				"file": cfgFile.Path(),
 			},
 			Metadata{
+				CfgPath:   cfgFile.Path(),
 				CfgText:   []byte(cfgFileContents),
 				Directory: filepath.Dir(cfgFile.Path()),
 				Verbosity: ui.VerbosityLevelNormal,
@@ -79,6 +82,7 @@ func TestMetadata_Set(t *testing.T) {
 			nil,
 			nil,
 			Metadata{
+				CfgPath:   filepath.Join(dirFull.Path(), "tusk.yml"),
 				CfgText:   []byte(dirFullContents),
 				Directory: dirFull.Path(),
 				Verbosity: ui.VerbosityLevelNormal,
@@ -92,6 +96,7 @@ func TestMetadata_Set(t *testing.T) {
 				"file": cfgFile.Path(),
 			},
 			Metadata{
+				CfgPath:   cfgFile.Path(),
 				CfgText:   []byte(cfgFileContents),
 				Directory: filepath.Dir(cfgFile.Path()),
 				Verbosity: ui.VerbosityLevelNormal,
@@ -124,6 +129,304 @@ func TestMetadata_Set(t *testing.T) {
 			},
 			"",
 		},
+		{
+			"allow-network",
+			map[string]bool{
+				"allow-network": true,
+			},
+			nil,
+			Metadata{
+				AllowNetwork: true,
+				Directory:    ".",
+				Verbosity:    ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"artifacts",
+			map[string]bool{
+				"artifacts": true,
+			},
+			nil,
+			Metadata{
+				Artifacts: true,
+				Directory: ".",
+				Verbosity: ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"fail-on-budget",
+			map[string]bool{
+				"fail-on-budget": true,
+			},
+			nil,
+			Metadata{
+				Directory:    ".",
+				FailOnBudget: true,
+				Verbosity:    ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"fail-on budget",
+			nil,
+			map[string]string{
+				"fail-on": "budget",
+			},
+			Metadata{
+				Directory:    ".",
+				FailOnBudget: true,
+				Verbosity:    ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"fail-on all categories",
+			nil,
+			map[string]string{
+				"fail-on": "duplicate-task, budget,line-buffering,undeclared-variable,unknown-field",
+			},
+			Metadata{
+				Directory:           ".",
+				FailOnBudget:        true,
+				FailOnDuplicateTask: true,
+				FailOnLineBuffering: true,
+				FailOnUndeclared:    true,
+				FailOnUnknownField:  true,
+				Verbosity:           ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"capture-output",
+			nil,
+			map[string]string{
+				"capture-output": "transcript.log",
+			},
+			Metadata{
+				CaptureOutput: "transcript.log",
+				Directory:     ".",
+				Verbosity:     ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"ignore-version",
+			map[string]bool{
+				"ignore-version": true,
+			},
+			nil,
+			Metadata{
+				Directory:     ".",
+				IgnoreVersion: true,
+				Verbosity:     ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"run-dir",
+			nil,
+			map[string]string{
+				"run-dir": "/tmp/tusk-run",
+			},
+			Metadata{
+				Directory: ".",
+				RunDir:    "/tmp/tusk-run",
+				Verbosity: ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"export-options",
+			nil,
+			map[string]string{
+				"export-options": "/tmp/options.env",
+			},
+			Metadata{
+				Directory:     ".",
+				ExportOptions: "/tmp/options.env",
+				Verbosity:     ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"mask-secrets",
+			map[string]bool{
+				"mask-secrets": true,
+			},
+			nil,
+			Metadata{
+				Directory:   ".",
+				MaskSecrets: true,
+				Verbosity:   ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"no-interactive",
+			map[string]bool{
+				"no-interactive": true,
+			},
+			nil,
+			Metadata{
+				Directory:     ".",
+				NoInteractive: true,
+				Verbosity:     ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"on-failure",
+			nil,
+			map[string]string{
+				"on-failure": "notify",
+			},
+			Metadata{
+				Directory: ".",
+				OnFailure: "notify",
+				Verbosity: ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"list-changed-options",
+			map[string]bool{
+				"list-changed-options": true,
+			},
+			nil,
+			Metadata{
+				Directory:          ".",
+				ListChangedOptions: true,
+				Verbosity:          ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"parallel-order",
+			nil,
+			map[string]string{
+				"parallel-order": "random",
+				"seed":           "42",
+			},
+			Metadata{
+				Directory:     ".",
+				ParallelOrder: ParallelOrderRandom,
+				Seed:          42,
+				Verbosity:     ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"max-parallel",
+			nil,
+			map[string]string{
+				"max-parallel": "2",
+			},
+			Metadata{
+				Directory:   ".",
+				MaxParallel: 2,
+				Verbosity:   ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"matrix",
+			map[string]bool{
+				"matrix-parallel": true,
+			},
+			map[string]string{
+				"matrix": "go=1.21,1.22 os=linux",
+			},
+			Metadata{
+				Directory: ".",
+				Matrix: Matrix{
+					{Option: "go", Values: marshal.StringList{"1.21", "1.22"}},
+					{Option: "os", Values: marshal.StringList{"linux"}},
+				},
+				MatrixParallel: true,
+				Verbosity:      ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"no-cleanup-on-interrupt",
+			map[string]bool{
+				"no-cleanup-on-interrupt": true,
+			},
+			nil,
+			Metadata{
+				Directory:            ".",
+				NoCleanupOnInterrupt: true,
+				Verbosity:            ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"no-env-inherit",
+			map[string]bool{
+				"no-env-inherit": true,
+			},
+			nil,
+			Metadata{
+				Directory:    ".",
+				NoEnvInherit: true,
+				Verbosity:    ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"which",
+			map[string]bool{
+				"which": true,
+			},
+			nil,
+			Metadata{
+				Directory: ".",
+				Verbosity: ui.VerbosityLevelNormal,
+				Which:     true,
+			},
+			"",
+		},
+		{
+			"yes",
+			map[string]bool{
+				"yes": true,
+			},
+			nil,
+			Metadata{
+				Directory: ".",
+				Verbosity: ui.VerbosityLevelNormal,
+				Yes:       true,
+			},
+			"",
+		},
+		{
+			"check",
+			map[string]bool{
+				"check": true,
+			},
+			nil,
+			Metadata{
+				CheckOnly: true,
+				Directory: ".",
+				Verbosity: ui.VerbosityLevelNormal,
+			},
+			"",
+		},
+		{
+			"docs",
+			nil,
+			map[string]string{
+				"docs": "markdown",
+			},
+			Metadata{
+				Directory: ".",
+				Docs:      "markdown",
+				Verbosity: ui.VerbosityLevelNormal,
+			},
+			"",
+		},
 		{
 			"print-help",
 			map[string]bool{
@@ -174,6 +477,19 @@ func TestMetadata_Set(t *testing.T) {
 			},
 			"",
 		},
+		{
+			"summary-only",
+			map[string]bool{
+				"summary-only": true,
+			},
+			nil,
+			Metadata{
+				Directory:   ".",
+				SummaryOnly: true,
+				Verbosity:   ui.VerbosityLevelQuiet,
+			},
+			"",
+		},
 		{
 			"verbosity-verbose",
 			map[string]bool{
@@ -186,6 +502,19 @@ func TestMetadata_Set(t *testing.T) {
 			},
 			"",
 		},
+		{
+			"verbose-errors",
+			map[string]bool{
+				"verbose-errors": true,
+			},
+			nil,
+			Metadata{
+				Directory:     ".",
+				Verbosity:     ui.VerbosityLevelNormal,
+				VerboseErrors: true,
+			},
+			"",
+		},
 		{
 			"verbosity-prefers-silence",
 			map[string]bool{
@@ -243,3 +572,15 @@ func TestMetadata_Set(t *testing.T) {
 		})
 	}
 }
+
+func TestMetadata_Set_fail_on_unknown(t *testing.T) {
+	opts := mockOptGetter{
+		strings: map[string]string{"fail-on": "budget,deprecated"},
+	}
+
+	var meta Metadata
+	err := meta.Set(opts)
+	if err == nil || !strings.Contains(err.Error(), `unknown warning category "deprecated"`) {
+		t.Errorf("meta.Set(): want error for unknown category, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// namespaceSeparator separates the name of a namespace from the names of the
+// tasks within it.
+const namespaceSeparator = ":"
+
+// Namespace is a group of related tasks. Tasks in a namespace are named with
+// the name of the namespace as a prefix, such as db:migrate.
+type Namespace struct {
+	Tasks map[string]*Task `yaml:"tasks"`
+}
+
+// addNamespaces adds the tasks of each namespace to the config by their full
+// names.
+func (c *Config) addNamespaces() error {
+	for ns, namespace := range c.Namespaces {
+		if ns == "" || strings.Contains(ns, namespaceSeparator) {
+			return fmt.Errorf("invalid namespace name %q", ns)
+		}
+
+		for name, t := range namespace.Tasks {
+			fullName := ns + namespaceSeparator + name
+			if _, ok := c.Tasks[fullName]; ok {
+				return fmt.Errorf(
+					"task %q is defined both in namespace %q and in tasks", fullName, ns,
+				)
+			}
+
+			if c.Tasks == nil {
+				c.Tasks = make(map[string]*Task)
+			}
+
+			t.Namespace = ns
+			c.Tasks[fullName] = t
+		}
+	}
+
+	return nil
+}
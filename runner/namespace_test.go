@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+var namespaceCfgText = []byte(`
+namespaces:
+  db:
+    tasks:
+      migrate:
+        options:
+          target:
+            default: latest
+        run: echo migrate ${target}
+      reset:
+        run:
+          - task: db:migrate
+  app:
+    tasks:
+      start:
+        run:
+          - task:
+              name: db:migrate
+              options:
+                target: v2
+tasks:
+  build:
+    run: echo build
+`)
+
+func TestParse_namespaces(t *testing.T) {
+	cfg, err := Parse(namespaceCfgText)
+	assert.NilError(t, err)
+
+	for name, namespace := range map[string]string{
+		"build":      "",
+		"db:migrate": "db",
+		"db:reset":   "db",
+		"app:start":  "app",
+	} {
+		task, ok := cfg.Tasks[name]
+		assert.Assert(t, ok, "task %s not found", name)
+		assert.Check(t, cmp.Equal(task.Name, name))
+		assert.Check(t, cmp.Equal(task.Namespace, namespace))
+	}
+	assert.Check(t, cmp.Len(cfg.Tasks, 4))
+}
+
+func TestParseComplete_namespaces_sub_tasks(t *testing.T) {
+	tests := []struct {
+		taskName string
+		want     string
+	}{
+		{"db:reset", "echo migrate latest"},
+		{"app:start", "echo migrate v2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.taskName, func(t *testing.T) {
+			cfg, err := ParseComplete(&Metadata{CfgText: namespaceCfgText}, tt.taskName, nil, nil)
+			assert.NilError(t, err)
+
+			sub := cfg.Tasks[tt.taskName].RunList[0].Tasks[0]
+			assert.Check(t, cmp.Equal(sub.Name, "db:migrate"))
+			assert.Check(t, cmp.Equal(sub.RunList[0].Command[0].Exec, tt.want))
+		})
+	}
+}
+
+func TestParse_namespaces_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name: "duplicate task",
+			input: `
+namespaces:
+  db:
+    tasks:
+      migrate: {run: echo namespaced}
+tasks:
+  db:migrate: {run: echo top-level}
+`,
+			wantErr: `task "db:migrate" is defined both in namespace "db" and in tasks`,
+		},
+		{
+			name: "separator in name",
+			input: `
+namespaces:
+  db:prod:
+    tasks:
+      migrate: {run: echo migrate}
+`,
+			wantErr: `invalid namespace name "db:prod"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input))
+			assert.Error(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParse_namespaces_multiple_documents(t *testing.T) {
+	cfgText := []byte(`
+namespaces:
+  db:
+    tasks:
+      migrate: {run: echo base}
+      seed: {run: echo seed}
+---
+namespaces:
+  db:
+    tasks:
+      migrate: {run: echo overlay}
+`)
+
+	cfg, warnings, err := parse(cfgText)
+	assert.NilError(t, err)
+
+	assert.Equal(t, cfg.Tasks["db:migrate"].RunList[0].Command[0].Exec, "echo overlay")
+	assert.Equal(t, cfg.Tasks["db:seed"].RunList[0].Command[0].Exec, "echo seed")
+
+	assert.DeepEqual(t, warnings, []string{
+		`task "db:migrate" is defined in multiple documents, using the last definition`,
+	})
+}
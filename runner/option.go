@@ -3,6 +3,8 @@ package runner
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -10,34 +12,46 @@ import (
 	yaml "gopkg.in/yaml.v2"
 )
 
+// envVarPattern matches valid environment variable names.
+var envVarPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 // Option represents an abstract command line option.
 type Option struct {
 	ValueWithList `yaml:",inline"`
+	PathCheck     `yaml:",inline"`
 
 	Short    string
 	Type     string
 	Usage    string
 	Private  bool
 	Required bool
+	Secret   bool
+
+	ConflictsWith marshal.StringList `yaml:"conflicts-with,omitempty"`
+	DependsOn     marshal.StringList `yaml:"depends-on,omitempty"`
+	ExportAs      string             `yaml:"export-as,omitempty"`
 
 	// Used to determine value
 	Environment   string
 	DefaultValues ValueList `yaml:"default"`
 
 	// Computed members not specified in yaml file
-	Name       string `yaml:"-"`
-	Passed     string `yaml:"-"`
-	cacheValue string `yaml:"-"`
-	isCacheSet bool   `yaml:"-"`
+	Name         string       `yaml:"-"`
+	Passed       string       `yaml:"-"`
+	allowNetwork bool         `yaml:"-"`
+	taskOutputs  *taskOutputs `yaml:"-"`
+	cacheValue   string       `yaml:"-"`
+	isCacheSet   bool         `yaml:"-"`
 }
 
 // Dependencies returns a list of options that are required explicitly.
 // This does not include interpolations.
 func (o *Option) Dependencies() []string {
-	options := make([]string, 0, len(o.DefaultValues))
+	options := make([]string, 0, len(o.DefaultValues)+len(o.DependsOn))
 	for _, value := range o.DefaultValues {
 		options = append(options, value.When.Dependencies()...)
 	}
+	options = append(options, o.DependsOn...)
 
 	return options
 }
@@ -73,19 +87,39 @@ func (o *Option) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		}
 	}
 
+	if o.ExportAs != "" && !envVarPattern.MatchString(o.ExportAs) {
+		return fmt.Errorf(
+			"invalid export-as name %q: must contain only letters, digits, "+
+				"and underscores, and not start with a digit",
+			o.ExportAs,
+		)
+	}
+
+	if err := o.PathCheck.validate(o.isPath()); err != nil {
+		return err
+	}
+
 	if o.Required && len(o.DefaultValues) > 0 {
 		return errors.New("default value defined for required option")
 	}
 
+	for _, value := range o.DefaultValues {
+		for i := range value.When {
+			if value.When[i].usesOptionSet() {
+				return errors.New("option-set cannot be used in option defaults")
+			}
+		}
+	}
+
 	return nil
 }
 
 // Evaluate determines an option's value.
 //
 // The order of priority is:
-//   1. Command-line option passed
-//   2. Environment variable set
-//   3. The first item in the default value list with a valid when clause
+//  1. Command-line option passed
+//  2. Environment variable set
+//  3. The first item in the default value list with a valid when clause
 //
 // Values may also be cached to avoid re-running commands.
 func (o *Option) Evaluate(vars map[string]string) (string, error) {
@@ -98,6 +132,10 @@ func (o *Option) Evaluate(vars map[string]string) (string, error) {
 		return "", err
 	}
 
+	if err := o.checkPath(value); err != nil {
+		return "", err
+	}
+
 	o.cache(value)
 
 	return value, nil
@@ -147,6 +185,18 @@ func (o *Option) getDefaultValue(vars map[string]string) (string, error) {
 			continue
 		}
 
+		if candidate.URL != "" && !o.allowNetwork {
+			return "", fmt.Errorf(
+				"option %s reads from a url, but network access is not allowed; "+
+					"use --allow-network to allow it",
+				o.Name,
+			)
+		}
+
+		if candidate.FromTask != nil {
+			return o.taskOutputs.value(o.Name, candidate.FromTask)
+		}
+
 		value, err := candidate.commandValueOrDefault()
 		if err != nil {
 			return "", errors.Wrapf(err, "could not compute value for option: %s", o.Name)
@@ -166,6 +216,135 @@ func (o *Option) getDefaultValue(vars map[string]string) (string, error) {
 	return "", nil
 }
 
+// specifiedOptions returns the names of options that were set explicitly,
+// rather than by a default value.
+func specifiedOptions(options []*Option) map[string]bool {
+	specified := make(map[string]bool, len(options))
+	for _, o := range options {
+		if _, found := o.getSpecified(); found && !o.Private {
+			specified[o.Name] = true
+		}
+	}
+
+	return specified
+}
+
+// checkConflicts returns an error if any two options that conflict with each
+// other have both been specified. Conflicts apply in both directions.
+func checkConflicts(options []*Option, specified map[string]bool) error {
+	for _, o := range options {
+		if !specified[o.Name] {
+			continue
+		}
+
+		for _, name := range o.ConflictsWith {
+			if specified[name] {
+				return fmt.Errorf(
+					"options %q and %q cannot be specified together", o.Name, name,
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkConflictNames returns an error if any option conflicts with an option
+// that is not declared, which would otherwise never conflict. Task options can
+// conflict with shared options or those of the same task, and shared options
+// can conflict with any declared option.
+func (c *Config) checkConflictNames() error {
+	shared := make(map[string]bool, len(c.Options))
+	all := make(map[string]bool, len(c.Options))
+	for _, o := range c.Options {
+		shared[o.Name] = true
+		all[o.Name] = true
+	}
+	for _, t := range c.Tasks {
+		for _, o := range t.Options {
+			all[o.Name] = true
+		}
+	}
+
+	if err := checkConflictsKnown(c.Options, all); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(c.Tasks))
+	for name := range c.Tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		t := c.Tasks[name]
+
+		known := make(map[string]bool, len(shared)+len(t.Options))
+		for name := range shared {
+			known[name] = true
+		}
+		for _, o := range t.Options {
+			known[o.Name] = true
+		}
+
+		if err := checkConflictsKnown(t.Options, known); err != nil {
+			return errors.Wrapf(err, "task %q", name)
+		}
+	}
+
+	return nil
+}
+
+func checkConflictsKnown(options []*Option, known map[string]bool) error {
+	for _, o := range options {
+		for _, name := range o.ConflictsWith {
+			if !known[name] {
+				return fmt.Errorf("option %q conflicts with unknown option %q", o.Name, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkRequireOneOf returns an error unless exactly one of the options named
+// has been specified.
+func checkRequireOneOf(options []*Option, names []string, specified map[string]bool) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	known := make(map[string]bool, len(options))
+	for _, o := range options {
+		known[o.Name] = true
+	}
+
+	var passed []string
+	for _, name := range names {
+		if !known[name] {
+			return fmt.Errorf("require-one-of references unknown option %q", name)
+		}
+
+		if specified[name] {
+			passed = append(passed, name)
+		}
+	}
+
+	switch len(passed) {
+	case 0:
+		return fmt.Errorf(
+			"one of the options %s must be specified", strings.Join(names, ", "),
+		)
+	case 1:
+		return nil
+	default:
+		return fmt.Errorf(
+			"only one of the options %s can be specified, got %s",
+			strings.Join(names, ", "), strings.Join(passed, ", "),
+		)
+	}
+}
+
 func (o *Option) cache(value string) {
 	o.isCacheSet = true
 	o.cacheValue = value
@@ -199,7 +378,14 @@ func (o *Options) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return err
 	}
 
-	options, err := getOptionsWithOrder(ms)
+	// Options are unmarshaled as part of the same document, so that errors
+	// refer to lines of the config file
+	var values map[string]*Option
+	if err := unmarshal(&values); err != nil {
+		return err
+	}
+
+	options, err := getOptionsWithOrder(ms, values)
 	if err != nil {
 		return err
 	}
@@ -220,21 +406,29 @@ func (o *Options) Lookup(name string) (*Option, bool) {
 	return nil, false
 }
 
-// getOptionsWithOrder returns both the option map and the ordered names.
-func getOptionsWithOrder(ms yaml.MapSlice) ([]*Option, error) {
+// getOptionsWithOrder returns the options in order with names assigned.
+func getOptionsWithOrder(ms yaml.MapSlice, values map[string]*Option) ([]*Option, error) {
 	options := make([]*Option, 0, len(ms))
-	assign := func(name string, text []byte) error {
-		var opt Option
-		if err := yaml.UnmarshalStrict(text, &opt); err != nil {
-			return err
+	for _, item := range ms {
+		name, ok := item.Key.(string)
+		if !ok {
+			return nil, fmt.Errorf("%q is not a valid key name", item.Key)
+		}
+
+		opt := values[name]
+		if opt == nil {
+			opt = new(Option)
 		}
 		opt.Name = name
 
-		options = append(options, &opt)
+		for _, conflict := range opt.ConflictsWith {
+			if conflict == name {
+				return nil, fmt.Errorf("option %q cannot conflict with itself", name)
+			}
+		}
 
-		return nil
+		options = append(options, opt)
 	}
 
-	_, err := marshal.ParseOrderedMap(ms, assign)
-	return options, err
+	return options, nil
 }
@@ -1,12 +1,16 @@
 package runner
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"testing"
 
 	"github.com/rliebz/tusk/marshal"
 	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
 )
 
 func TestOption_Dependencies(t *testing.T) {
@@ -19,9 +23,9 @@ func TestOption_Dependencies(t *testing.T) {
 		{When: WhenList{createWhen(
 			withWhenNotEqual("baz", "bazvalue"),
 		)}, Value: "bar"},
-	}}
+	}, DependsOn: []string{"qux"}}
 
-	expected := []string{"foo", "bar", "baz"}
+	expected := []string{"foo", "bar", "baz", "qux"}
 	actual := option.Dependencies()
 	if !equalUnordered(expected, actual) {
 		t.Errorf(
@@ -327,6 +331,27 @@ var evaluteTypeDefaultTests = []struct {
 	{"", ""},
 }
 
+func TestOption_Evaluate_url_requires_network(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "fetched")
+	}))
+	defer server.Close()
+
+	option := Option{
+		Name:          "foo",
+		DefaultValues: ValueList{{URL: server.URL}},
+	}
+
+	_, err := option.Evaluate(nil)
+	assert.ErrorContains(t, err, "network access is not allowed")
+
+	option.allowNetwork = true
+
+	value, err := option.Evaluate(nil)
+	assert.NilError(t, err)
+	assert.Equal(t, value, "fetched")
+}
+
 func TestOption_Evaluate_type_defaults(t *testing.T) {
 	for _, tt := range evaluteTypeDefaultTests {
 		opt := Option{Type: tt.typeName}
@@ -396,6 +421,14 @@ var unmarshalOptionErrorTests = []struct {
 		"required and default defined",
 		"{required: true, default: foo}",
 	},
+	{
+		"export-as starts with a digit",
+		"{export-as: 1REGION}",
+	},
+	{
+		"export-as contains invalid characters",
+		"{export-as: MY-REGION}",
+	},
 }
 
 func TestOption_UnmarshalYAML_invalid_definitions(t *testing.T) {
@@ -414,12 +447,13 @@ func TestOption_UnmarshalYAML_invalid_definitions(t *testing.T) {
 func TestGetOptionsWithOrder(t *testing.T) {
 	name := "foo"
 	env := "fooenv"
-	ms := yaml.MapSlice{
-		{Key: name, Value: &Option{Environment: env}},
-		{Key: "bar", Value: &Option{Environment: "barenv"}},
+	ms := yaml.MapSlice{{Key: name}, {Key: "bar"}}
+	values := map[string]*Option{
+		name:  {Environment: env},
+		"bar": {Environment: "barenv"},
 	}
 
-	options, err := getOptionsWithOrder(ms)
+	options, err := getOptionsWithOrder(ms, values)
 	if err != nil {
 		t.Fatalf("GetOptionsWithOrder(ms) => unexpected error: %v", err)
 	}
@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+)
+
+// Orders in which the children of a parallel block can be launched.
+const (
+	ParallelOrderDeclared = "declared"
+	ParallelOrderRandom   = "random"
+)
+
+// parseParallelOrder parses the order to launch parallel children in, as
+// well as the seed used to shuffle them. A seed is generated if one is needed
+// but not passed.
+func parseParallelOrder(order, seed string) (string, int64, error) {
+	switch order {
+	case "", ParallelOrderDeclared:
+		if seed != "" {
+			return "", 0, fmt.Errorf("--seed can only be used with --parallel-order %s", ParallelOrderRandom)
+		}
+		return order, 0, nil
+	case ParallelOrderRandom:
+	default:
+		return "", 0, fmt.Errorf(
+			"unknown parallel order %q, must be one of: %s, %s",
+			order, ParallelOrderDeclared, ParallelOrderRandom,
+		)
+	}
+
+	if seed == "" {
+		return ParallelOrderRandom, now().UnixNano(), nil
+	}
+
+	n, err := strconv.ParseInt(seed, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid seed %q: must be an integer", seed)
+	}
+
+	return ParallelOrderRandom, n, nil
+}
+
+// launchOrder returns the order to launch the children of a parallel block
+// in, as indexes into the list of children. Children are launched in the
+// order they are declared unless the run context shuffles them, in which case
+// the same seed always gives the same order.
+func (r *RunContext) launchOrder(n int) []int {
+	if r.ParallelOrder == ParallelOrderRandom {
+		return rand.New(rand.NewSource(r.Seed)).Perm(n) // nolint: gosec
+	}
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+
+	return order
+}
+
+// parseMaxParallel parses the maximum number of children of a parallel block
+// to run at once. Zero means there is no limit.
+func parseMaxParallel(text string) (int, error) {
+	if text == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(text)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid --max-parallel %q: must be a positive integer", text)
+	}
+
+	return n, nil
+}
+
+// runParallel calls f concurrently with the index of each of n children of a
+// parallel block, in launch order, and waits for them all to finish. No more
+// than MaxParallel children run at once, if it is set.
+func (r *RunContext) runParallel(n int, f func(i int)) {
+	limit := n
+	if r.MaxParallel > 0 && r.MaxParallel < n {
+		limit = r.MaxParallel
+	}
+	slots := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for _, i := range r.launchOrder(n) {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+func TestParseParallelOrder(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Unix(0, 99) }
+
+	tests := []struct {
+		name      string
+		order     string
+		seed      string
+		wantOrder string
+		wantSeed  int64
+	}{
+		{"default", "", "", "", 0},
+		{"declared", "declared", "", ParallelOrderDeclared, 0},
+		{"random with seed", "random", "42", ParallelOrderRandom, 42},
+		{"random without seed", "random", "", ParallelOrderRandom, 99},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, seed, err := parseParallelOrder(tt.order, tt.seed)
+			assert.NilError(t, err)
+			assert.Check(t, cmp.Equal(order, tt.wantOrder))
+			assert.Check(t, cmp.Equal(seed, tt.wantSeed))
+		})
+	}
+}
+
+func TestParseParallelOrder_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   string
+		seed    string
+		wantErr string
+	}{
+		{
+			"unknown order",
+			"reverse", "",
+			`unknown parallel order "reverse", must be one of: declared, random`,
+		},
+		{
+			"seed without random",
+			"", "42",
+			"--seed can only be used with --parallel-order random",
+		},
+		{
+			"invalid seed",
+			"random", "abc",
+			`invalid seed "abc": must be an integer`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseParallelOrder(tt.order, tt.seed)
+			assert.Error(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestRunContext_launchOrder(t *testing.T) {
+	declared := RunContext{}
+	assert.Check(t, cmp.DeepEqual(declared.launchOrder(4), []int{0, 1, 2, 3}))
+
+	random := RunContext{ParallelOrder: ParallelOrderRandom, Seed: 7}
+	first := random.launchOrder(8)
+	assert.Check(t, cmp.DeepEqual(random.launchOrder(8), first))
+	assert.Check(t, cmp.Len(first, 8))
+
+	seen := make(map[int]bool)
+	for _, i := range first {
+		seen[i] = true
+	}
+	assert.Check(t, cmp.Len(seen, 8))
+}
+
+func TestParseMaxParallel(t *testing.T) {
+	n, err := parseMaxParallel("")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(n, 0))
+
+	n, err = parseMaxParallel("3")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(n, 3))
+
+	for _, text := range []string{"0", "-1", "abc"} {
+		_, err := parseMaxParallel(text)
+		assert.Check(t, cmp.ErrorContains(err, "must be a positive integer"), text)
+	}
+}
+
+func TestRunContext_runParallel(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxParallel int
+		want        int
+	}{
+		{"unlimited", 0, 6},
+		{"limited", 2, 2},
+		{"limit above count", 10, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			running, most := 0, 0
+			ran := make([]bool, 6)
+
+			ctx := RunContext{MaxParallel: tt.maxParallel}
+			ctx.runParallel(len(ran), func(i int) {
+				mu.Lock()
+				running++
+				if running > most {
+					most = running
+				}
+				mu.Unlock()
+
+				time.Sleep(50 * time.Millisecond)
+
+				mu.Lock()
+				running--
+				ran[i] = true
+				mu.Unlock()
+			})
+
+			assert.Check(t, cmp.Equal(most, tt.want))
+			assert.Check(t, cmp.DeepEqual(ran, []bool{true, true, true, true, true, true}))
+		})
+	}
+}
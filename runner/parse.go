@@ -1,38 +1,205 @@
 package runner
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 
+	"github.com/pkg/errors"
 	"github.com/rliebz/tusk/marshal"
+	"github.com/rliebz/tusk/ui"
 	yaml "gopkg.in/yaml.v2"
 )
 
 // Parse loads the contents of a config file into a struct.
+//
+// Files with multiple yaml documents are merged in order, with later
+// documents taking priority.
 func Parse(text []byte) (*Config, error) {
+	cfg, _, err := parse(text)
+	return cfg, err
+}
+
+// parse loads the contents of a config file, returning any warnings from
+// merging multiple documents.
+func parse(text []byte) (*Config, []string, error) {
+	docs, err := splitDocuments(text)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var root yaml.MapSlice
+	var warnings []string
+	switch {
+	case len(docs) == 1:
+		root = docs[0]
+	case len(docs) > 1:
+		merged, mergeWarnings, err := mergeDocuments(docs)
+		if err != nil {
+			return nil, nil, err
+		}
+		root = merged
+		warnings = mergeWarnings
+
+		if text, err = yaml.Marshal(merged); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	cfg := new(Config)
 
-	if err := yaml.UnmarshalStrict(text, cfg); err != nil {
-		return nil, err
+	if isStrict(root) {
+		if err := yaml.UnmarshalStrict(text, cfg); err != nil {
+			return nil, nil, err
+		}
+
+		return cfg, warnings, nil
 	}
 
-	return cfg, nil
+	ignored, err := unmarshalLenient(text, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg.ignoredFields = ignored
+
+	return cfg, warnings, nil
+}
+
+// isStrict returns whether a config should fail on fields that are not
+// defined, which is the case unless it sets strict to false.
+func isStrict(root yaml.MapSlice) bool {
+	for _, item := range root {
+		if item.Key == "strict" {
+			strict, ok := item.Value.(bool)
+			return !ok || strict
+		}
+	}
+
+	return true
+}
+
+// splitDocuments decodes each yaml document in a file.
+func splitDocuments(text []byte) ([]yaml.MapSlice, error) {
+	var docs []yaml.MapSlice
+
+	decoder := yaml.NewDecoder(bytes.NewReader(text))
+	for {
+		var doc yaml.MapSlice
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			return docs, nil
+		}
+		if err != nil {
+			// Errors in the first document are reported by strict unmarshaling
+			if len(docs) == 0 {
+				return nil, nil
+			}
+
+			return nil, errors.Wrapf(err, "decoding document %d", len(docs)+1)
+		}
+
+		docs = append(docs, doc)
+	}
 }
 
 // ParseComplete parses the file completely with interpolation.
 func ParseComplete(
-	cfgText []byte,
+	meta *Metadata,
 	taskName string,
 	args []string,
 	flags map[string]string,
 ) (*Config, error) {
-	cfg, err := Parse(cfgText)
+	cfg, warnings, err := parse(meta.CfgText)
 	if err != nil {
 		return nil, err
 	}
 
-	t, isTaskSet := cfg.Tasks[taskName]
-	if !isTaskSet {
-		return cfg, nil
+	for _, warning := range warnings {
+		if meta.FailOnDuplicateTask {
+			return nil, errors.New(warning)
+		}
+
+		ui.Warn(warning)
+	}
+
+	for _, field := range cfg.ignoredFields {
+		if meta.FailOnUnknownField {
+			return nil, fmt.Errorf("unknown field %s", field)
+		}
+
+		ui.Warn(fmt.Sprintf("ignoring unknown field %s", field))
+	}
+
+	if err := cfg.checkVersion(meta.Version); err != nil {
+		if !meta.IgnoreVersion {
+			return nil, err
+		}
+
+		ui.Warn(err)
+	}
+
+	if meta.CheckOnly {
+		if err := cfg.warnReferences(meta); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := cfg.prepareTask(meta, newTaskOutputs(meta), taskName, args, flags, nil); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// parseTask parses a config and prepares one of its tasks to run, without
+// repeating the warnings and checks of ParseComplete. The task is nil if it
+// does not exist.
+func parseTask(
+	meta *Metadata,
+	outputs *taskOutputs,
+	taskName string,
+	args []string,
+	flags map[string]string,
+	vars map[string]string,
+) (*Config, *Task, error) {
+	cfg, _, err := parse(meta.CfgText)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t, err := cfg.prepareTask(meta, outputs, taskName, args, flags, vars)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, t, nil
+}
+
+// prepareTask sets up a parsed config according to the metadata and passes
+// the args, flags, and any additional variables to the task with a name. The
+// task is nil if it does not exist.
+func (c *Config) prepareTask(
+	meta *Metadata,
+	outputs *taskOutputs,
+	taskName string,
+	args []string,
+	flags map[string]string,
+	vars map[string]string,
+) (*Task, error) {
+	if meta.AllowNetwork {
+		c.allowNetwork()
+	}
+	c.setTaskOutputs(outputs)
+
+	if meta.LayeredDefaults {
+		if err := c.layerDefaults(meta.Directory, taskName); err != nil {
+			return nil, err
+		}
+	}
+
+	t, ok := c.Tasks[taskName]
+	if !ok {
+		return nil, nil
 	}
 
 	passed, err := combineArgsAndFlags(t, args, flags)
@@ -40,27 +207,51 @@ func ParseComplete(
 		return nil, err
 	}
 
-	if err := passTaskValues(t, cfg, passed); err != nil {
+	if err := passTaskValues(t, c, passed, vars, make(optionCache)); err != nil {
 		return nil, err
 	}
 
-	return cfg, nil
+	return t, nil
+}
+
+// Variables available to interpolate in a task run after another task fails.
+const (
+	failedTaskVar  = "failed-task"
+	failedErrorVar = "failed-error"
+)
+
+// ParseFailureHook parses the task to run after another task fails. The name
+// of the failed task and its error can be interpolated in the hook task as
+// ${failed-task} and ${failed-error}.
+func ParseFailureHook(meta *Metadata, name, failed string, failure error) (*Task, error) {
+	vars := map[string]string{
+		failedTaskVar:  failed,
+		failedErrorVar: failure.Error(),
+	}
+
+	_, t, err := parseTask(meta, newTaskOutputs(meta), name, nil, nil, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if t == nil {
+		return nil, fmt.Errorf("on-failure task %q does not exist", name)
+	}
+
+	return t, nil
 }
 
 func combineArgsAndFlags(
 	t *Task, args []string, flags map[string]string,
 ) (map[string]string, error) {
-	if len(t.Args) != len(args) {
+	passed, ok := t.Args.values(args)
+	if !ok {
 		return nil, fmt.Errorf(
-			"task %q requires exactly %d args, got %d",
-			t.Name, len(t.Args), len(args),
+			"task %q requires %s args, got %d",
+			t.Name, t.Args.DescribeCount(), len(args),
 		)
 	}
 
-	passed := make(map[string]string, len(args)+len(flags))
-	for i, arg := range t.Args {
-		passed[arg.Name] = args[i]
-	}
 	for name, value := range flags {
 		passed[name] = value
 	}
@@ -68,30 +259,53 @@ func combineArgsAndFlags(
 	return passed, nil
 }
 
-func passTaskValues(t *Task, cfg *Config, passed map[string]string) error {
-	vars, err := interpolateGlobalOptions(t, cfg, passed)
+func passTaskValues(
+	t *Task, cfg *Config, passed, vars map[string]string, cache optionCache,
+) error {
+	// Options must be found before interpolation removes their references
+	options, err := FindAllOptions(t, cfg)
 	if err != nil {
 		return err
 	}
 
-	if err := interpolateTask(t, passed, vars); err != nil {
+	vars, err = interpolateGlobalOptions(t, cfg, passed, vars, cache)
+	if err != nil {
 		return err
 	}
 
-	return addSubTasks(t, cfg)
+	if err := interpolateTask(t, passed, vars, cache); err != nil {
+		return err
+	}
+
+	specified := specifiedOptions(options)
+	if err := checkConflicts(options, specified); err != nil {
+		return err
+	}
+	if err := checkRequireOneOf(options, t.RequireOneOf, specified); err != nil {
+		return err
+	}
+	t.setSpecifiedOptions(specified)
+
+	t.maskSecrets(options)
+	t.setExports(options)
+
+	return addSubTasks(t, cfg, cache)
 }
 
 func interpolateGlobalOptions(
-	t *Task, cfg *Config, passed map[string]string,
+	t *Task, cfg *Config, passed, initial map[string]string, cache optionCache,
 ) (map[string]string, error) {
 	globalOptions, err := getRequiredGlobalOptions(t, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	vars := make(map[string]string, len(globalOptions))
+	vars := make(map[string]string, len(initial)+len(globalOptions))
+	for k, v := range initial {
+		vars[k] = v
+	}
 	for _, o := range globalOptions {
-		if err := interpolateOption(o, passed, vars); err != nil {
+		if err := interpolateOption(o, passed, vars, cache); err != nil {
 			return nil, err
 		}
 	}
@@ -141,7 +355,9 @@ func interpolateArg(a *Arg, passed, vars map[string]string) error {
 	return nil
 }
 
-func interpolateOption(o *Option, passed, vars map[string]string) error {
+func interpolateOption(
+	o *Option, passed, vars map[string]string, cache optionCache,
+) error {
 	if err := marshal.Interpolate(o, vars); err != nil {
 		return err
 	}
@@ -150,17 +366,29 @@ func interpolateOption(o *Option, passed, vars map[string]string) error {
 		o.Passed = valuePassed
 	}
 
+	key, err := cache.key(o, vars)
+	if err != nil {
+		return err
+	}
+
+	if value, ok := cache[key]; ok {
+		o.cache(value)
+	}
+
 	value, err := o.Evaluate(vars)
 	if err != nil {
 		return err
 	}
 
+	cache[key] = value
 	vars[o.Name] = value
 
 	return nil
 }
 
-func interpolateTask(t *Task, passed, vars map[string]string) error {
+func interpolateTask(
+	t *Task, passed, vars map[string]string, cache optionCache,
+) error {
 	taskVars := make(map[string]string, len(vars)+len(t.Args)+len(t.Options))
 	for k, v := range vars {
 		taskVars[k] = v
@@ -173,7 +401,7 @@ func interpolateTask(t *Task, passed, vars map[string]string) error {
 	}
 
 	for _, o := range t.Options {
-		if err := interpolateOption(o, passed, taskVars); err != nil {
+		if err := interpolateOption(o, passed, taskVars, cache); err != nil {
 			return err
 		}
 	}
@@ -186,15 +414,43 @@ func interpolateTask(t *Task, passed, vars map[string]string) error {
 		return err
 	}
 
+	if err := marshal.Interpolate(&t.BeforeEach, taskVars); err != nil {
+		return err
+	}
+
+	if err := marshal.Interpolate(&t.AfterEach, taskVars); err != nil {
+		return err
+	}
+
+	if err := marshal.Interpolate(&t.Produces, taskVars); err != nil {
+		return err
+	}
+
+	if err := marshal.Interpolate(&t.Skip, taskVars); err != nil {
+		return err
+	}
+
+	if t.Confirm != nil {
+		if err := marshal.Interpolate(t.Confirm, taskVars); err != nil {
+			return err
+		}
+	}
+
+	if t.Container != nil {
+		if err := marshal.Interpolate(t.Container, taskVars); err != nil {
+			return err
+		}
+	}
+
 	t.Vars = taskVars
 
 	return nil
 }
 
-func addSubTasks(t *Task, cfg *Config) error {
+func addSubTasks(t *Task, cfg *Config, cache optionCache) error {
 	for _, run := range t.AllRunItems() {
 		for _, desc := range run.SubTaskList {
-			sub, err := newTaskFromSub(desc, cfg)
+			sub, err := newTaskFromSub(desc, cfg, t.Vars, cache)
 			if err != nil {
 				return err
 			}
@@ -206,7 +462,9 @@ func addSubTasks(t *Task, cfg *Config) error {
 	return nil
 }
 
-func newTaskFromSub(desc *SubTask, cfg *Config) (*Task, error) {
+func newTaskFromSub(
+	desc *SubTask, cfg *Config, vars map[string]string, cache optionCache,
+) (*Task, error) {
 	st, ok := cfg.Tasks[desc.Name]
 	if !ok {
 		return nil, fmt.Errorf("sub-task %q does not exist", desc.Name)
@@ -219,6 +477,14 @@ func newTaskFromSub(desc *SubTask, cfg *Config) (*Task, error) {
 		return nil, err
 	}
 
+	inherited, err := desc.passedOptions(subTask, vars)
+	if err != nil {
+		return nil, err
+	}
+	for optName, opt := range inherited {
+		values[optName] = opt
+	}
+
 	for optName, opt := range desc.Options {
 		if _, isValidOption := subTask.Options.Lookup(optName); !isValidOption {
 			return nil, fmt.Errorf(
@@ -229,7 +495,7 @@ func newTaskFromSub(desc *SubTask, cfg *Config) (*Task, error) {
 		values[optName] = opt
 	}
 
-	if err := passTaskValues(subTask, cfg, values); err != nil {
+	if err := passTaskValues(subTask, cfg, values, nil, cache); err != nil {
 		return nil, err
 	}
 
@@ -258,17 +524,13 @@ func copyTask(t *Task) *Task {
 }
 
 func getArgValues(subTask *Task, argsPassed []string) (map[string]string, error) {
-	if len(argsPassed) != len(subTask.Args) {
+	values, ok := subTask.Args.values(argsPassed)
+	if !ok {
 		return nil, fmt.Errorf(
-			"subtask %q requires %d args but got %d",
-			subTask.Name, len(subTask.Args), len(argsPassed),
+			"subtask %q requires %s args but got %d",
+			subTask.Name, subTask.Args.DescribeCount(), len(argsPassed),
 		)
 	}
 
-	values := make(map[string]string)
-	for i, arg := range subTask.Args {
-		values[arg.Name] = argsPassed[i]
-	}
-
 	return values, nil
 }
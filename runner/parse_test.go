@@ -2,10 +2,17 @@ package runner
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/rliebz/tusk/marshal"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
 )
 
 var interpolatetests = []struct {
@@ -801,7 +808,7 @@ given input:
 			tt.testCase, tt.taskName, tt.flags, tt.input,
 		)
 
-		cfg, err := ParseComplete([]byte(tt.input), tt.taskName, tt.args, tt.flags)
+		cfg, err := ParseComplete(&Metadata{CfgText: []byte(tt.input)}, tt.taskName, tt.args, tt.flags)
 		if err != nil {
 			t.Errorf(context+"unexpected error parsing text: %s", err)
 			continue
@@ -984,6 +991,67 @@ tasks:
 	},
 }
 
+func TestParseComplete_arg_defaults(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  test:
+    args:
+      env:
+        values: [dev, prod]
+      target:
+        default: ./...
+    run: echo ${env} ${target}
+  all:
+    run:
+      task:
+        name: test
+        args: prod
+`)
+
+	tests := []struct {
+		name    string
+		task    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "default", task: "test", args: []string{"dev"}, want: "echo dev ./..."},
+		{name: "override", task: "test", args: []string{"dev", "./pkg/..."}, want: "echo dev ./pkg/..."},
+		{name: "sub-task default", task: "all", want: "echo prod ./..."},
+		{
+			name:    "too few",
+			task:    "test",
+			args:    []string{},
+			wantErr: `task "test" requires between 1 and 2 args, got 0`,
+		},
+		{
+			name:    "too many",
+			task:    "test",
+			args:    []string{"dev", "a", "b"},
+			wantErr: `task "test" requires between 1 and 2 args, got 3`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := &Metadata{CfgText: cfgText}
+			cfg, err := ParseComplete(meta, tt.task, tt.args, map[string]string{})
+			if tt.wantErr != "" {
+				assert.Error(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+
+			task := cfg.Tasks[tt.task]
+			if tt.task == "all" {
+				task = &task.RunList[0].Tasks[0]
+			}
+
+			assert.Equal(t, task.RunList[0].Command[0].Exec, tt.want)
+		})
+	}
+}
+
 func TestParseComplete_invalid(t *testing.T) {
 	for _, tt := range invalidinterpolatetests {
 		context := fmt.Sprintf(`
@@ -997,7 +1065,7 @@ given input:
 			tt.testCase, tt.taskName, tt.flags, tt.input,
 		)
 
-		_, err := ParseComplete([]byte(tt.input), tt.taskName, tt.args, tt.flags)
+		_, err := ParseComplete(&Metadata{CfgText: []byte(tt.input)}, tt.taskName, tt.args, tt.flags)
 		if err == nil {
 			t.Errorf(context+"expected error for test case: %s", tt.testCase)
 			continue
@@ -1017,7 +1085,7 @@ tasks:
     run: echo ${bar}
 `)
 
-	cfg, err := ParseComplete(cfgText, "", []string{}, map[string]string{})
+	cfg, err := ParseComplete(&Metadata{CfgText: cfgText}, "", []string{}, map[string]string{})
 	if err != nil {
 		t.Fatalf("unexpected error parsing text: %s", err)
 	}
@@ -1038,7 +1106,726 @@ tasks:
 	if expectedCommand != actualCommand.Exec {
 		t.Errorf(
 			`expected raw command for mytask: "%s", actual: "%s"`,
-			expectedCommand, actualCommand,
+			expectedCommand, actualCommand.Exec,
 		)
 	}
 }
+
+func TestParseComplete_options_evaluated_once(t *testing.T) {
+	dir := fs.NewDir(t, "evaluated-once")
+	defer dir.Remove()
+
+	counter := filepath.Join(dir.Path(), "counter")
+
+	cfgText := []byte(fmt.Sprintf(`
+options:
+  shared:
+    default:
+      command: echo shared >> %[1]s; echo shared
+tasks:
+  parent:
+    run:
+      - task: child
+      - task: child
+      - task: other
+      - task: {name: child, options: {local: overridden}}
+  child:
+    options:
+      local:
+        default:
+          command: echo local >> %[1]s; echo local
+    run: echo ${shared} ${local}
+  other:
+    run: echo ${shared}
+`, counter))
+
+	cfg, err := ParseComplete(&Metadata{CfgText: cfgText}, "parent", []string{}, map[string]string{})
+	assert.NilError(t, err)
+
+	contents, err := ioutil.ReadFile(counter)
+	assert.NilError(t, err)
+	assert.Equal(t, string(contents), "shared\nlocal\n")
+
+	actual := flattenRuns(cfg.Tasks["parent"].AllRunItems())
+	assert.Equal(t, len(actual), 4)
+	assert.Equal(t, actual[0].Command[0].Exec, "echo shared local")
+	assert.Equal(t, actual[1].Command[0].Exec, "echo shared local")
+	assert.Equal(t, actual[2].Command[0].Exec, "echo shared")
+	assert.Equal(t, actual[3].Command[0].Exec, "echo shared overridden")
+}
+
+func TestParseComplete_url_values(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "fetched\n")
+	}))
+	defer server.Close()
+
+	cfgText := []byte(fmt.Sprintf(`
+tasks:
+  mytask:
+    options:
+      foo:
+        default:
+          url: %s
+    run: echo ${foo}
+`, server.URL))
+
+	_, err := ParseComplete(&Metadata{CfgText: cfgText}, "mytask", []string{}, map[string]string{})
+	assert.ErrorContains(t, err, "network access is not allowed")
+
+	meta := &Metadata{CfgText: cfgText, AllowNetwork: true}
+	cfg, err := ParseComplete(meta, "mytask", []string{}, map[string]string{})
+	assert.NilError(t, err)
+	assert.Equal(t, cfg.Tasks["mytask"].RunList[0].Command[0].Exec, "echo fetched")
+}
+
+func TestParseComplete_secret_values(t *testing.T) {
+	cfgText := []byte(`
+options:
+  token:
+    secret: true
+    default: hunter2
+tasks:
+  mytask:
+    options:
+      user:
+        default: admin
+    run:
+      - login ${user} ${token}
+      - pipeline:
+          - echo ${token}
+          - cat
+`)
+
+	cfg, err := ParseComplete(&Metadata{CfgText: cfgText}, "mytask", []string{}, map[string]string{})
+	assert.NilError(t, err)
+
+	runs := cfg.Tasks["mytask"].RunList
+	assert.Equal(t, runs[0].Command[0].Exec, "login admin hunter2")
+	assert.Equal(t, runs[0].Command[0].Print, "login admin ****")
+	assert.Equal(t, runs[1].Pipeline.print(), "echo **** | cat")
+}
+
+func TestParseComplete_secret_values_short(t *testing.T) {
+	cfgText := []byte(`
+options:
+  pin:
+    secret: true
+    default: "1"
+tasks:
+  mytask:
+    run: login --retries 1 --pin ${pin}
+`)
+
+	cfg, err := ParseComplete(&Metadata{CfgText: cfgText}, "mytask", []string{}, map[string]string{})
+	assert.NilError(t, err)
+
+	command := cfg.Tasks["mytask"].RunList[0].Command[0]
+	assert.Equal(t, command.Print, "login --retries 1 --pin 1")
+	assert.Equal(t, len(cfg.Tasks["mytask"].secrets), 0)
+}
+
+func TestParseComplete_pass_options(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  parent:
+    options:
+      env:
+        default: prod
+      region:
+        default: us-east-1
+      other:
+        default: unused
+    run:
+      - task:
+          name: child
+          pass-options: all
+      - task:
+          name: child
+          pass-options: [region]
+      - task:
+          name: child
+          options: {env: test}
+          pass-options: all
+  child:
+    options:
+      env:
+        default: dev
+      region:
+        default: local
+    run: echo ${env} ${region}
+`)
+
+	cfg, err := ParseComplete(&Metadata{CfgText: cfgText}, "parent", []string{}, map[string]string{})
+	assert.NilError(t, err)
+
+	actual := flattenRuns(cfg.Tasks["parent"].AllRunItems())
+	assert.Equal(t, len(actual), 3)
+	assert.Equal(t, actual[0].Command[0].Exec, "echo prod us-east-1")
+	assert.Equal(t, actual[1].Command[0].Exec, "echo dev us-east-1")
+	assert.Equal(t, actual[2].Command[0].Exec, "echo test us-east-1")
+}
+
+func TestParseComplete_pass_options_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		pass    string
+		wantErr string
+	}{
+		{"undeclared", "[other]", `option "other" cannot be passed to task "child"`},
+		{"undefined", "[region]", `option "region" passed to task "child" is not defined`},
+		{"all with others", "[all, env]", `cannot list "all" with other options`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfgText := []byte(fmt.Sprintf(`
+tasks:
+  parent:
+    options:
+      env: {}
+      other: {}
+    run:
+      task:
+        name: child
+        pass-options: %s
+  child:
+    options:
+      env: {}
+      region: {}
+    run: echo ${env} ${region}
+`, tt.pass))
+
+			_, err := ParseComplete(&Metadata{CfgText: cfgText}, "parent", []string{}, map[string]string{})
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParseComplete_conflicts_with(t *testing.T) {
+	cfgText := []byte(`
+options:
+  verbose:
+    type: bool
+tasks:
+  mytask:
+    options:
+      quiet:
+        type: bool
+        conflicts-with: [verbose, debug]
+      debug:
+        type: bool
+    run: echo ${verbose} ${quiet} ${debug}
+`)
+
+	tests := []struct {
+		name    string
+		flags   map[string]string
+		wantErr string
+	}{
+		{"neither", map[string]string{}, ""},
+		{"only declaring option", map[string]string{"quiet": "true"}, ""},
+		{"only conflicting option", map[string]string{"verbose": "true"}, ""},
+		{"unrelated options", map[string]string{"verbose": "true", "debug": "true"}, ""},
+		{
+			"both",
+			map[string]string{"quiet": "true", "verbose": "true"},
+			`options "quiet" and "verbose" cannot be specified together`,
+		},
+		{
+			"both with local option",
+			map[string]string{"debug": "true", "quiet": "true"},
+			`options "quiet" and "debug" cannot be specified together`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := &Metadata{CfgText: cfgText}
+			_, err := ParseComplete(meta, "mytask", []string{}, tt.flags)
+			if tt.wantErr == "" {
+				assert.NilError(t, err)
+				return
+			}
+
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParseComplete_require_one_of(t *testing.T) {
+	cfgText := []byte(`
+options:
+  url:
+    usage: The url to read from
+tasks:
+  mytask:
+    require-one-of: [file, url, stdin]
+    options:
+      file:
+        usage: The file to read from
+      stdin:
+        type: bool
+    run: echo ${file} ${stdin}
+`)
+
+	tests := []struct {
+		name    string
+		flags   map[string]string
+		wantErr string
+	}{
+		{
+			"none",
+			map[string]string{},
+			"one of the options file, url, stdin must be specified",
+		},
+		{"local option", map[string]string{"file": "in.txt"}, ""},
+		{"global option", map[string]string{"url": "https://example.com"}, ""},
+		{
+			"multiple",
+			map[string]string{"file": "in.txt", "stdin": "true"},
+			"only one of the options file, url, stdin can be specified, got file, stdin",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := &Metadata{CfgText: cfgText}
+			_, err := ParseComplete(meta, "mytask", []string{}, tt.flags)
+			if tt.wantErr == "" {
+				assert.NilError(t, err)
+				return
+			}
+
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParseComplete_require_one_of_unknown(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  mytask:
+    require-one-of: [file, url]
+    options:
+      file:
+        usage: The file to read from
+    run: echo ${file}
+`)
+
+	meta := &Metadata{CfgText: cfgText}
+	_, err := ParseComplete(meta, "mytask", []string{}, map[string]string{"file": "in.txt"})
+	assert.ErrorContains(t, err, `require-one-of references unknown option "url"`)
+}
+
+func TestParse_conflicts_with_unknown(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name: "task option",
+			input: `
+options:
+  verbose: {type: bool}
+tasks:
+  mytask:
+    options:
+      quiet:
+        type: bool
+        conflicts-with: [verbose, verbsoe]
+    run: echo ${quiet}
+`,
+			wantErr: `task "mytask": option "quiet" conflicts with unknown option "verbsoe"`,
+		},
+		{
+			name: "option of another task",
+			input: `
+tasks:
+  one:
+    options:
+      debug: {type: bool}
+    run: echo ${debug}
+  two:
+    options:
+      quiet:
+        type: bool
+        conflicts-with: debug
+    run: echo ${quiet}
+`,
+			wantErr: `task "two": option "quiet" conflicts with unknown option "debug"`,
+		},
+		{
+			name: "shared option",
+			input: `
+options:
+  verbose:
+    type: bool
+    conflicts-with: silnet
+`,
+			wantErr: `option "verbose" conflicts with unknown option "silnet"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParse_conflicts_with_task_option(t *testing.T) {
+	cfgText := []byte(`
+options:
+  verbose:
+    type: bool
+    conflicts-with: quiet
+tasks:
+  mytask:
+    options:
+      quiet: {type: bool}
+    run: echo ${verbose} ${quiet}
+`)
+
+	_, err := Parse(cfgText)
+	assert.NilError(t, err)
+}
+
+func TestParse_conflicts_with_self(t *testing.T) {
+	cfgText := []byte(`
+options:
+  verbose:
+    conflicts-with: verbose
+`)
+
+	_, err := Parse(cfgText)
+	assert.ErrorContains(t, err, `option "verbose" cannot conflict with itself`)
+}
+
+func TestParseComplete_file_contents(t *testing.T) {
+	dir := fs.NewDir(t, "tusk-file", fs.WithFile("VERSION", "1.2.3\n"))
+	defer dir.Remove()
+
+	cfgText := []byte(fmt.Sprintf(`
+tasks:
+  release:
+    run: git tag v${file(%q)}
+  missing:
+    run: git tag v${file(%q)}
+`, dir.Join("VERSION"), dir.Join("MISSING")))
+
+	meta := &Metadata{CfgText: cfgText}
+	cfg, err := ParseComplete(meta, "release", []string{}, map[string]string{})
+	assert.NilError(t, err)
+	assert.Equal(t, cfg.Tasks["release"].RunList[0].Command[0].Exec, "git tag v1.2.3")
+
+	_, err = ParseComplete(meta, "missing", []string{}, map[string]string{})
+	assert.ErrorContains(t, err, "reading file")
+}
+
+func TestParseComplete_file_contents_yaml(t *testing.T) {
+	dir := fs.NewDir(t, "tusk-file",
+		fs.WithFile("NOTES", "first: line\nsecond line\n"),
+	)
+	defer dir.Remove()
+
+	cfgText := []byte(fmt.Sprintf(`
+tasks:
+  release:
+    run: echo ${file(%q)}
+`, dir.Join("NOTES")))
+
+	cfg, err := ParseComplete(&Metadata{CfgText: cfgText}, "release", []string{}, map[string]string{})
+	assert.NilError(t, err)
+	assert.Equal(t, cfg.Tasks["release"].RunList[0].Command[0].Exec, "echo first: line\nsecond line")
+}
+
+func TestParseComplete_function_global_options(t *testing.T) {
+	cfgText := []byte(`
+options:
+  tags:
+    default: a,b
+tasks:
+  build:
+    run: docker build ${each(tags, "--tag ")} .
+`)
+
+	cfg, err := ParseComplete(&Metadata{CfgText: cfgText}, "build", nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t,
+		cfg.Tasks["build"].RunList[0].Command[0].Exec,
+		"docker build --tag a --tag b .",
+	)
+}
+
+func TestParseComplete_printf_global_options(t *testing.T) {
+	cfgText := []byte(`
+options:
+  build-number:
+    default: "42"
+tasks:
+  release:
+    run: echo ${printf("build-%05d", build-number)}
+`)
+
+	cfg, err := ParseComplete(&Metadata{CfgText: cfgText}, "release", nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, cfg.Tasks["release"].RunList[0].Command[0].Exec, "echo build-00042")
+}
+
+func TestParseComplete_skip(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  deploy:
+    options:
+      branch:
+        default: main
+    skip:
+      command: test "${branch}" = feature
+    run: exit 1
+`)
+
+	tests := []struct {
+		branch  string
+		wantErr string
+	}{
+		{"feature", ""},
+		{"main", "exit status 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			flags := map[string]string{"branch": tt.branch}
+			cfg, err := ParseComplete(&Metadata{CfgText: cfgText}, "deploy", nil, flags)
+			assert.NilError(t, err)
+
+			task := cfg.Tasks["deploy"]
+			assert.DeepEqual(t, task.Skip[0].Command, marshal.StringList{
+				`test "` + tt.branch + `" = feature`,
+			})
+
+			err = task.Execute(RunContext{})
+			if tt.wantErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.Error(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Environment variables are interpolated when the config is parsed, so values
+// changed with set-environment are only visible to the shell.
+func TestParseComplete_env_set_environment(t *testing.T) {
+	assert.NilError(t, os.Setenv("TUSK_TEST_ENV_STAGE", "before"))
+	defer os.Unsetenv("TUSK_TEST_ENV_STAGE") // nolint: errcheck
+
+	dir := fs.NewDir(t, "env-set-environment")
+	defer dir.Remove()
+
+	cfgText := []byte(fmt.Sprintf(`
+tasks:
+  stage:
+    run:
+      - set-environment: {TUSK_TEST_ENV_STAGE: after}
+      - echo "${env.TUSK_TEST_ENV_STAGE} $$TUSK_TEST_ENV_STAGE" > %s
+`, dir.Join("out")))
+
+	meta := &Metadata{CfgText: cfgText}
+	cfg, err := ParseComplete(meta, "stage", nil, map[string]string{})
+	assert.NilError(t, err)
+
+	assert.NilError(t, cfg.Tasks["stage"].Execute(meta.RunContext()))
+
+	out, err := ioutil.ReadFile(dir.Join("out"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), "before after\n")
+}
+
+func TestParseComplete_redact_options(t *testing.T) {
+	cfgText := []byte(`
+options:
+  token:
+    default: hunter2
+tasks:
+  login:
+    redact-options: token
+    run: login ${token}
+  debug:
+    run: echo ${token}
+`)
+
+	meta := &Metadata{CfgText: cfgText}
+
+	cfg, err := ParseComplete(meta, "login", []string{}, map[string]string{})
+	assert.NilError(t, err)
+	command := cfg.Tasks["login"].RunList[0].Command[0]
+	assert.Equal(t, command.Exec, "login hunter2")
+	assert.Equal(t, command.Print, "login ****")
+
+	cfg, err = ParseComplete(meta, "debug", []string{}, map[string]string{})
+	assert.NilError(t, err)
+	command = cfg.Tasks["debug"].RunList[0].Command[0]
+	assert.Equal(t, command.Exec, "echo hunter2")
+	assert.Equal(t, command.Print, "echo hunter2")
+}
+
+func TestParseComplete_export_as(t *testing.T) {
+	cfgText := []byte(`
+options:
+  region:
+    default: us-east
+    export-as: TUSK_TEST_REGION
+tasks:
+  deploy:
+    options:
+      replicas:
+        default: "3"
+        export-as: TUSK_TEST_REPLICAS
+    run:
+      - echo Deploying to ${region}
+      - test "$TUSK_TEST_REGION" = eu-west && test "$TUSK_TEST_REPLICAS" = 3
+      - pipeline:
+          - echo "$TUSK_TEST_REGION"
+          - grep -x eu-west
+  other:
+    run: test -z "$TUSK_TEST_REGION"
+`)
+
+	meta := &Metadata{CfgText: cfgText}
+	flags := map[string]string{"region": "eu-west"}
+
+	cfg, err := ParseComplete(meta, "deploy", []string{}, flags)
+	assert.NilError(t, err)
+	assert.NilError(t, cfg.Tasks["deploy"].Execute(meta.RunContext()))
+
+	cfg, err = ParseComplete(meta, "other", []string{}, flags)
+	assert.NilError(t, err)
+	assert.NilError(t, cfg.Tasks["other"].Execute(meta.RunContext()))
+}
+
+func TestParseComplete_depends_on(t *testing.T) {
+	dir := fs.NewDir(t, "depends-on")
+	defer dir.Remove()
+
+	cfgText := []byte(fmt.Sprintf(`
+options:
+  login:
+    default:
+      command: echo secret > %[1]s && echo done
+tasks:
+  deploy:
+    options:
+      token:
+        depends-on: login
+        default:
+          command: cat %[1]s
+    run: echo ${token}
+`, dir.Join("token")))
+
+	meta := &Metadata{CfgText: cfgText}
+	cfg, err := ParseComplete(meta, "deploy", []string{}, map[string]string{})
+	assert.NilError(t, err)
+	assert.Equal(t, cfg.Tasks["deploy"].RunList[0].Command[0].Exec, "echo secret")
+}
+
+func TestParseComplete_option_set(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  mytask:
+    options:
+      level:
+        default: info
+    run:
+      when:
+        option-set: level
+      command: echo ${level}
+`)
+
+	tests := []struct {
+		name    string
+		flags   map[string]string
+		wantRun bool
+	}{
+		{"default value", map[string]string{}, false},
+		{"explicitly set", map[string]string{"level": "debug"}, true},
+		{"explicitly set to default", map[string]string{"level": "info"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := &Metadata{CfgText: cfgText}
+			cfg, err := ParseComplete(meta, "mytask", []string{}, tt.flags)
+			assert.NilError(t, err)
+
+			task := cfg.Tasks["mytask"]
+			ok, err := task.RunList[0].shouldRun(task.Vars)
+			assert.NilError(t, err)
+			assert.Equal(t, ok, tt.wantRun)
+		})
+	}
+}
+
+func TestParse_option_set_in_default(t *testing.T) {
+	cfgText := []byte(`
+options:
+  level:
+    default:
+      - when: {option-set: verbose}
+        value: debug
+`)
+
+	_, err := Parse(cfgText)
+	assert.ErrorContains(t, err, "option-set cannot be used in option defaults")
+
+	cfgText = []byte(`
+options:
+  level:
+    default:
+      - when: {any-of: [{option-set: verbose}]}
+        value: debug
+`)
+
+	_, err = Parse(cfgText)
+	assert.ErrorContains(t, err, "option-set cannot be used in option defaults")
+}
+
+func TestParseComplete_any_of_defaults(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  deploy:
+    options:
+      env:
+        default: dev
+      region:
+        default: local
+      replicas:
+        default:
+          - when:
+              any-of:
+                - equal: {env: prod}
+                - [{equal: {env: staging}}, {equal: {region: us-east}}]
+            value: "3"
+          - "1"
+    run: echo ${replicas}
+`)
+
+	tests := []struct {
+		flags map[string]string
+		want  string
+	}{
+		{map[string]string{}, "echo 1"},
+		{map[string]string{"env": "prod"}, "echo 3"},
+		{map[string]string{"env": "staging"}, "echo 1"},
+		{map[string]string{"env": "staging", "region": "us-east"}, "echo 3"},
+	}
+
+	for _, tt := range tests {
+		meta := &Metadata{CfgText: cfgText}
+		cfg, err := ParseComplete(meta, "deploy", []string{}, tt.flags)
+		assert.NilError(t, err)
+		assert.Equal(t, cfg.Tasks["deploy"].RunList[0].Command[0].Exec, tt.want, "flags: %v", tt.flags)
+	}
+}
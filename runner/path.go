@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Kinds of paths that path options can require.
+const (
+	pathKindFile = "file"
+	pathKindDir  = "dir"
+)
+
+// PathCheck describes how the value of a path option is validated. Relative
+// paths are resolved from the directory of the config file, where tasks run.
+type PathCheck struct {
+	MustExist bool   `yaml:"must-exist,omitempty"`
+	Kind      string `yaml:"kind,omitempty"`
+	Writable  bool   `yaml:"writable,omitempty"`
+}
+
+// isSet returns whether any path checks are defined.
+func (pc PathCheck) isSet() bool {
+	return pc.MustExist || pc.Kind != "" || pc.Writable
+}
+
+// validate checks that the path checks can be used by an option.
+func (pc PathCheck) validate(isPath bool) error {
+	if !isPath {
+		if pc.isSet() {
+			return errors.New("must-exist, kind, and writable can only be used with type path")
+		}
+
+		return nil
+	}
+
+	switch pc.Kind {
+	case "", pathKindFile, pathKindDir:
+		return nil
+	default:
+		return fmt.Errorf(
+			"invalid path kind %q, must be one of: %s, %s", pc.Kind, pathKindFile, pathKindDir,
+		)
+	}
+}
+
+// check returns an error if a path does not meet the requirements.
+func (pc PathCheck) check(path string) error {
+	info, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		if pc.MustExist {
+			return fmt.Errorf("path %q does not exist", path)
+		}
+	case err != nil:
+		return err
+	default:
+		if err := pc.checkKind(info); err != nil {
+			return errors.Wrapf(err, "path %q", path)
+		}
+	}
+
+	if pc.Writable && !isWritable(path, info) {
+		return fmt.Errorf("path %q is not writable", path)
+	}
+
+	return nil
+}
+
+func (pc PathCheck) checkKind(info os.FileInfo) error {
+	switch {
+	case pc.Kind == pathKindDir && !info.IsDir():
+		return errors.New("expected directory, got file")
+	case pc.Kind == pathKindFile && info.IsDir():
+		return errors.New("expected file, got directory")
+	default:
+		return nil
+	}
+}
+
+// isWritable returns whether a path can be written to. Paths that do not exist
+// are writable if a file can be created in their parent directory.
+func isWritable(path string, info os.FileInfo) bool {
+	if info != nil && !info.IsDir() {
+		f, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return false
+		}
+		return f.Close() == nil
+	}
+
+	dir := path
+	if info == nil {
+		dir = filepath.Dir(path)
+	}
+
+	f, err := ioutil.TempFile(dir, ".tusk-writable-")
+	if err != nil {
+		return false
+	}
+	f.Close()           // nolint: errcheck
+	os.Remove(f.Name()) // nolint: errcheck
+
+	return true
+}
+
+func (o *Option) isPath() bool {
+	return strings.ToLower(o.Type) == "path"
+}
+
+// checkPath returns an error if the value of a path option does not meet its
+// requirements. Empty values are not checked.
+func (o *Option) checkPath(value string) error {
+	if !o.isPath() || value == "" {
+		return nil
+	}
+
+	if err := o.PathCheck.check(value); err != nil {
+		return errors.Wrapf(err, "option %s", o.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestOption_Evaluate_path(t *testing.T) {
+	dir := fs.NewDir(t, "path-option",
+		fs.WithFile("file.txt", "contents"),
+		fs.WithDir("sub"),
+	)
+	defer dir.Remove()
+
+	file := filepath.Join(dir.Path(), "file.txt")
+	sub := filepath.Join(dir.Path(), "sub")
+	missing := filepath.Join(dir.Path(), "missing")
+
+	tests := []struct {
+		name    string
+		check   PathCheck
+		value   string
+		wantErr string
+	}{
+		{name: "existing file", check: PathCheck{MustExist: true, Kind: "file"}, value: file},
+		{name: "existing dir", check: PathCheck{MustExist: true, Kind: "dir"}, value: sub},
+		{name: "missing allowed", check: PathCheck{Kind: "file"}, value: missing},
+		{name: "empty", check: PathCheck{MustExist: true}, value: ""},
+		{
+			name:    "missing",
+			check:   PathCheck{MustExist: true},
+			value:   missing,
+			wantErr: `option out: path "` + missing + `" does not exist`,
+		},
+		{
+			name:    "expected dir",
+			check:   PathCheck{Kind: "dir"},
+			value:   file,
+			wantErr: `option out: path "` + file + `": expected directory, got file`,
+		},
+		{
+			name:    "expected file",
+			check:   PathCheck{Kind: "file"},
+			value:   sub,
+			wantErr: `option out: path "` + sub + `": expected file, got directory`,
+		},
+		{name: "writable file", check: PathCheck{Writable: true}, value: file},
+		{name: "writable dir", check: PathCheck{Writable: true}, value: sub},
+		{name: "writable missing", check: PathCheck{Writable: true}, value: filepath.Join(sub, "new")},
+		{
+			name:    "writable missing parent",
+			check:   PathCheck{Writable: true},
+			value:   filepath.Join(missing, "new"),
+			wantErr: `option out: path "` + filepath.Join(missing, "new") + `" is not writable`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := Option{Name: "out", Type: "path", PathCheck: tt.check, Passed: tt.value}
+
+			value, err := o.Evaluate(nil)
+			if tt.wantErr != "" {
+				assert.Error(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Equal(t, value, tt.value)
+		})
+	}
+}
+
+func TestOption_Evaluate_path_not_writable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+
+	dir := fs.NewDir(t, "path-option",
+		fs.WithFile("file.txt", "contents", fs.WithMode(0o444)),
+		fs.WithDir("sub", fs.WithMode(0o555)),
+	)
+	defer dir.Remove()
+
+	for _, name := range []string{"file.txt", "sub"} {
+		path := filepath.Join(dir.Path(), name)
+		o := Option{Name: "out", Type: "path", PathCheck: PathCheck{Writable: true}, Passed: path}
+
+		_, err := o.Evaluate(nil)
+		assert.Error(t, err, `option out: path "`+path+`" is not writable`)
+	}
+}
+
+func TestOption_UnmarshalYAML_path_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "not a path",
+			input:   "{type: string, must-exist: true}",
+			wantErr: "must-exist, kind, and writable can only be used with type path",
+		},
+		{
+			name:    "unknown kind",
+			input:   "{type: path, kind: socket}",
+			wantErr: `invalid path kind "socket", must be one of: file, dir`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var o Option
+			err := yaml.UnmarshalStrict([]byte(tt.input), &o)
+			assert.Error(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/rliebz/tusk/ui"
+)
+
+// print returns the text to print for a list of commands used as a pipeline.
+func (cl CommandList) print() string {
+	stages := make([]string, 0, len(cl))
+	for _, c := range cl {
+		stages = append(stages, c.Print)
+	}
+
+	return strings.Join(stages, " | ")
+}
+
+// echo returns whether the pipeline is printed before it is run, which is the
+// case unless every stage has echo turned off.
+func (cl CommandList) echo() bool {
+	for _, c := range cl {
+		if c.echo() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// execPipeline executes each command with its stdout connected to the stdin
+// of the next command.
+//
+// Unlike a pipeline run by a shell, the exit status of each stage is checked.
+// If any stage fails, the error of the right-most failed stage is returned.
+func (cl CommandList) execPipeline(ctx RunContext) (err error) {
+	cmds := make([]*exec.Cmd, 0, len(cl))
+	for _, c := range cl {
+		cmd, err := ctx.shellCommand(c)
+		if err != nil {
+			return err
+		}
+		if ui.Verbosity > ui.VerbosityLevelSilent {
+			cmd.Stderr = ui.Stderr
+		}
+		cmds = append(cmds, cmd)
+	}
+	defer ctx.dumpEnvOnFailure(cmds[0], &err)
+
+	stdin, closeStdin, err := cl[0].openStdin()
+	if err != nil {
+		return err
+	}
+	defer closeStdin()
+	cmds[0].Stdin = stdin
+	if stdout := ctx.commandStdout(); stdout != nil {
+		cmds[len(cmds)-1].Stdout = stdout
+	}
+	output := ctx.holdOutput(cmds...)
+	defer output.printOnFailure(cl.print(), &err)
+
+	log, err := ctx.openStepLog()
+	if err != nil {
+		return err
+	}
+	if log != nil {
+		defer log.Close() // nolint: errcheck
+		defer printStepLogOnFailure(log.Name(), &err)
+		for _, cmd := range cmds {
+			cmd.Stderr = teeTo(cmd.Stderr, log)
+		}
+		cmds[len(cmds)-1].Stdout = teeTo(cmds[len(cmds)-1].Stdout, log)
+	}
+
+	stderrs := make([]stderrCapture, 0, len(cmds))
+	for _, cmd := range cmds {
+		stderrs = append(stderrs, ctx.captureStderr(cmd))
+	}
+
+	// Tusk must close its copies of each pipe once the stages that use them
+	// have started, so that EOF is propagated when a stage exits.
+	var pipes []io.Closer
+	closePipes := func() {
+		for _, p := range pipes {
+			p.Close() // nolint: errcheck
+		}
+	}
+
+	for i := 0; i < len(cmds)-1; i++ {
+		r, w, err := os.Pipe()
+		if err != nil {
+			closePipes()
+			return err
+		}
+		pipes = append(pipes, r, w)
+
+		cmds[i].Stdout = w
+		cmds[i+1].Stdin = r
+	}
+
+	started := 0
+	for _, cmd := range cmds {
+		if err = ctx.startCommand(cmd); err != nil {
+			break
+		}
+		started++
+	}
+
+	closePipes()
+
+	errs := make([]error, len(cmds))
+	for i := 0; i < started; i++ {
+		errs[i] = stderrs[i].check(ctx.waitCommand(cmds[i]))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for i := len(errs) - 1; i >= 0; i-- {
+		if errs[i] == nil {
+			continue
+		}
+
+		ui.PrintCommandError(
+			fmt.Errorf("pipeline stage %d (%s): %v", i+1, cl[i].Print, errs[i]),
+		)
+		return errs[i]
+	}
+
+	return nil
+}
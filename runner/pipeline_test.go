@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestRun_UnmarshalYAML_pipeline(t *testing.T) {
+	var r Run
+	err := yaml.UnmarshalStrict([]byte(`pipeline: [echo hello, {exec: cat, print: meow}]`), &r)
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, r, Run{
+		Pipeline: CommandList{
+			{Exec: "echo hello", Print: "echo hello"},
+			{Exec: "cat", Print: "meow"},
+		},
+	})
+	assert.Equal(t, r.Pipeline.print(), "echo hello | meow")
+
+	err = yaml.UnmarshalStrict([]byte(`{pipeline: [echo hello], command: echo}`), &r)
+	assert.ErrorContains(t, err, "only one action can be defined")
+}
+
+func TestCommandList_execPipeline(t *testing.T) {
+	dir := fs.NewDir(t, "pipeline")
+	defer dir.Remove()
+
+	out := filepath.Join(dir.Path(), "out.txt")
+
+	pipeline := CommandList{
+		{Exec: "printf 'one\\ntwo\\nthree\\n'"},
+		{Exec: "grep t"},
+		{Exec: "tr a-z A-Z > " + out},
+	}
+
+	assert.NilError(t, pipeline.execPipeline(RunContext{}))
+
+	contents, err := ioutil.ReadFile(out)
+	assert.NilError(t, err)
+	assert.Equal(t, string(contents), "TWO\nTHREE\n")
+}
+
+func TestCommandList_execPipeline_middle_stage_fails(t *testing.T) {
+	dir := fs.NewDir(t, "pipeline")
+	defer dir.Remove()
+
+	out := filepath.Join(dir.Path(), "out.txt")
+
+	pipeline := CommandList{
+		{Exec: "echo hello"},
+		{Exec: "cat; exit 3"},
+		{Exec: "cat > " + out},
+	}
+
+	err := pipeline.execPipeline(RunContext{})
+	exitErr, ok := err.(*exec.ExitError)
+	assert.Assert(t, ok, "want exit error, got %v", err)
+	assert.Equal(t, exitErr.Sys().(syscall.WaitStatus).ExitStatus(), 3)
+
+	// Later stages still receive the output before the failure
+	contents, err := ioutil.ReadFile(out)
+	assert.NilError(t, err)
+	assert.Equal(t, strings.TrimSpace(string(contents)), "hello")
+}
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"errors"
+
+	"github.com/rliebz/tusk/marshal"
+)
+
+// Privacy determines whether a task is private.
+//
+// Privacy can be defined as either a boolean or a list of when clauses. When
+// clauses are evaluated as soon as the configuration is loaded, including for
+// help and shell completion, so they may only use static checks of the
+// environment. Commands and option values are not allowed.
+type Privacy struct {
+	Value bool
+	When  WhenList
+}
+
+// UnmarshalYAML allows either a boolean or when clauses to define privacy.
+func (p *Privacy) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value bool
+	boolCandidate := marshal.UnmarshalCandidate{
+		Unmarshal: func() error { return unmarshal(&value) },
+		Assign:    func() { *p = Privacy{Value: value} },
+	}
+
+	var when WhenList
+	whenCandidate := marshal.UnmarshalCandidate{
+		Unmarshal: func() error { return unmarshal(&when) },
+		Validate: func() error {
+			if len(when.Dependencies()) != 0 {
+				return errors.New("private conditions cannot reference options")
+			}
+
+			if hasCommands(when) {
+				return errors.New("private conditions cannot run commands")
+			}
+
+			return nil
+		},
+		Assign: func() { *p = Privacy{When: when} },
+	}
+
+	return marshal.UnmarshalOneOf(boolCandidate, whenCandidate)
+}
+
+// evaluate returns whether a task should be private.
+func (p *Privacy) evaluate() (bool, error) {
+	if p.When == nil {
+		return p.Value, nil
+	}
+
+	if err := p.When.Validate(nil); err != nil {
+		if IsFailedCondition(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// hasCommands returns whether any of the conditions run a command.
+func hasCommands(l WhenList) bool {
+	for _, w := range l {
+		if len(w.Command) > 0 {
+			return true
+		}
+
+		for _, group := range w.AnyOf {
+			if hasCommands(group) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
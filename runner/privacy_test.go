@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"os"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestTask_UnmarshalYAML_private(t *testing.T) {
+	envVar := "TUSK_TEST_PRIVATE_ENV"
+
+	tests := []struct {
+		name    string
+		input   string
+		env     *string
+		want    bool
+		wantErr string
+	}{
+		{
+			name:  "unspecified",
+			input: `run: echo hello`,
+			want:  false,
+		},
+		{
+			name:  "bool true",
+			input: `private: true`,
+			want:  true,
+		},
+		{
+			name:  "bool false",
+			input: `private: false`,
+			want:  false,
+		},
+		{
+			name:  "when matches",
+			input: `private: {environment: {TUSK_TEST_PRIVATE_ENV: production}}`,
+			env:   stringPointer("production"),
+			want:  true,
+		},
+		{
+			name:  "when does not match",
+			input: `private: {environment: {TUSK_TEST_PRIVATE_ENV: production}}`,
+			env:   stringPointer("local"),
+			want:  false,
+		},
+		{
+			name:  "when list",
+			input: `private: [{os: fake}]`,
+			want:  false,
+		},
+		{
+			name:    "when references option",
+			input:   `private: {equal: {foo: bar}}`,
+			wantErr: "private conditions cannot reference options",
+		},
+		{
+			name:    "when runs command",
+			input:   `private: {command: "test -f .private"}`,
+			wantErr: "private conditions cannot run commands",
+		},
+		{
+			name:    "when runs command in any-of",
+			input:   `private: {any-of: [{os: fake}, {command: "true"}]}`,
+			wantErr: "private conditions cannot run commands",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != nil {
+				assert.NilError(t, os.Setenv(envVar, *tt.env))
+				defer os.Unsetenv(envVar) // nolint: errcheck
+			}
+
+			var task Task
+			err := yaml.UnmarshalStrict([]byte(tt.input), &task)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Equal(t, task.Private, tt.want)
+		})
+	}
+}
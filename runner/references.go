@@ -0,0 +1,134 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rliebz/tusk/marshal"
+	"github.com/rliebz/tusk/ui"
+)
+
+// builtinVars are the variables tusk provides for interpolation without them
+// being declared as args or options.
+var builtinVars = []string{
+	failedTaskVar,
+	failedErrorVar,
+	stepNameVar,
+	stepIndexVar,
+}
+
+// warnReferences prints a warning for each undeclared variable referenced in
+// the config, or returns an error for the first one if they are treated as
+// failures.
+func (c *Config) warnReferences(meta *Metadata) error {
+	problems, err := c.checkReferences()
+	if err != nil {
+		return err
+	}
+
+	for _, problem := range problems {
+		if meta.FailOnUndeclared {
+			return errors.New(problem)
+		}
+
+		ui.Warn(problem)
+	}
+
+	return nil
+}
+
+// checkReferences returns a problem for each option or task that interpolates
+// a variable that is not a declared arg or option, so that a typo can be
+// caught with --check instead of silently expanding to nothing.
+func (c *Config) checkReferences() ([]string, error) {
+	global := make(map[string]bool, len(builtinVars)+len(c.Options))
+	for _, name := range builtinVars {
+		global[name] = true
+	}
+	for _, o := range c.Options {
+		global[o.Name] = true
+	}
+
+	var problems []string
+	for _, o := range c.Options {
+		undeclared, err := undeclaredReferences(o, global)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(undeclared) > 0 {
+			problems = append(problems, describeUndeclared("option", o.Name, undeclared))
+		}
+	}
+
+	names := make([]string, 0, len(c.Tasks))
+	for name := range c.Tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		t := c.Tasks[name]
+
+		declared := make(map[string]bool, len(global)+len(t.Args)+len(t.Options))
+		for name := range global {
+			declared[name] = true
+		}
+		for _, a := range t.Args {
+			declared[a.Name] = true
+		}
+		for _, o := range t.Options {
+			declared[o.Name] = true
+		}
+
+		undeclared, err := undeclaredReferences(t, declared)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(undeclared) > 0 {
+			problems = append(problems, describeUndeclared("task", name, undeclared))
+		}
+	}
+
+	return problems, nil
+}
+
+// undeclaredReferences returns the names of the variables interpolated in an
+// item that are not declared, in the order they are first referenced.
+// References escaped with $$ are ignored.
+func undeclaredReferences(item interface{}, declared map[string]bool) ([]string, error) {
+	marshaled, err := rawText(item)
+	if err != nil {
+		return nil, err
+	}
+	marshaled = bytes.ReplaceAll(marshaled, []byte("$$"), nil)
+
+	seen := make(map[string]bool)
+	var undeclared []string
+	for _, name := range marshal.FindPotentialVariables(marshaled) {
+		if declared[name] || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		undeclared = append(undeclared, name)
+	}
+
+	return undeclared, nil
+}
+
+func describeUndeclared(kind, name string, undeclared []string) string {
+	refs := make([]string, 0, len(undeclared))
+	for _, ref := range undeclared {
+		refs = append(refs, "${"+ref+"}")
+	}
+
+	return fmt.Sprintf(
+		"%s %q references undeclared variables: %s",
+		kind, name, strings.Join(refs, ", "),
+	)
+}
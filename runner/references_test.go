@@ -0,0 +1,132 @@
+package runner
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+func TestConfig_checkReferences(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name: "declared",
+			input: `
+options:
+  region: {default: us-east-1}
+tasks:
+  deploy:
+    args:
+      target: {}
+    options:
+      env: {default: dev}
+      tags: {default: "a,b"}
+    run:
+      - echo ${target} ${env} ${region} ${each(tags, "--tag ")}
+      - echo $${HOME} ${env.HOME:-}
+    before-each: echo ${step-index} ${step-name}
+  notify:
+    run: echo ${failed-task} ${failed-error}
+  tag:
+    options:
+      build: {default: "1"}
+    run: git tag "v${file(VERSION)}" ${printf("%03d", build)}
+`,
+		},
+		{
+			name: "undeclared in command",
+			input: `
+tasks:
+  build:
+    options:
+      version: {}
+    run: go build -ldflags "-X main.version=${verison}"
+`,
+			want: []string{`task "build" references undeclared variables: ${verison}`},
+		},
+		{
+			name: "option of another task",
+			input: `
+tasks:
+  one:
+    options:
+      foo: {}
+    run: echo ${foo}
+  two:
+    run:
+      - echo ${foo} ${bar}
+      - echo ${foo}
+`,
+			want: []string{`task "two" references undeclared variables: ${foo}, ${bar}`},
+		},
+		{
+			name: "undeclared in global option",
+			input: `
+options:
+  greeting:
+    default: Hello, ${nmae}
+tasks:
+  greet:
+    run: echo ${greeting} ${nmae}
+`,
+			want: []string{
+				`option "greeting" references undeclared variables: ${nmae}`,
+				`task "greet" references undeclared variables: ${nmae}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse([]byte(tt.input))
+			assert.NilError(t, err)
+
+			problems, err := cfg.checkReferences()
+			assert.NilError(t, err)
+			assert.Check(t, cmp.DeepEqual(problems, tt.want))
+		})
+	}
+}
+
+func TestParseComplete_references(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  build:
+    options:
+      version: {}
+    run: echo ${verison}
+`)
+
+	tests := []struct {
+		name    string
+		meta    Metadata
+		wantErr string
+	}{
+		{"not checking", Metadata{}, ""},
+		{"checking", Metadata{CheckOnly: true}, ""},
+		{
+			"failing",
+			Metadata{CheckOnly: true, FailOnUndeclared: true},
+			`task "build" references undeclared variables: ${verison}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := tt.meta
+			meta.CfgText = cfgText
+
+			_, err := ParseComplete(&meta, "build", nil, map[string]string{})
+			if tt.wantErr == "" {
+				assert.NilError(t, err)
+				return
+			}
+
+			assert.Error(t, err, tt.wantErr)
+		})
+	}
+}
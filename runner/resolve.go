@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ResolveOptions evaluates a set of options in dependency order, so that each
+// option is evaluated after the options it references or lists in depends-on,
+// and returns the value of each option by name. Values passed are used as if
+// passed by command line.
+//
+// Options are interpolated in place, as they are when running a task. An
+// error is returned if any options depend on each other in a cycle.
+func ResolveOptions(opts []*Option, passed map[string]string) (map[string]string, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	byName := make(map[string]*Option, len(opts))
+	for _, o := range opts {
+		byName[o.Name] = o
+	}
+
+	vars := make(map[string]string, len(opts))
+	cache := make(optionCache)
+	state := make(map[string]int, len(opts))
+
+	var visit func(o *Option, path []string) error
+	visit = func(o *Option, path []string) error {
+		path = append(path, o.Name)
+
+		switch state[o.Name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf(
+				"options have a circular dependency: %s", strings.Join(path, " -> "),
+			)
+		}
+		state[o.Name] = visiting
+
+		dependencies, err := getDependencies(o)
+		if err != nil {
+			return errors.Wrapf(err, "finding dependencies of option %q", o.Name)
+		}
+		sort.Strings(dependencies)
+
+		for _, name := range dependencies {
+			dependency, ok := byName[name]
+			if !ok {
+				continue
+			}
+
+			if err := visit(dependency, path); err != nil {
+				return err
+			}
+		}
+
+		if err := interpolateOption(o, passed, vars, cache); err != nil {
+			return errors.Wrapf(err, "evaluating option %q", o.Name)
+		}
+
+		state[o.Name] = visited
+		return nil
+	}
+
+	for _, o := range opts {
+		if err := visit(o, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return vars, nil
+}
@@ -0,0 +1,137 @@
+package runner
+
+import (
+	"fmt"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func parseOptions(t *testing.T, text string) []*Option {
+	t.Helper()
+
+	var options Options
+	if err := yaml.UnmarshalStrict([]byte(text), &options); err != nil {
+		t.Fatal(err)
+	}
+
+	return options
+}
+
+func TestResolveOptions_chain(t *testing.T) {
+	// Declared in reverse order of their dependencies
+	options := parseOptions(t, `
+url:
+  default: https://${host}/${path}
+host:
+  default: ${region}.example.com
+region:
+  default: us-east
+path:
+  default: api
+`)
+
+	vars, err := ResolveOptions(options, map[string]string{"region": "eu-west"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, vars, map[string]string{
+		"url":    "https://eu-west.example.com/api",
+		"host":   "eu-west.example.com",
+		"region": "eu-west",
+		"path":   "api",
+	})
+}
+
+func TestResolveOptions_diamond(t *testing.T) {
+	options := parseOptions(t, `
+summary:
+  default: ${left}+${right}
+left:
+  default: left-${base}
+right:
+  default:
+    - when:
+        equal: {base: root}
+      value: right-${base}
+    - other
+base:
+  default: root
+`)
+
+	vars, err := ResolveOptions(options, map[string]string{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, vars, map[string]string{
+		"summary": "left-root+right-root",
+		"left":    "left-root",
+		"right":   "right-root",
+		"base":    "root",
+	})
+}
+
+func TestResolveOptions_depends_on(t *testing.T) {
+	dir := fs.NewDir(t, "depends-on")
+	defer dir.Remove()
+
+	marker := dir.Join("marker")
+
+	// The options do not reference each other, so only depends-on orders them
+	options := parseOptions(t, fmt.Sprintf(`
+check:
+  depends-on: setup
+  default:
+    command: test -f %[1]s && echo found || echo missing
+setup:
+  default:
+    command: touch %[1]s && echo done
+`, marker))
+
+	vars, err := ResolveOptions(options, map[string]string{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, vars, map[string]string{
+		"check": "found",
+		"setup": "done",
+	})
+}
+
+func TestResolveOptions_depends_on_cycle(t *testing.T) {
+	options := parseOptions(t, `
+a:
+  depends-on: [b]
+b:
+  depends-on: [a]
+`)
+
+	_, err := ResolveOptions(options, map[string]string{})
+	assert.Error(t, err, "options have a circular dependency: a -> b -> a")
+}
+
+func TestResolveOptions_cycle(t *testing.T) {
+	options := parseOptions(t, `
+a:
+  default: ${b}
+b:
+  default:
+    - when:
+        equal: {c: x}
+      value: x
+c:
+  default: ${a}
+`)
+
+	_, err := ResolveOptions(options, map[string]string{})
+	assert.Error(t, err, "options have a circular dependency: a -> b -> c -> a")
+}
+
+func TestResolveOptions_option_error(t *testing.T) {
+	options := parseOptions(t, `
+base:
+  required: true
+derived:
+  default: ${base}
+`)
+
+	_, err := ResolveOptions(options, map[string]string{})
+	assert.ErrorContains(t, err, `evaluating option "base"`)
+	assert.ErrorContains(t, err, "no value passed for required option: base")
+}
@@ -2,6 +2,8 @@ package runner
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/rliebz/tusk/marshal"
 	"github.com/rliebz/tusk/ui"
@@ -9,10 +11,16 @@ import (
 
 // Run defines a a single runnable item within a task.
 type Run struct {
+	Name           string             `yaml:",omitempty"`
 	When           WhenList           `yaml:",omitempty"`
 	Command        CommandList        `yaml:",omitempty"`
+	Pipeline       CommandList        `yaml:",omitempty"`
 	SubTaskList    SubTaskList        `yaml:"task,omitempty"`
 	SetEnvironment map[string]*string `yaml:"set-environment,omitempty"`
+	Unset          marshal.StringList `yaml:",omitempty"`
+	FailOnStderr   *bool              `yaml:"fail-on-stderr,omitempty"`
+	UseSnippet     string             `yaml:"use-snippet,omitempty"`
+	Abort          *Abort             `yaml:"abort,omitempty"`
 
 	// Computed members not specified in yaml file
 	Tasks []Task `yaml:"-"`
@@ -34,8 +42,11 @@ func (r *Run) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		Validate: func() error {
 			actionUsedList := []bool{
 				len(runItem.Command) != 0,
+				len(runItem.Pipeline) != 0,
 				len(runItem.SubTaskList) != 0,
-				runItem.SetEnvironment != nil,
+				runItem.SetEnvironment != nil || len(runItem.Unset) != 0,
+				runItem.UseSnippet != "",
+				runItem.Abort != nil,
 			}
 
 			count := 0
@@ -49,6 +60,29 @@ func (r *Run) UnmarshalYAML(unmarshal func(interface{}) error) error {
 				return errors.New("only one action can be defined in `run`")
 			}
 
+			isCommand := len(runItem.Command) != 0 || len(runItem.Pipeline) != 0 ||
+				runItem.UseSnippet != ""
+			if runItem.FailOnStderr != nil && !isCommand {
+				return errors.New("`fail-on-stderr` can only be used with a command or pipeline")
+			}
+
+			for i, c := range runItem.Pipeline {
+				if c.Filter != "" {
+					return errors.New("commands in a pipeline cannot use `filter`")
+				}
+				if i > 0 && c.Stdin != nil {
+					return errors.New("only the first command in a pipeline can use `stdin`")
+				}
+			}
+
+			for _, key := range runItem.Unset {
+				if value, ok := runItem.SetEnvironment[key]; ok && value != nil {
+					return fmt.Errorf(
+						"environment variable %q cannot be both set and unset", key,
+					)
+				}
+			}
+
 			return nil
 		},
 	}
@@ -66,6 +100,10 @@ func (r *Run) shouldRun(vars map[string]string) (bool, error) {
 			ui.PrintSkipped(command.Print, err.Error())
 		}
 
+		if len(r.Pipeline) != 0 {
+			ui.PrintSkipped(r.Pipeline.print(), err.Error())
+		}
+
 		for _, subTask := range r.SubTaskList {
 			ui.PrintSkipped("task: "+subTask.Name, err.Error())
 		}
@@ -76,6 +114,51 @@ func (r *Run) shouldRun(vars map[string]string) (bool, error) {
 	return true, nil
 }
 
+// label returns the name of the run item for summaries, which defaults to a
+// description of its action.
+func (r *Run) label() string {
+	if r.Name != "" {
+		return r.Name
+	}
+
+	var labels []string
+	for _, command := range r.Command {
+		labels = append(labels, command.Print)
+	}
+	if len(r.Pipeline) != 0 {
+		labels = append(labels, r.Pipeline.print())
+	}
+	for _, subTask := range r.SubTaskList {
+		labels = append(labels, "task: "+subTask.Name)
+	}
+	if r.Abort != nil {
+		labels = append(labels, "abort: "+r.Abort.Message)
+	}
+	if len(labels) == 0 {
+		return "set-environment"
+	}
+
+	return strings.Join(labels, "; ")
+}
+
+// environment returns the environment variables to modify, where variables to
+// be unset have a nil value.
+func (r *Run) environment() map[string]*string {
+	if len(r.Unset) == 0 {
+		return r.SetEnvironment
+	}
+
+	environment := make(map[string]*string, len(r.SetEnvironment)+len(r.Unset))
+	for key, value := range r.SetEnvironment {
+		environment[key] = value
+	}
+	for _, key := range r.Unset {
+		environment[key] = nil
+	}
+
+	return environment
+}
+
 // RunList is a list of run items with custom yaml unmarshaling.
 type RunList []*Run
 
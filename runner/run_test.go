@@ -4,7 +4,9 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/rliebz/tusk/marshal"
 	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
 )
 
 func TestRun_UnmarshalYAML(t *testing.T) {
@@ -37,6 +39,21 @@ func TestRun_UnmarshalYAML(t *testing.T) {
 				Command: CommandList{{Exec: "example", Print: "example"}},
 			},
 		},
+		{
+			"unset",
+			`unset: [foo, bar]`,
+			Run{
+				Unset: marshal.StringList{"foo", "bar"},
+			},
+		},
+		{
+			"set-environment-and-unset",
+			`{set-environment: {foo: bar}, unset: baz}`,
+			Run{
+				SetEnvironment: map[string]*string{"foo": stringPointer("bar")},
+				Unset:          marshal.StringList{"baz"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -91,6 +108,9 @@ var multipleActionTests = []string{
 	`{task: echo 'hello', environment: {foo: bar}}`,
 	`{command: example, task: echo 'hello', environment: {foo: bar}}`,
 	`{environment: {foo: bar}, set-environment: {bar: baz}}`,
+	`{command: example, unset: foo}`,
+	`{set-environment: {foo: bar}, unset: foo}`,
+	`{pipeline: [{exec: echo foo, filter: cat}, cat]}`,
 }
 
 func TestRun_UnmarshalYAML_command_and_subtask(t *testing.T) {
@@ -198,3 +218,9 @@ func TestRunList_UnmarshalYAML(t *testing.T) {
 		})
 	}
 }
+
+func TestRun_UnmarshalYAML_fail_on_stderr_without_command(t *testing.T) {
+	var r Run
+	err := yaml.UnmarshalStrict([]byte(`{task: build, fail-on-stderr: true}`), &r)
+	assert.ErrorContains(t, err, "`fail-on-stderr` can only be used with a command or pipeline")
+}
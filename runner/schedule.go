@@ -0,0 +1,150 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rliebz/tusk/marshal"
+)
+
+// scheduleTimeFormat is the format for times of day in a schedule.
+const scheduleTimeFormat = "15:04"
+
+// now allows overwriting the clock during tests.
+var now = time.Now
+
+// Schedule is a window of time during which a when clause is true.
+type Schedule struct {
+	After  string             `yaml:",omitempty"`
+	Before string             `yaml:",omitempty"`
+	Days   marshal.StringList `yaml:",omitempty"`
+
+	// TZ is the name of a time zone, such as America/New_York. The local time
+	// zone is used if none is specified.
+	TZ string `yaml:"tz,omitempty"`
+}
+
+// validate returns an error if the schedule definition is invalid.
+func (s *Schedule) validate() error {
+	if s.After == "" && s.Before == "" && len(s.Days) == 0 {
+		return fmt.Errorf("schedule must specify at least one of after, before, or days")
+	}
+
+	for _, t := range []string{s.After, s.Before} {
+		if _, err := parseTimeOfDay(t); err != nil {
+			return err
+		}
+	}
+
+	for _, day := range s.Days {
+		if _, err := parseWeekday(day); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.location(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// matches returns a condition failed error if the time is outside of the
+// schedule.
+func (s *Schedule) matches(t time.Time) error {
+	loc, err := s.location()
+	if err != nil {
+		return err
+	}
+	t = t.In(loc)
+
+	if len(s.Days) != 0 {
+		found := false
+		for _, day := range s.Days {
+			weekday, err := parseWeekday(day)
+			if err != nil {
+				return err
+			}
+
+			if weekday == t.Weekday() {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return newCondFailErrorf("current day %s is not one of %v", t.Weekday(), s.Days)
+		}
+	}
+
+	after, err := parseTimeOfDay(s.After)
+	if err != nil {
+		return err
+	}
+
+	before, err := parseTimeOfDay(s.Before)
+	if err != nil {
+		return err
+	}
+
+	current := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+
+	var inWindow bool
+	switch {
+	case s.After != "" && s.Before != "" && after > before:
+		// The window wraps around midnight, such as 22:00 to 06:00
+		inWindow = current >= after || current < before
+	default:
+		inWindow = (s.After == "" || current >= after) &&
+			(s.Before == "" || current < before)
+	}
+
+	if !inWindow {
+		return newCondFailErrorf(
+			"current time %s is outside of the schedule", t.Format(scheduleTimeFormat),
+		)
+	}
+
+	return nil
+}
+
+func (s *Schedule) location() (*time.Location, error) {
+	if s.TZ == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(s.TZ)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid schedule time zone %q", s.TZ)
+	}
+
+	return loc, nil
+}
+
+// parseTimeOfDay returns the time since midnight for a time such as 22:00.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	t, err := time.Parse(scheduleTimeFormat, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schedule time %q, must be formatted as HH:MM", s)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	name := strings.ToLower(s)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || name == full[:3] {
+			return day, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid schedule day %q", s)
+}
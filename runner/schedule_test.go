@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestWhen_Validate_schedule(t *testing.T) {
+	// 2020-01-01 is a Wednesday
+	utc := func(hour, minute int) time.Time {
+		return time.Date(2020, 1, 1, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		desc      string
+		schedule  string
+		now       time.Time
+		shouldErr bool
+	}{
+		{"inside window", `{after: "09:00", before: "17:00", tz: UTC}`, utc(12, 0), false},
+		{"at window start", `{after: "09:00", before: "17:00", tz: UTC}`, utc(9, 0), false},
+		{"at window end", `{after: "09:00", before: "17:00", tz: UTC}`, utc(17, 0), true},
+		{"before window", `{after: "09:00", before: "17:00", tz: UTC}`, utc(8, 59), true},
+		{"overnight late", `{after: "22:00", before: "06:00", tz: UTC}`, utc(23, 0), false},
+		{"overnight early", `{after: "22:00", before: "06:00", tz: UTC}`, utc(5, 59), false},
+		{"outside overnight", `{after: "22:00", before: "06:00", tz: UTC}`, utc(12, 0), true},
+		{"after only", `{after: "22:00", tz: UTC}`, utc(23, 0), false},
+		{"before only", `{before: "06:00", tz: UTC}`, utc(23, 0), true},
+		{"matching day", `{days: [mon, Wednesday], tz: UTC}`, utc(12, 0), false},
+		{"other day", `{days: [sat, sun], tz: UTC}`, utc(12, 0), true},
+		{"time zone", `{after: "06:00", before: "08:00", tz: Asia/Tokyo}`, utc(22, 0), false},
+		{"time zone outside", `{after: "06:00", before: "08:00", tz: Asia/Tokyo}`, utc(7, 0), true},
+		{"time zone shifts day", `{days: thu, tz: Asia/Tokyo}`, utc(22, 0), false},
+	}
+
+	defer func() { now = time.Now }()
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			now = func() time.Time { return tt.now }
+
+			var w When
+			assert.NilError(t, yaml.UnmarshalStrict([]byte("schedule: "+tt.schedule), &w))
+
+			err := w.Validate(nil)
+			if tt.shouldErr {
+				assert.Check(t, IsFailedCondition(err), "want failed condition, got %v", err)
+			} else {
+				assert.NilError(t, err)
+			}
+		})
+	}
+}
+
+func TestWhen_UnmarshalYAML_invalid_schedule(t *testing.T) {
+	tests := []string{
+		`schedule: {}`,
+		`schedule: {after: "10pm"}`,
+		`schedule: {before: "25:00"}`,
+		`schedule: {days: [someday]}`,
+		`schedule: {after: "22:00", tz: Not/AZone}`,
+	}
+
+	for _, input := range tests {
+		var w When
+		if err := yaml.UnmarshalStrict([]byte(input), &w); err == nil {
+			t.Errorf("Unmarshaling %s: expected error, got nil", input)
+		}
+	}
+}
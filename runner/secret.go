@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rliebz/tusk/ui"
+)
+
+// secretMask is printed in place of the value of a secret option.
+const secretMask = "****"
+
+// minSecretLength is the length of the shortest value that is masked. Shorter
+// values would mask unrelated text in every printed command.
+const minSecretLength = 4
+
+// maskSecrets replaces the values of secret options, as well as any options
+// the task redacts, in printed commands.
+func (t *Task) maskSecrets(options []*Option) {
+	redacted := make(map[string]bool, len(t.RedactOptions))
+	for _, name := range t.RedactOptions {
+		redacted[name] = true
+	}
+
+	var secrets []string
+	for _, o := range options {
+		if !o.Secret && !redacted[o.Name] {
+			continue
+		}
+
+		value := t.Vars[o.Name]
+		if value == "" {
+			continue
+		}
+
+		if len(value) < minSecretLength {
+			ui.Warn(fmt.Sprintf(
+				"value of option %q is too short to be masked", o.Name,
+			))
+			continue
+		}
+
+		secrets = append(secrets, value)
+	}
+
+	if len(secrets) == 0 {
+		return
+	}
+	t.secrets = secrets
+
+	for _, r := range t.AllRunItems() {
+		for i := range r.Command {
+			r.Command[i].Print = maskString(r.Command[i].Print, secrets)
+		}
+
+		for i := range r.Pipeline {
+			r.Pipeline[i].Print = maskString(r.Pipeline[i].Print, secrets)
+		}
+	}
+}
+
+// maskString replaces all instances of the secrets in a string.
+func maskString(s string, secrets []string) string {
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, secretMask)
+	}
+
+	return s
+}
@@ -0,0 +1,24 @@
+package runner
+
+import "fmt"
+
+// useSnippets replaces run items that use a snippet with the snippet's
+// commands. Snippets are inlined before interpolation, so they use the
+// variables of the task they are used in.
+func (t *Task) useSnippets(snippets map[string]CommandList) error {
+	for _, r := range t.AllRunItems() {
+		if r.UseSnippet == "" {
+			continue
+		}
+
+		snippet, ok := snippets[r.UseSnippet]
+		if !ok {
+			return fmt.Errorf("task %q uses unknown snippet %q", t.Name, r.UseSnippet)
+		}
+
+		r.Command = append(CommandList(nil), snippet...)
+		r.UseSnippet = ""
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package runner
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+var snippetConfig = `
+snippets:
+  setup:
+    - set -e; cd ${dir}
+    - exec: echo "building ${name}"
+      print: build ${name}
+
+tasks:
+  frontend:
+    options:
+      dir: {default: ./web}
+      name: {default: frontend}
+    run:
+      - use-snippet: setup
+      - npm run build
+  backend:
+    options:
+      dir: {default: ./api}
+      name: {default: backend}
+    run:
+      - use-snippet: setup
+    finally:
+      - use-snippet: setup
+`
+
+func TestParseComplete_snippets(t *testing.T) {
+	tests := []struct {
+		task string
+		want []string
+	}{
+		{"frontend", []string{"set -e; cd ./web", `echo "building frontend"`, "npm run build"}},
+		{"backend", []string{"set -e; cd ./api", `echo "building backend"`, "set -e; cd ./api", `echo "building backend"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.task, func(t *testing.T) {
+			cfg, err := ParseComplete(&Metadata{CfgText: []byte(snippetConfig)}, tt.task, nil, map[string]string{})
+			assert.NilError(t, err)
+
+			var got []string
+			for _, r := range cfg.Tasks[tt.task].AllRunItems() {
+				assert.Equal(t, r.UseSnippet, "")
+				for _, c := range r.Command {
+					got = append(got, c.Exec)
+				}
+			}
+			assert.DeepEqual(t, got, tt.want)
+
+			assert.Equal(t, cfg.Tasks[tt.task].RunList[0].Command[1].Print, "build "+tt.task)
+		})
+	}
+}
+
+func TestParse_snippets_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name: "unknown snippet",
+			input: `
+tasks:
+  build:
+    run:
+      use-snippet: setup
+`,
+			wantErr: `task "build" uses unknown snippet "setup"`,
+		},
+		{
+			name: "with another action",
+			input: `
+snippets:
+  setup: echo setup
+tasks:
+  build:
+    run:
+      use-snippet: setup
+      command: echo build
+`,
+			wantErr: "only one action can be defined in `run`",
+		},
+		{
+			name: "defined in multiple documents",
+			input: `
+snippets:
+  setup: echo one
+---
+snippets:
+  setup: echo two
+`,
+			wantErr: `snippet "setup" is defined in multiple documents`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParse_snippets_multiple_documents(t *testing.T) {
+	cfg, err := Parse([]byte(`
+snippets:
+  one: echo one
+---
+snippets:
+  two: echo two
+tasks:
+  both:
+    run:
+      - use-snippet: one
+      - use-snippet: two
+`))
+	assert.NilError(t, err)
+
+	run := cfg.Tasks["both"].RunList
+	assert.Equal(t, run[0].Command[0].Exec, "echo one")
+	assert.Equal(t, run[1].Command[0].Exec, "echo two")
+}
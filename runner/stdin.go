@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rliebz/tusk/marshal"
+)
+
+// Stdin is the input passed to a command, either as text or read from a file.
+type Stdin struct {
+	Text string `yaml:"text,omitempty"`
+	File string `yaml:"file,omitempty"`
+}
+
+// UnmarshalYAML allows text to be used on its own.
+func (s *Stdin) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var text string
+	textCandidate := marshal.UnmarshalCandidate{
+		Unmarshal: func() error { return unmarshal(&text) },
+		Assign:    func() { *s = Stdin{Text: text} },
+	}
+
+	type stdinType Stdin // Use new type to avoid recursion
+	var stdinItem stdinType
+	stdinCandidate := marshal.UnmarshalCandidate{
+		Unmarshal: func() error { return unmarshal(&stdinItem) },
+		Validate: func() error {
+			if stdinItem.Text != "" && stdinItem.File != "" {
+				return errors.New("stdin cannot specify both text and a file")
+			}
+
+			return nil
+		},
+		Assign: func() { *s = Stdin(stdinItem) },
+	}
+
+	return marshal.UnmarshalOneOf(textCandidate, stdinCandidate)
+}
+
+// open returns a reader for the input. The caller must close it when the
+// command has finished.
+func (s *Stdin) open() (io.ReadCloser, error) {
+	if s.File == "" {
+		return ioutil.NopCloser(strings.NewReader(s.Text)), nil
+	}
+
+	f, err := os.Open(s.File)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening stdin file")
+	}
+
+	return f, nil
+}
+
+// openStdin returns the input for a command, which is the stdin of tusk
+// unless the command sets its own. The function returned closes the input.
+func (c *Command) openStdin() (io.Reader, func(), error) {
+	if c.Stdin == nil {
+		return os.Stdin, func() {}, nil
+	}
+
+	r, err := c.Stdin.open()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	closeStdin := func() {
+		r.Close() // nolint: errcheck
+	}
+
+	return r, closeStdin, nil
+}
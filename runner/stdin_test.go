@@ -0,0 +1,124 @@
+package runner
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/rliebz/tusk/ui"
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestStdin_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Stdin
+	}{
+		{`hello`, Stdin{Text: "hello"}},
+		{`""`, Stdin{}},
+		{`{text: hello}`, Stdin{Text: "hello"}},
+		{`{file: input.txt}`, Stdin{File: "input.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var got Stdin
+			assert.NilError(t, yaml.UnmarshalStrict([]byte(tt.input), &got))
+			assert.DeepEqual(t, tt.want, got)
+		})
+	}
+}
+
+func TestStdin_UnmarshalYAML_text_and_file(t *testing.T) {
+	var s Stdin
+	err := yaml.UnmarshalStrict([]byte(`{text: hello, file: input.txt}`), &s)
+	assert.ErrorContains(t, err, "stdin cannot specify both text and a file")
+}
+
+func TestRun_UnmarshalYAML_pipeline_stdin(t *testing.T) {
+	var r Run
+	err := yaml.UnmarshalStrict([]byte(`pipeline: [{exec: cat, stdin: a}, {exec: cat, stdin: b}]`), &r)
+	assert.ErrorContains(t, err, "only the first command in a pipeline can use `stdin`")
+}
+
+func TestCommand_exec_stdin(t *testing.T) {
+	dir := fs.NewDir(t, "stdin", fs.WithFile("input.txt", "from a file\n"))
+	defer dir.Remove()
+
+	stdout, err := os.Create(dir.Join("stdout"))
+	assert.NilError(t, err)
+	defer stdout.Close() // nolint: errcheck
+
+	defer func(w io.Writer) { ui.Stdout = w }(ui.Stdout)
+	ui.Stdout = stdout
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+		want    string
+	}{
+		{name: "text", input: `{exec: cat, stdin: "inline text\n"}`, want: "inline text\n"},
+		{name: "empty", input: `{exec: cat, stdin: ""}`, want: ""},
+		{
+			name:  "file",
+			input: `{exec: cat, stdin: {file: ` + dir.Join("input.txt") + `}}`,
+			want:  "from a file\n",
+		},
+		{
+			name:    "missing file",
+			input:   `{exec: cat, stdin: {file: ` + dir.Join("missing.txt") + `}}`,
+			wantErr: "opening stdin file",
+		},
+		{
+			name:  "pipeline",
+			input: `{pipeline: [{exec: cat, stdin: "b\na\n"}, sort]}`,
+			want:  "a\nb\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NilError(t, stdout.Truncate(0))
+			_, err := stdout.Seek(0, 0)
+			assert.NilError(t, err)
+
+			var task Task
+			assert.NilError(t, yaml.UnmarshalStrict([]byte(`run: `+tt.input), &task))
+
+			err = task.Execute(RunContext{})
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+
+			got, err := ioutil.ReadFile(stdout.Name())
+			assert.NilError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestParseComplete_stdin_interpolation(t *testing.T) {
+	cfgText := []byte(`
+tasks:
+  greet:
+    options:
+      name:
+        default: world
+    run:
+      command:
+        exec: cat
+        stdin: hello ${name}
+`)
+
+	cfg, err := ParseComplete(&Metadata{CfgText: cfgText}, "greet", nil, map[string]string{"name": "tusk"})
+	assert.NilError(t, err)
+
+	stdin := cfg.Tasks["greet"].RunList[0].Command[0].Stdin
+	assert.DeepEqual(t, &Stdin{Text: "hello tusk"}, stdin)
+}
@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/rliebz/tusk/ui"
+)
+
+var unsafeFilenamePattern = regexp.MustCompile(`[^\w.-]+`)
+
+// stepLogs writes the output of each step in a run to its own numbered file.
+// It is shared by all copies of a run context.
+type stepLogs struct {
+	mu   sync.Mutex
+	dir  string
+	step int
+}
+
+// open creates the log file for the next step, which is named after the task
+// currently running.
+func (l *stepLogs) open(ctx RunContext) (*os.File, error) {
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return nil, err
+	}
+
+	name := "tusk"
+	if tasks := ctx.Tasks(); len(tasks) > 0 {
+		name = unsafeFilenamePattern.ReplaceAllString(tasks[len(tasks)-1], "-")
+	}
+
+	l.mu.Lock()
+	l.step++
+	step := l.step
+	l.mu.Unlock()
+
+	path := filepath.Join(l.dir, fmt.Sprintf("%02d-%s.log", step, name))
+
+	return os.Create(path)
+}
+
+// openStepLog returns the log file for the next step, or nil if steps are not
+// being logged.
+func (r *RunContext) openStepLog() (*os.File, error) {
+	if r.stepLogs == nil {
+		return nil, nil
+	}
+
+	return r.stepLogs.open(*r)
+}
+
+// printStepLogOnFailure prints where the output of a step was saved, if the
+// step failed.
+func printStepLogOnFailure(path string, err *error) {
+	if *err == nil {
+		return
+	}
+
+	ui.Info(fmt.Sprintf("output of the failed step was saved to %s", ui.LinkPath(path)))
+}
+
+// teeTo returns a writer that writes to both w and the log, where w may be nil.
+func teeTo(w io.Writer, log io.Writer) io.Writer {
+	if w == nil {
+		return log
+	}
+
+	return io.MultiWriter(w, log)
+}
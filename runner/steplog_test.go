@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"io/ioutil"
+	"sort"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestTask_Execute_step_logs(t *testing.T) {
+	dir := fs.NewDir(t, "run-dir")
+	defer dir.Remove()
+
+	runDir := dir.Join("logs")
+	meta := Metadata{RunDir: runDir}
+
+	task := Task{
+		Name: "build",
+		RunList: RunList{
+			&Run{Command: CommandList{
+				{Exec: "echo one"},
+				{Exec: "echo two >&2"},
+			}},
+			&Run{Pipeline: CommandList{
+				{Exec: "printf 'a\\nb\\n'"},
+				{Exec: "grep b"},
+			}},
+		},
+		Finally: RunList{
+			&Run{Command: CommandList{{Exec: "echo cleanup"}}},
+		},
+	}
+
+	assert.NilError(t, task.Execute(meta.RunContext()))
+
+	files, err := ioutil.ReadDir(runDir)
+	assert.NilError(t, err)
+
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	sort.Strings(names)
+
+	assert.DeepEqual(t, names, []string{
+		"01-build.log",
+		"02-build.log",
+		"03-build.log",
+		"04-build.log",
+	})
+
+	want := []string{"one\n", "two\n", "b\n", "cleanup\n"}
+	for i, name := range names {
+		contents, err := ioutil.ReadFile(dir.Join("logs", name))
+		assert.NilError(t, err)
+		assert.Equal(t, string(contents), want[i], name)
+	}
+}
+
+func TestTask_Execute_step_logs_names(t *testing.T) {
+	dir := fs.NewDir(t, "run-dir")
+	defer dir.Remove()
+
+	meta := Metadata{RunDir: dir.Path()}
+	ctx := meta.RunContext()
+
+	for _, name := range []string{"lint", "db:migrate"} {
+		task := Task{
+			Name:    name,
+			RunList: RunList{&Run{Command: CommandList{{Exec: "true"}}}},
+		}
+		assert.NilError(t, task.Execute(ctx))
+	}
+
+	for _, name := range []string{"01-lint.log", "02-db-migrate.log"} {
+		_, err := ioutil.ReadFile(dir.Join(name))
+		assert.NilError(t, err)
+	}
+}
@@ -1,12 +1,20 @@
 package runner
 
-import "github.com/rliebz/tusk/marshal"
+import (
+	"fmt"
+
+	"github.com/rliebz/tusk/marshal"
+)
+
+// passAllOptions is used to pass all matching options to a sub-task.
+const passAllOptions = "all"
 
 // SubTask is a description of a sub-task with passed options.
 type SubTask struct {
-	Name    string
-	Args    marshal.StringList
-	Options map[string]string
+	Name        string
+	Args        marshal.StringList
+	Options     map[string]string
+	PassOptions marshal.StringList `yaml:"pass-options,omitempty"`
 }
 
 // UnmarshalYAML allows unmarshaling a string to represent the subtask name.
@@ -21,7 +29,19 @@ func (s *SubTask) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var subTaskItem subTaskType
 	subTaskCandidate := marshal.UnmarshalCandidate{
 		Unmarshal: func() error { return unmarshal(&subTaskItem) },
-		Assign:    func() { *s = SubTask(subTaskItem) },
+		Validate: func() error {
+			for _, name := range subTaskItem.PassOptions {
+				if name == passAllOptions && len(subTaskItem.PassOptions) > 1 {
+					return fmt.Errorf(
+						`pass-options for task %q cannot list %q with other options`,
+						subTaskItem.Name, passAllOptions,
+					)
+				}
+			}
+
+			return nil
+		},
+		Assign: func() { *s = SubTask(subTaskItem) },
 	}
 
 	return marshal.UnmarshalOneOf(nameCandidate, subTaskCandidate)
@@ -46,3 +66,38 @@ func (l *SubTaskList) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 	return marshal.UnmarshalOneOf(sliceCandidate, itemCandidate)
 }
+
+// passedOptions returns the values of options passed from the parent task to
+// the sub-task, not including options specified explicitly.
+func (s *SubTask) passedOptions(subTask *Task, vars map[string]string) (map[string]string, error) {
+	passed := make(map[string]string)
+
+	if len(s.PassOptions) == 1 && s.PassOptions[0] == passAllOptions {
+		for _, opt := range subTask.Options {
+			if value, ok := vars[opt.Name]; ok && !opt.Private {
+				passed[opt.Name] = value
+			}
+		}
+
+		return passed, nil
+	}
+
+	for _, name := range s.PassOptions {
+		if _, ok := subTask.Options.Lookup(name); !ok {
+			return nil, fmt.Errorf(
+				"option %q cannot be passed to task %q", name, subTask.Name,
+			)
+		}
+
+		value, ok := vars[name]
+		if !ok {
+			return nil, fmt.Errorf(
+				"option %q passed to task %q is not defined", name, subTask.Name,
+			)
+		}
+
+		passed[name] = value
+	}
+
+	return passed, nil
+}
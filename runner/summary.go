@@ -0,0 +1,85 @@
+package runner
+
+import (
+	"bytes"
+	"os/exec"
+	"sync"
+
+	"github.com/rliebz/tusk/ui"
+)
+
+// summarizes returns whether a summary of the steps of a task is printed once
+// it has run, which is the case for the task being run when only summaries
+// are printed. Sub-tasks and the combinations of a matrix are not summarized.
+func (r *RunContext) summarizes(t *Task) bool {
+	return r.SummaryOnly && !r.inMatrix &&
+		len(r.taskStack) == 1 && r.taskStack[0] == t
+}
+
+// runSummarized runs each item in the run list, stopping at the first that
+// fails, and prints a summary of the items that ran.
+func (t *Task) runSummarized(ctx RunContext) error {
+	results := make([]ui.StepResult, 0, len(t.RunList))
+	defer func() { ui.PrintStepSummary(t.Name, results) }()
+
+	for i, r := range t.RunList {
+		ctx.step = i + 1
+		result := t.runForResult(ctx, r)
+
+		if ctx.interrupted() {
+			return ErrInterrupted
+		}
+
+		results = append(results, result)
+		if result.Err != nil {
+			return result.Err
+		}
+	}
+
+	return nil
+}
+
+// commandOutput holds back the output of a command, which may be written
+// concurrently by several processes in a pipeline.
+type commandOutput struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (o *commandOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.buf.Write(p)
+}
+
+// holdOutput sends the output of commands to a buffer when only summaries
+// are printed, returning the buffer. Output captured for another purpose is
+// left as-is. If output is not held back, nil is returned.
+func (r *RunContext) holdOutput(cmds ...*exec.Cmd) *commandOutput {
+	if !r.SummaryOnly {
+		return nil
+	}
+
+	output := new(commandOutput)
+	for _, cmd := range cmds {
+		cmd.Stderr = output
+		if r.stdout == nil {
+			cmd.Stdout = output
+		}
+	}
+
+	return output
+}
+
+// printOnFailure prints the output held back for a command if it failed.
+func (o *commandOutput) printOnFailure(command string, err *error) {
+	if o == nil || *err == nil || IsInterrupted(*err) {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	ui.PrintCommandOutput(command, o.buf.Bytes())
+}
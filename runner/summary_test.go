@@ -0,0 +1,115 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/rliebz/tusk/ui"
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
+)
+
+// captureSummaryOutput sets up output for a run that only prints summaries,
+// returning everything written and a function to restore the output.
+func captureSummaryOutput() (*bytes.Buffer, func()) {
+	stdout, stderr, level, summaryOnly := ui.Stdout, ui.Stderr, ui.Verbosity, ui.SummaryOnly
+	restore := func() {
+		ui.Stdout, ui.Stderr = stdout, stderr
+		ui.LoggerStderr.SetOutput(os.Stderr)
+		ui.Verbosity, ui.SummaryOnly = level, summaryOnly
+	}
+
+	buf := new(bytes.Buffer)
+	ui.Stdout, ui.Stderr = buf, buf
+	ui.LoggerStderr.SetOutput(buf)
+	ui.Verbosity = ui.VerbosityLevelQuiet
+	ui.SummaryOnly = true
+
+	return buf, restore
+}
+
+func TestTask_Execute_summary_only(t *testing.T) {
+	buf, restore := captureSummaryOutput()
+	defer restore()
+
+	input := `
+run:
+  - name: build
+    command: echo built && echo building >&2
+  - name: lint
+    pipeline:
+      - echo lint-output
+      - cat && exit 3
+  - name: test
+    command: echo tested
+`
+
+	var task Task
+	assert.NilError(t, yaml.UnmarshalStrict([]byte(input), &task))
+	task.Name = "ci"
+
+	err := task.Execute(RunContext{SummaryOnly: true})
+	assert.Error(t, err, "exit status 3")
+
+	out := buf.String()
+	assert.Check(t, !bytes.Contains(buf.Bytes(), []byte("built")), out)
+	assert.Check(t, !bytes.Contains(buf.Bytes(), []byte("building")), out)
+	assert.Check(t, !bytes.Contains(buf.Bytes(), []byte("tested")), out)
+	assert.Check(t, cmp.Contains(out, "Output: echo lint-output | cat && exit 3\n => lint-output\n"))
+	assert.Check(t, cmp.Contains(out, "Summary: ci\n => passed build\n => failed lint (exit status 3)\n"))
+}
+
+func TestTask_Execute_summary_only_sub_task(t *testing.T) {
+	buf, restore := captureSummaryOutput()
+	defer restore()
+
+	task := Task{
+		Name: "parent",
+		RunList: RunList{
+			&Run{Name: "sub", Tasks: []Task{{
+				Name:    "child",
+				RunList: RunList{&Run{Command: CommandList{{Exec: "echo child"}}}},
+			}}},
+		},
+	}
+
+	assert.NilError(t, task.Execute(RunContext{SummaryOnly: true}))
+	assert.Check(t, cmp.Equal(buf.String(), "Summary: parent\n => passed sub\n"))
+}
+
+func TestRunMatrix_summary_only(t *testing.T) {
+	buf, restore := captureSummaryOutput()
+	defer restore()
+
+	meta := &Metadata{
+		CfgText: []byte(`
+tasks:
+  test:
+    options:
+      go: {}
+    matrix:
+      go: [1.21, 1.22]
+    run:
+      - echo running ${go}
+      - test ${go} != 1.22
+`),
+	}
+
+	for _, parallel := range []bool{false, true} {
+		buf.Reset()
+
+		_, runs, err := ParseMatrix(meta, "test", nil, map[string]string{})
+		assert.NilError(t, err)
+
+		err = RunMatrix(RunContext{SummaryOnly: true}, runs, parallel)
+		assert.ErrorContains(t, err, "matrix go=1.22: exit status 1")
+
+		out := buf.String()
+		assert.Check(t, !bytes.Contains(buf.Bytes(), []byte("running")), out)
+		assert.Check(t, !bytes.Contains(buf.Bytes(), []byte("Summary: test")), out)
+		assert.Check(t, cmp.Contains(out, "Info: matrix go=1.21: passed in "))
+		assert.Check(t, cmp.Contains(out, "Info: matrix go=1.22: failed in "))
+	}
+}
@@ -0,0 +1,96 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// stderrTailLines is the number of lines of stderr kept for failed commands.
+const stderrTailLines = 20
+
+// tailWriter is an io.Writer that keeps only the last few lines written.
+type tailWriter struct {
+	max     int
+	lines   []string
+	partial []byte
+}
+
+func newTailWriter(max int) *tailWriter {
+	return &tailWriter{max: max}
+}
+
+// Write records the lines written, discarding lines beyond the maximum.
+func (w *tailWriter) Write(p []byte) (int, error) {
+	data := append(w.partial, p...)
+
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+
+		w.add(strings.TrimRight(string(data[:i]), "\r"))
+		data = data[i+1:]
+	}
+
+	w.partial = append([]byte(nil), data...)
+
+	return len(p), nil
+}
+
+// Lines returns the lines recorded, including any unterminated final line.
+func (w *tailWriter) Lines() []string {
+	lines := append([]string(nil), w.lines...)
+	if len(w.partial) > 0 {
+		lines = append(lines, string(w.partial))
+	}
+
+	if len(lines) > w.max {
+		lines = lines[len(lines)-w.max:]
+	}
+
+	return lines
+}
+
+// stderrCapture records the stderr of a command run by a step that fails on
+// any stderr output.
+type stderrCapture struct {
+	tail *tailWriter
+}
+
+// captureStderr starts recording the stderr of a command if the step running
+// fails on stderr output. Otherwise, nothing is recorded.
+func (r *RunContext) captureStderr(cmd *exec.Cmd) stderrCapture {
+	if !r.failOnStderr {
+		return stderrCapture{}
+	}
+
+	tail := newTailWriter(stderrTailLines)
+	cmd.Stderr = teeTo(cmd.Stderr, tail)
+
+	return stderrCapture{tail}
+}
+
+// check returns an error if a command that otherwise succeeded wrote to
+// stderr. Output that is only whitespace is ignored.
+func (c stderrCapture) check(err error) error {
+	if err != nil || c.tail == nil {
+		return err
+	}
+
+	output := strings.Join(c.tail.Lines(), "\n")
+	if strings.TrimSpace(output) == "" {
+		return nil
+	}
+
+	return fmt.Errorf("command succeeded but wrote to stderr:\n%s", output)
+}
+
+func (w *tailWriter) add(line string) {
+	w.lines = append(w.lines, line)
+	if len(w.lines) > w.max {
+		w.lines = w.lines[1:]
+	}
+}
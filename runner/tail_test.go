@@ -0,0 +1,17 @@
+package runner
+
+import (
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestTailWriter(t *testing.T) {
+	w := newTailWriter(2)
+
+	fmt.Fprint(w, "one\ntwo\nthr")
+	fmt.Fprint(w, "ee\nfour")
+
+	assert.DeepEqual(t, w.Lines(), []string{"three", "four"})
+}
@@ -2,7 +2,10 @@ package runner
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rliebz/tusk/marshal"
@@ -23,20 +26,47 @@ type Task struct {
 	Args    Args    `yaml:"args,omitempty"`
 	Options Options `yaml:"options,omitempty"`
 
-	RunList     RunList `yaml:"run"`
-	Finally     RunList `yaml:"finally,omitempty"`
-	Usage       string  `yaml:",omitempty"`
-	Description string  `yaml:",omitempty"`
-	Private     bool
+	RunList     RunList            `yaml:"run"`
+	Finally     RunList            `yaml:"finally,omitempty"`
+	Usage       string             `yaml:",omitempty"`
+	Description string             `yaml:",omitempty"`
+	Privacy     Privacy            `yaml:"private,omitempty"`
+	Skip        WhenList           `yaml:"skip,omitempty"`
+	Produces    marshal.StringList `yaml:",omitempty"`
+	Budget      time.Duration      `yaml:",omitempty"`
+	Confirm     *Confirm           `yaml:",omitempty"`
+	Container   *Container         `yaml:",omitempty"`
+
+	DescriptionFile string `yaml:"description-file,omitempty"`
+
+	BeforeEach CommandList `yaml:"before-each,omitempty"`
+	AfterEach  CommandList `yaml:"after-each,omitempty"`
+
+	ContinueOnError bool     `yaml:"continue-on-error,omitempty"`
+	FailOnStderr    bool     `yaml:"fail-on-stderr,omitempty"`
+	FinallyParallel bool     `yaml:"finally-parallel,omitempty"`
+	RequireCleanGit CleanGit `yaml:"require-clean-git,omitempty"`
+
+	Matrix         Matrix `yaml:"matrix,omitempty"`
+	MatrixParallel bool   `yaml:"matrix-parallel,omitempty"`
+
+	RedactOptions marshal.StringList `yaml:"redact-options,omitempty"`
+	RequireOneOf  marshal.StringList `yaml:"require-one-of,omitempty"`
 
 	// Computed members not specified in yaml file
-	Name string            `yaml:"-"`
-	Vars map[string]string `yaml:"-"`
+	Name      string            `yaml:"-"`
+	Namespace string            `yaml:"-"`
+	Private   bool              `yaml:"-"`
+	Source    string            `yaml:"-"`
+	Exports   map[string]string `yaml:"-"`
+	Vars      map[string]string `yaml:"-"`
+	secrets   []string
 }
 
 // UnmarshalYAML unmarshals and assigns names to options.
 func (t *Task) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var includeTarget Task
+	var includePath string
 	includeCandidate := marshal.UnmarshalCandidate{
 		Unmarshal: func() error {
 			var def struct {
@@ -57,9 +87,11 @@ func (t *Task) UnmarshalYAML(unmarshal func(interface{}) error) error {
 				return errors.New(`tasks using "include" may not specify other fields`)
 			}
 
+			includePath = def.Include
+
 			f, err := os.Open(def.Include)
 			if err != nil {
-				return fmt.Errorf("opening included file: %w", err)
+				return errors.Wrap(err, "opening included file")
 			}
 			defer f.Close() // nolint: errcheck
 
@@ -67,12 +99,15 @@ func (t *Task) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			decoder.SetStrict(true)
 
 			if err := decoder.Decode(&includeTarget); err != nil {
-				return fmt.Errorf("decoding included file %q: %w", def.Include, err)
+				return errors.Wrapf(err, "decoding included file %q", def.Include)
 			}
 
 			return nil
 		},
-		Assign: func() { *t = includeTarget },
+		Assign: func() {
+			*t = includeTarget
+			t.Source = includePath
+		},
 	}
 
 	var taskTarget Task
@@ -81,8 +116,24 @@ func (t *Task) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			type taskType Task // Use new type to avoid recursion
 			return unmarshal((*taskType)(&taskTarget))
 		},
-		Validate: taskTarget.checkOptArgCollisions,
-		Assign:   func() { *t = taskTarget },
+		Validate: func() error {
+			if err := taskTarget.checkOptArgCollisions(); err != nil {
+				return err
+			}
+
+			if err := taskTarget.readDescriptionFile(); err != nil {
+				return err
+			}
+
+			private, err := taskTarget.Privacy.evaluate()
+			if err != nil {
+				return err
+			}
+			taskTarget.Private = private
+
+			return nil
+		},
+		Assign: func() { *t = taskTarget },
 	}
 
 	return marshal.UnmarshalOneOf(includeCandidate, taskCandidate)
@@ -104,11 +155,105 @@ func (t *Task) checkOptArgCollisions() error {
 	return nil
 }
 
+// readDescriptionFile sets the description of a task from the contents of its
+// description file. Relative paths are resolved from the working directory,
+// which is the directory of the config file.
+func (t *Task) readDescriptionFile() error {
+	if t.DescriptionFile == "" {
+		return nil
+	}
+
+	if t.Description != "" {
+		return errors.New(`tasks using "description-file" may not specify "description"`)
+	}
+
+	contents, err := ioutil.ReadFile(t.DescriptionFile)
+	if err != nil {
+		return errors.Wrap(err, "reading description file")
+	}
+	t.Description = string(contents)
+
+	return nil
+}
+
 // AllRunItems returns all run items referenced, including `run` and `finally`.
 func (t *Task) AllRunItems() RunList {
 	return append(t.RunList, t.Finally...)
 }
 
+// setsEnvironment returns whether the task or any of its sub-tasks sets or
+// unsets environment variables.
+func (t *Task) setsEnvironment() bool {
+	for _, r := range t.AllRunItems() {
+		if len(r.environment()) > 0 {
+			return true
+		}
+
+		for i := range r.Tasks {
+			if r.Tasks[i].setsEnvironment() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// setSpecifiedOptions records which options were passed explicitly, for use
+// by option-set conditions.
+func (t *Task) setSpecifiedOptions(specified map[string]bool) {
+	if len(specified) == 0 {
+		return
+	}
+
+	t.Skip.setSpecified(specified)
+	for _, r := range t.AllRunItems() {
+		r.When.setSpecified(specified)
+	}
+}
+
+// setExports records the environment variables to export to the task's
+// commands, from the values of options that use export-as.
+func (t *Task) setExports(options []*Option) {
+	for _, o := range options {
+		if o.ExportAs == "" {
+			continue
+		}
+
+		if t.Exports == nil {
+			t.Exports = make(map[string]string)
+		}
+		t.Exports[o.ExportAs] = t.Vars[o.Name]
+	}
+}
+
+// Artifacts returns the paths produced by the task and its sub-tasks, which
+// may include glob patterns.
+func (t *Task) Artifacts() []string {
+	seen := make(map[string]struct{})
+	var artifacts []string
+
+	add := func(paths []string) {
+		for _, path := range paths {
+			if _, ok := seen[path]; ok {
+				continue
+			}
+
+			seen[path] = struct{}{}
+			artifacts = append(artifacts, path)
+		}
+	}
+
+	add(t.Produces)
+	for _, r := range t.AllRunItems() {
+		for i := range r.Tasks {
+			add(r.Tasks[i].Artifacts())
+		}
+	}
+
+	return artifacts
+}
+
 // Dependencies returns a list of options that are required explicitly.
 // This does not include interpolations.
 func (t *Task) Dependencies() []string {
@@ -120,23 +265,54 @@ func (t *Task) Dependencies() []string {
 	for _, run := range t.AllRunItems() {
 		options = append(options, run.When.Dependencies()...)
 	}
+	options = append(options, t.Skip.Dependencies()...)
+	options = append(options, t.RequireOneOf...)
 
 	return options
 }
 
 // Execute runs the Run scripts in the task.
 func (t *Task) Execute(ctx RunContext) (err error) {
+	if skip, err := t.shouldSkip(); skip || err != nil {
+		return err
+	}
+
+	if err := t.checkCleanGit(); err != nil {
+		return err
+	}
+
+	if err := t.confirm(ctx); err != nil {
+		return err
+	}
+
 	if !t.Private {
 		ctx.PushTask(t)
 	}
+	ctx.exports = t.Exports
+	ctx.secrets = t.secrets
+	ctx.container = t.Container
 
 	ui.PrintTask(t.Name)
 
-	defer ui.PrintTaskCompleted(t.Name)
+	start := now()
+	defer func() { ui.PrintTaskCompleted(t.Name, now().Sub(start)) }()
+	defer t.checkBudget(ctx, start, &err)
 	defer t.runFinally(ctx, &err)
 
-	for _, r := range t.RunList {
-		if rerr := t.run(ctx, r, stateRunning); rerr != nil {
+	switch {
+	case t.ContinueOnError:
+		return t.runAll(ctx)
+	case ctx.summarizes(t):
+		return t.runSummarized(ctx)
+	}
+
+	for i, r := range t.RunList {
+		ctx.step = i + 1
+		rerr := t.run(ctx, r, stateRunning)
+		if ctx.interrupted() {
+			return ErrInterrupted
+		}
+		if rerr != nil {
 			return rerr
 		}
 	}
@@ -144,14 +320,71 @@ func (t *Task) Execute(ctx RunContext) (err error) {
 	return err
 }
 
+// shouldSkip returns whether the task's skip conditions are met.
+func (t *Task) shouldSkip() (bool, error) {
+	if len(t.Skip) == 0 {
+		return false, nil
+	}
+
+	if err := t.Skip.Validate(t.Vars); err != nil {
+		if !IsFailedCondition(err) {
+			return false, err
+		}
+
+		return false, nil
+	}
+
+	ui.PrintSkipped("task: "+t.Name, "skip condition met")
+	return true, nil
+}
+
+// checkBudget warns if the task took longer than its budget, or returns an
+// error if the run context requires budgets to be met.
+func (t *Task) checkBudget(ctx RunContext, start time.Time, err *error) {
+	if t.Budget == 0 || ctx.CheckOnly {
+		return
+	}
+
+	elapsed := now().Sub(start)
+	if elapsed <= t.Budget {
+		return
+	}
+
+	msg := fmt.Sprintf(
+		"task %q took %s, exceeding its budget of %s",
+		t.Name, elapsed.Round(time.Millisecond), t.Budget,
+	)
+
+	if !ctx.FailOnBudget {
+		ui.Warn(msg)
+		return
+	}
+
+	// Do not overwrite existing errors
+	if *err == nil {
+		*err = errors.New(msg)
+	}
+}
+
 func (t *Task) runFinally(ctx RunContext, err *error) {
 	if len(t.Finally) == 0 {
 		return
 	}
 
+	if ctx.NoCleanupOnInterrupt && ctx.interrupted() {
+		return
+	}
+	ctx.finalizing = true
+
 	ui.PrintTaskFinally(t.Name)
 
-	for _, r := range t.Finally {
+	if t.FinallyParallel {
+		t.runFinallyParallel(ctx, err)
+		return
+	}
+
+	for i, r := range t.Finally {
+		ctx.step = i + 1
 		if rerr := t.run(ctx, r, stateFinally); rerr != nil {
 			// Do not overwrite existing errors
 			if *err == nil {
@@ -162,16 +395,44 @@ func (t *Task) runFinally(ctx RunContext, err *error) {
 	}
 }
 
+// runFinallyParallel runs the finally items concurrently, up to the limit set
+// by --max-parallel. Unlike running them in order, all items are run even if
+// some fail, and their errors are combined with any existing error.
+func (t *Task) runFinallyParallel(ctx RunContext, err *error) {
+	errs := make([]error, len(t.Finally)+1)
+	errs[0] = *err
+
+	ctx.runParallel(len(t.Finally), func(i int) {
+		ctx := ctx
+		ctx.step = i + 1
+		errs[i+1] = t.run(ctx, t.Finally[i], stateFinally)
+	})
+
+	*err = combineErrors(errs...)
+}
+
 // run executes a Run struct.
 func (t *Task) run(ctx RunContext, r *Run, s executionState) error {
 	if ok, err := r.shouldRun(t.Vars); !ok || err != nil {
 		return err
 	}
 
+	return t.runStep(ctx, r, s)
+}
+
+// runActions executes a Run struct without checking its conditions.
+func (t *Task) runActions(ctx RunContext, r *Run, s executionState) error {
+	ctx.failOnStderr = t.FailOnStderr
+	if r.FailOnStderr != nil {
+		ctx.failOnStderr = *r.FailOnStderr
+	}
+
 	runFuncs := []func() error{
 		func() error { return t.runCommands(ctx, r, s) },
+		func() error { return t.runPipeline(ctx, r, s) },
 		func() error { return t.runSubTasks(ctx, r) },
-		func() error { return t.runEnvironment(r) },
+		func() error { return t.runEnvironment(ctx, r) },
+		func() error { return t.runAbort(r) },
 	}
 
 	for i := range runFuncs {
@@ -185,14 +446,15 @@ func (t *Task) run(ctx RunContext, r *Run, s executionState) error {
 
 func (t *Task) runCommands(ctx RunContext, r *Run, s executionState) error {
 	for _, command := range r.Command {
-		switch s {
-		case stateFinally:
-			ui.PrintCommandWithParenthetical(command.Print, "finally", ctx.Tasks()...)
-		default:
-			ui.PrintCommand(command.Print, ctx.Tasks()...)
+		// Commands are always printed when checking, as with make -n
+		if command.echo() || ctx.CheckOnly {
+			printCommand(ctx, command.Print, s)
+		}
+		if ctx.CheckOnly {
+			continue
 		}
 
-		if err := command.exec(); err != nil {
+		if err := command.exec(ctx); err != nil {
 			ui.PrintCommandError(err)
 			return err
 		}
@@ -201,6 +463,21 @@ func (t *Task) runCommands(ctx RunContext, r *Run, s executionState) error {
 	return nil
 }
 
+func (t *Task) runPipeline(ctx RunContext, r *Run, s executionState) error {
+	if len(r.Pipeline) == 0 {
+		return nil
+	}
+
+	if r.Pipeline.echo() || ctx.CheckOnly {
+		printCommand(ctx, r.Pipeline.print(), s)
+	}
+	if ctx.CheckOnly {
+		return nil
+	}
+
+	return r.Pipeline.execPipeline(ctx)
+}
+
 func (t *Task) runSubTasks(ctx RunContext, r *Run) error {
 	for i := range r.Tasks {
 		if err := r.Tasks[i].Execute(ctx); err != nil {
@@ -211,9 +488,13 @@ func (t *Task) runSubTasks(ctx RunContext, r *Run) error {
 	return nil
 }
 
-func (t *Task) runEnvironment(r *Run) error {
-	ui.PrintEnvironment(r.SetEnvironment)
-	for key, value := range r.SetEnvironment {
+func (t *Task) runEnvironment(ctx RunContext, r *Run) error {
+	environment := r.environment()
+
+	ui.PrintEnvironment(environment)
+	for key, value := range environment {
+		ctx.markEnvironment(key)
+
 		if value == nil {
 			if err := os.Unsetenv(key); err != nil {
 				return err
@@ -229,3 +510,24 @@ func (t *Task) runEnvironment(r *Run) error {
 
 	return nil
 }
+
+// printCommand prints a command with any relevant information about how it
+// is being run.
+func printCommand(ctx RunContext, command string, s executionState) {
+	var parentheticals []string
+	if s == stateFinally {
+		parentheticals = append(parentheticals, "finally")
+	}
+	if ctx.CheckOnly {
+		parentheticals = append(parentheticals, "check")
+	}
+
+	if len(parentheticals) == 0 {
+		ui.PrintCommand(command, ctx.step, ctx.Tasks()...)
+		return
+	}
+
+	ui.PrintCommandWithParenthetical(
+		command, strings.Join(parentheticals, ", "), ctx.step, ctx.Tasks()...,
+	)
+}
@@ -4,15 +4,22 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/rliebz/tusk/marshal"
 	"github.com/rliebz/tusk/ui"
 	yaml "gopkg.in/yaml.v2"
 	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
 )
 
 func TestTask_UnmarshalYAML(t *testing.T) {
@@ -57,6 +64,7 @@ args: { three: {}, four: {} }
 					Exec:  `echo "We're in!"`,
 					Print: `echo "We're in!"`,
 				}}}},
+				Source: testdata("included.yml"),
 			},
 		},
 		{
@@ -74,6 +82,26 @@ args: { three: {}, four: {} }
 			input:   fmt.Sprintf(`{include: %q}`, testdata("not-a-real-file.yml")),
 			wantErr: "opening included file",
 		},
+		{
+			name:  "description file",
+			input: fmt.Sprintf(`{description-file: %q}`, testdata("description.md")),
+			want: Task{
+				Description:     "Builds the project for every supported platform.\n\nArtifacts are written to the dist directory.\n",
+				DescriptionFile: testdata("description.md"),
+			},
+		},
+		{
+			name: "description file and description",
+			input: fmt.Sprintf(
+				`{description: foo, description-file: %q}`, testdata("description.md"),
+			),
+			wantErr: `tasks using "description-file" may not specify "description"`,
+		},
+		{
+			name:    "description file missing",
+			input:   fmt.Sprintf(`{description-file: %q}`, testdata("not-a-real-file.md")),
+			wantErr: "reading description file",
+		},
 		{
 			name:    "invalid",
 			input:   "[invalid]",
@@ -99,7 +127,7 @@ args: { foo: {} }
 				assert.NilError(t, err)
 			}
 
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(Option{})); diff != "" {
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(Option{}, Task{})); diff != "" {
 				t.Errorf("parsed task differs from expected:\n%s", diff)
 			}
 		})
@@ -262,6 +290,264 @@ func TestTask_run_environment(t *testing.T) {
 	}
 }
 
+func TestTask_run_unset_inherited(t *testing.T) {
+	inherited := "TUSK_TEST_INHERITED"
+	if err := os.Setenv(inherited, "inherited"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv(inherited) // nolint: errcheck
+
+	checkUnset := &Run{
+		Command: CommandList{{Exec: fmt.Sprintf(`test -z "${%s+x}"`, inherited)}},
+	}
+
+	task := Task{
+		RunList: RunList{
+			&Run{Unset: marshal.StringList{inherited}},
+			checkUnset,
+			&Run{Tasks: []Task{{Name: "sub", RunList: RunList{checkUnset}}}},
+		},
+	}
+
+	assert.NilError(t, task.Execute(RunContext{}))
+
+	_, isSet := os.LookupEnv(inherited)
+	assert.Check(t, !isSet, "want %s to be unset", inherited)
+}
+
+func TestTask_Execute_no_env_inherit(t *testing.T) {
+	ambient := "TUSK_TEST_AMBIENT"
+	if err := os.Setenv(ambient, "ambient"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv(ambient) // nolint: errcheck
+
+	explicit := "TUSK_TEST_EXPLICIT"
+	defer os.Unsetenv(explicit) // nolint: errcheck
+
+	check := fmt.Sprintf(
+		`test -z "${%s+x}" && test "$%s" = explicit && test "$PATH" = %s`,
+		ambient, explicit, minimalPath,
+	)
+
+	task := Task{
+		RunList: RunList{
+			&Run{
+				Tasks: []Task{{
+					Name: "sub",
+					RunList: RunList{&Run{
+						SetEnvironment: map[string]*string{explicit: stringPointer("explicit")},
+					}},
+				}},
+			},
+			&Run{Command: CommandList{{Exec: check}}},
+			&Run{Pipeline: CommandList{{Exec: "echo ok"}, {Exec: "cat > /dev/null && " + check}}},
+		},
+	}
+
+	meta := Metadata{NoEnvInherit: true}
+	assert.NilError(t, task.Execute(meta.RunContext()))
+
+	ambientCheck := &Run{Command: CommandList{{Exec: fmt.Sprintf(`test -n "$%s"`, ambient)}}}
+	task = Task{RunList: RunList{ambientCheck}}
+	assert.NilError(t, task.Execute(RunContext{}), "want environment inherited by default")
+}
+
+func TestTask_Execute_check_only(t *testing.T) {
+	dir := fs.NewDir(t, "check-only")
+	defer dir.Remove()
+
+	condition := filepath.Join(dir.Path(), "condition")
+	command := filepath.Join(dir.Path(), "command")
+	finally := filepath.Join(dir.Path(), "finally")
+
+	task := Task{
+		RunList: RunList{
+			&Run{
+				When:    WhenList{createWhen(withWhenCommand("touch " + condition))},
+				Command: CommandList{{Exec: "touch " + command}},
+			},
+			&Run{Pipeline: CommandList{{Exec: "echo ok"}, {Exec: "cat > " + command}}},
+		},
+		Finally: RunList{
+			&Run{Command: CommandList{{Exec: "touch " + finally}}},
+		},
+	}
+
+	assert.NilError(t, task.Execute(RunContext{CheckOnly: true}))
+
+	_, err := os.Stat(condition)
+	assert.NilError(t, err, "want condition to be evaluated")
+
+	for _, path := range []string{command, finally} {
+		_, err = os.Stat(path)
+		assert.Check(t, os.IsNotExist(err), "want %s to not be created", path)
+	}
+}
+
+func TestTask_Execute_silent_commands(t *testing.T) {
+	defer func(l *log.Logger, w io.Writer, level ui.VerbosityLevel) {
+		ui.LoggerStderr = l
+		ui.Stdout = w
+		ui.Verbosity = level
+	}(ui.LoggerStderr, ui.Stdout, ui.Verbosity)
+	ui.Verbosity = ui.VerbosityLevelNormal
+
+	var task Task
+	err := yaml.UnmarshalStrict([]byte(`
+run:
+  - "@echo silent"
+  - echo loud
+  - pipeline: ["@echo piped", "@cat"]
+`), &task)
+	assert.NilError(t, err)
+
+	var stdout, stderr bytes.Buffer
+	ui.Stdout = &stdout
+	ui.LoggerStderr = log.New(&stderr, "", 0)
+
+	assert.NilError(t, task.Execute(RunContext{}))
+
+	// A command starting with @ would fail if passed to the shell as-is
+	assert.Equal(t, stdout.String(), "silent\nloud\npiped\n")
+	assert.Check(t, strings.Contains(stderr.String(), "echo loud"))
+	assert.Check(t, !strings.Contains(stderr.String(), "silent"), "output: %s", stderr.String())
+	assert.Check(t, !strings.Contains(stderr.String(), "piped"), "output: %s", stderr.String())
+
+	stderr.Reset()
+	assert.NilError(t, task.Execute(RunContext{CheckOnly: true}))
+	assert.Check(t, strings.Contains(stderr.String(), "echo silent"), "output: %s", stderr.String())
+}
+
+func TestTask_Execute_skip(t *testing.T) {
+	tests := []struct {
+		name    string
+		branch  string
+		wantRun bool
+	}{
+		{name: "skipped", branch: "feature", wantRun: false},
+		{name: "not skipped", branch: "master", wantRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fs.NewDir(t, "skip")
+			defer dir.Remove()
+
+			command := filepath.Join(dir.Path(), "command")
+			finally := filepath.Join(dir.Path(), "finally")
+
+			task := Task{
+				Skip: WhenList{createWhen(withWhenEqual("branch", "feature"))},
+				RunList: RunList{
+					&Run{Command: CommandList{{Exec: "touch " + command}}},
+				},
+				Finally: RunList{
+					&Run{Command: CommandList{{Exec: "touch " + finally}}},
+				},
+				Vars: map[string]string{"branch": tt.branch},
+			}
+
+			assert.NilError(t, task.Execute(RunContext{}))
+
+			for _, path := range []string{command, finally} {
+				_, err := os.Stat(path)
+				if tt.wantRun {
+					assert.Check(t, err == nil, "want %s to be created", path)
+				} else {
+					assert.Check(t, os.IsNotExist(err), "want %s to not be created", path)
+				}
+			}
+		})
+	}
+}
+
+func TestTask_Execute_skip_sub_task(t *testing.T) {
+	dir := fs.NewDir(t, "skip-sub-task")
+	defer dir.Remove()
+
+	after := filepath.Join(dir.Path(), "after")
+
+	skipped := Task{
+		Skip:    WhenList{createWhen(withWhenOS(runtime.GOOS))},
+		RunList: RunList{&Run{Command: CommandList{{Exec: "exit 1"}}}},
+	}
+
+	task := Task{
+		RunList: RunList{
+			&Run{Tasks: []Task{skipped}},
+			&Run{Command: CommandList{{Exec: "touch " + after}}},
+		},
+	}
+
+	assert.NilError(t, task.Execute(RunContext{}))
+
+	_, err := os.Stat(after)
+	assert.NilError(t, err, "want commands after a skipped sub-task to run")
+}
+
+func TestTask_Dependencies_skip(t *testing.T) {
+	task := Task{
+		Skip: WhenList{createWhen(withWhenEqual("branch", "feature"))},
+	}
+
+	if diff := cmp.Diff([]string{"branch"}, task.Dependencies()); diff != "" {
+		t.Errorf("dependencies differ (-want +got):\n%s", diff)
+	}
+}
+
+func TestTask_checkBudget(t *testing.T) {
+	defer func(l *log.Logger) { ui.LoggerStderr = l }(ui.LoggerStderr)
+
+	tests := []struct {
+		name     string
+		budget   time.Duration
+		elapsed  time.Duration
+		ctx      RunContext
+		wantErr  bool
+		wantWarn bool
+	}{
+		{"no budget", 0, time.Second, RunContext{}, false, false},
+		{"within budget", time.Minute, time.Second, RunContext{}, false, false},
+		{"over budget", time.Second, time.Minute, RunContext{}, false, true},
+		{"over budget failing", time.Second, time.Minute, RunContext{FailOnBudget: true}, true, false},
+		{"check only", time.Second, time.Minute, RunContext{CheckOnly: true}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			ui.LoggerStderr = log.New(buf, "", 0)
+
+			task := Task{Name: "foo", Budget: tt.budget}
+
+			var err error
+			task.checkBudget(tt.ctx, now().Add(-tt.elapsed), &err)
+
+			if tt.wantErr != (err != nil) {
+				t.Errorf("checkBudget(): want error %t, got %v", tt.wantErr, err)
+			}
+
+			warned := strings.Contains(buf.String(), "exceeding its budget of")
+			if tt.wantWarn != warned {
+				t.Errorf("checkBudget(): want warning %t, got output %q", tt.wantWarn, buf.String())
+			}
+		})
+	}
+}
+
+func TestTask_checkBudget_existing_error(t *testing.T) {
+	task := Task{Name: "foo", Budget: time.Second}
+	ctx := RunContext{FailOnBudget: true}
+
+	err := errors.New("original")
+	task.checkBudget(ctx, now().Add(-time.Minute), &err)
+
+	if err.Error() != "original" {
+		t.Errorf("checkBudget(): want original error preserved, got %q", err)
+	}
+}
+
 func TestTask_run_finally(t *testing.T) {
 	task := Task{
 		Finally: RunList{
@@ -288,6 +574,68 @@ func TestTask_run_finally_error(t *testing.T) {
 	}
 }
 
+func TestTask_run_finally_parallel(t *testing.T) {
+	dir := fs.NewDir(t, "finally-parallel")
+	defer dir.Remove()
+
+	// Each step waits for the other to start, so they cannot run in order
+	waitFor := func(self, other string) string {
+		return fmt.Sprintf(
+			"touch %s; for i in $(seq 50); do [ -f %s ] && exit 0; sleep 0.1; done; exit 1",
+			dir.Join(self), dir.Join(other),
+		)
+	}
+
+	task := Task{
+		FinallyParallel: true,
+		Finally: RunList{
+			&Run{Command: CommandList{{Exec: waitFor("first", "second")}}},
+			&Run{Command: CommandList{{Exec: waitFor("second", "first")}}},
+		},
+	}
+
+	var err error
+	task.runFinally(RunContext{}, &err)
+	assert.NilError(t, err)
+}
+
+func TestTask_run_finally_parallel_errors(t *testing.T) {
+	dir := fs.NewDir(t, "finally-parallel-errors")
+	defer dir.Remove()
+
+	task := Task{
+		FinallyParallel: true,
+		Finally: RunList{
+			&Run{Command: CommandList{{Exec: "exit 2"}}},
+			&Run{Command: CommandList{{Exec: "touch " + dir.Join("ran")}}},
+			&Run{Command: CommandList{{Exec: "exit 3"}}},
+		},
+	}
+
+	err := errors.New("task failed")
+	task.runFinally(RunContext{}, &err)
+	assert.Error(t, err, "3 errors occurred: task failed; exit status 2; exit status 3")
+
+	_, statErr := os.Stat(dir.Join("ran"))
+	assert.NilError(t, statErr, "want all finally steps to run")
+}
+
+func TestTask_run_finally_parallel_single_error(t *testing.T) {
+	task := Task{
+		FinallyParallel: true,
+		Finally: RunList{
+			&Run{Command: CommandList{{Exec: "exit 0"}}},
+			&Run{Command: CommandList{{Exec: "exit 2"}}},
+		},
+	}
+
+	var err error
+	task.runFinally(RunContext{}, &err)
+
+	_, ok := err.(*exec.ExitError)
+	assert.Check(t, ok, "want exit error to be returned as-is, got %T", err)
+}
+
 func TestTask_run_finally_ui(t *testing.T) {
 	defer func(level ui.VerbosityLevel) {
 		ui.LoggerStderr.SetOutput(os.Stderr)
@@ -302,7 +650,7 @@ func TestTask_run_finally_ui(t *testing.T) {
 	bufExpected := new(bytes.Buffer)
 	ui.LoggerStderr.SetOutput(bufExpected)
 	ui.PrintTaskFinally(taskName)
-	ui.PrintCommandWithParenthetical(command, "finally", taskName)
+	ui.PrintCommandWithParenthetical(command, "finally", 1, taskName)
 	expected := bufExpected.String()
 
 	bufActual := new(bytes.Buffer)
@@ -348,7 +696,7 @@ func TestTask_run_finally_ui_fails(t *testing.T) {
 	bufExpected := new(bytes.Buffer)
 	ui.LoggerStderr.SetOutput(bufExpected)
 	ui.PrintTaskFinally(taskName)
-	ui.PrintCommandWithParenthetical(command, "finally", taskName)
+	ui.PrintCommandWithParenthetical(command, "finally", 1, taskName)
 	ui.PrintCommandError(errExpected)
 	expected := bufExpected.String()
 
@@ -379,3 +727,81 @@ func TestTask_run_finally_ui_fails(t *testing.T) {
 		)
 	}
 }
+
+func TestTask_Execute_fail_on_stderr(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name: "task default",
+			input: `
+fail-on-stderr: true
+run: echo warning >&2
+`,
+			wantErr: "command succeeded but wrote to stderr:\nwarning",
+		},
+		{
+			name: "step",
+			input: `
+run:
+  - command: echo warning >&2
+    fail-on-stderr: true
+`,
+			wantErr: "command succeeded but wrote to stderr:\nwarning",
+		},
+		{
+			name: "pipeline",
+			input: `
+run:
+  pipeline: [echo hello, "cat; echo warning >&2"]
+  fail-on-stderr: true
+`,
+			wantErr: "command succeeded but wrote to stderr:\nwarning",
+		},
+		{
+			name: "clean command",
+			input: `
+fail-on-stderr: true
+run: echo hello
+`,
+		},
+		{
+			name: "step overrides task",
+			input: `
+fail-on-stderr: true
+run:
+  - command: echo progress >&2
+    fail-on-stderr: false
+`,
+		},
+		{
+			name:  "not enabled",
+			input: `run: echo warning >&2`,
+		},
+		{
+			name: "failed command",
+			input: `
+fail-on-stderr: true
+run: echo warning >&2 && exit 1
+`,
+			wantErr: "exit status 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var task Task
+			assert.NilError(t, yaml.UnmarshalStrict([]byte(tt.input), &task))
+
+			err := task.Execute(RunContext{})
+			if tt.wantErr != "" {
+				assert.Error(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+		})
+	}
+}
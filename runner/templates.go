@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"io/ioutil"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/rliebz/tusk/ui"
+)
+
+// Templates are custom formats for task banners and command prefixes, written
+// as Go templates. Any template left empty uses the built-in format.
+type Templates struct {
+	TaskStarted   string `yaml:"task-started,omitempty"`
+	TaskCompleted string `yaml:"task-completed,omitempty"`
+	Command       string `yaml:"command,omitempty"`
+}
+
+// UnmarshalYAML ensures that the templates are valid.
+func (t *Templates) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type templatesType Templates // Use new type to avoid recursion
+	if err := unmarshal((*templatesType)(t)); err != nil {
+		return err
+	}
+
+	_, err := t.Parse()
+	return err
+}
+
+// Parse parses the templates for use by the ui package. Templates are also
+// executed with empty data, so that references to unknown fields are caught
+// before any tasks are run.
+func (t *Templates) Parse() (ui.Templates, error) {
+	var parsed ui.Templates
+
+	items := []struct {
+		name   string
+		text   string
+		target **template.Template
+	}{
+		{"task-started", t.TaskStarted, &parsed.TaskStarted},
+		{"task-completed", t.TaskCompleted, &parsed.TaskCompleted},
+		{"command", t.Command, &parsed.Command},
+	}
+
+	for _, item := range items {
+		if item.text == "" {
+			continue
+		}
+
+		tmpl, err := template.New(item.name).Parse(item.text)
+		if err != nil {
+			return ui.Templates{}, errors.Wrapf(err, "invalid %s template", item.name)
+		}
+
+		if err := tmpl.Execute(ioutil.Discard, ui.TemplateData{}); err != nil {
+			return ui.Templates{}, errors.Wrapf(err, "invalid %s template", item.name)
+		}
+
+		*item.target = tmpl
+	}
+
+	return parsed, nil
+}
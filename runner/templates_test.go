@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rliebz/tusk/ui"
+	"gotest.tools/v3/assert"
+)
+
+func TestParse_templates(t *testing.T) {
+	cfgText := []byte(`
+templates:
+  task-started: "==> {{.Task}}"
+  command: "[{{.Step}}] {{.Tasks}} $"
+`)
+
+	cfg, err := Parse(cfgText)
+	assert.NilError(t, err)
+
+	templates, err := cfg.Templates.Parse()
+	assert.NilError(t, err)
+	assert.Check(t, templates.TaskCompleted == nil, "want built-in format when unset")
+
+	var buf bytes.Buffer
+	err = templates.Command.Execute(&buf, ui.TemplateData{Step: 3, Tasks: "build > test"})
+	assert.NilError(t, err)
+	assert.Equal(t, buf.String(), "[3] build > test $")
+}
+
+func TestParse_templates_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfgText string
+		wantErr string
+	}{
+		{
+			"syntax",
+			`templates: {task-started: "{{.Task"}`,
+			"invalid task-started template",
+		},
+		{
+			"unknown field",
+			`templates: {command: "{{.Missing}}"}`,
+			"invalid command template",
+		},
+		{
+			"unknown template",
+			`templates: {task-skipped: "{{.Task}}"}`,
+			"task-skipped",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.cfgText))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
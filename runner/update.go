@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rliebz/tusk/ui"
+)
+
+// defaultReleaseURL is queried for the latest release of tusk.
+const defaultReleaseURL = "https://api.github.com/repos/rliebz/tusk/releases/latest"
+
+// releaseURLEnv is the environment variable used to override the release url.
+const releaseURLEnv = "TUSK_RELEASE_URL"
+
+// CheckForUpdate prints whether a newer release of tusk than the current
+// version is available. Since the check is informational, failures to reach
+// the release url are printed as warnings rather than returned.
+//
+// The release url may respond with either a GitHub release, where the version
+// is read from its tag name, or a plain text version.
+func CheckForUpdate(w io.Writer, current string) {
+	url := os.Getenv(releaseURLEnv)
+	if url == "" {
+		url = defaultReleaseURL
+	}
+
+	latest, err := fetchLatestVersion(url)
+	if err != nil {
+		ui.Warn(fmt.Sprintf("could not check for updates: %v", err))
+		return
+	}
+
+	latestVersion, err := parseVersion(latest)
+	if err != nil {
+		ui.Warn(fmt.Sprintf("could not check for updates: %v", err))
+		return
+	}
+
+	currentVersion, err := parseVersion(current)
+	switch {
+	case err != nil:
+		fmt.Fprintf(w, "tusk %s is a development build; the latest release is %s\n", current, latest)
+	case compareVersions(currentVersion, latestVersion) < 0:
+		fmt.Fprintf(w, "tusk %s is out of date; the latest release is %s\n", current, latest)
+	default:
+		fmt.Fprintf(w, "tusk %s is up to date\n", current)
+	}
+}
+
+// fetchLatestVersion gets the latest release version from a url.
+func fetchLatestVersion(url string) (string, error) {
+	v := Value{URL: url}
+	body, err := v.fetchURL()
+	if err != nil {
+		return "", err
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.Unmarshal([]byte(body), &release); err == nil && release.TagName != "" {
+		return release.TagName, nil
+	}
+
+	return body, nil
+}
@@ -0,0 +1,87 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rliebz/tusk/ui"
+	"gotest.tools/v3/assert"
+)
+
+func TestCheckForUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		current string
+		want    string
+	}{
+		{"newer", "2.1.0", "2.0.0", "tusk 2.0.0 is out of date; the latest release is 2.1.0\n"},
+		{"equal", "2.1.0", "2.1.0", "tusk 2.1.0 is up to date\n"},
+		{"older", "2.1.0", "2.2.0", "tusk 2.2.0 is up to date\n"},
+		{
+			"github release",
+			`{"tag_name": "v2.1.0", "name": "v2.1.0"}`,
+			"2.0.0",
+			"tusk 2.0.0 is out of date; the latest release is v2.1.0\n",
+		},
+		{
+			"development build",
+			"2.1.0",
+			"dev",
+			"tusk dev is a development build; the latest release is 2.1.0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, tt.body)
+			}))
+			defer server.Close()
+
+			defer setReleaseURL(t, server.URL)()
+
+			var buf bytes.Buffer
+			CheckForUpdate(&buf, tt.current)
+			assert.Equal(t, buf.String(), tt.want)
+		})
+	}
+}
+
+func TestCheckForUpdate_offline(t *testing.T) {
+	defer func(l *log.Logger) { ui.LoggerStderr = l }(ui.LoggerStderr)
+	var stderr bytes.Buffer
+	ui.LoggerStderr = log.New(&stderr, "", 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	defer setReleaseURL(t, server.URL)()
+
+	var buf bytes.Buffer
+	CheckForUpdate(&buf, "2.0.0")
+	assert.Equal(t, buf.String(), "")
+	assert.Check(t, strings.Contains(stderr.String(), "could not check for updates"))
+}
+
+// setReleaseURL sets the release url, returning a function to restore it.
+func setReleaseURL(t *testing.T, url string) func() {
+	t.Helper()
+
+	old, ok := os.LookupEnv(releaseURLEnv)
+	assert.NilError(t, os.Setenv(releaseURLEnv, url))
+
+	return func() {
+		if ok {
+			os.Setenv(releaseURLEnv, old) // nolint: errcheck
+		} else {
+			os.Unsetenv(releaseURLEnv) // nolint: errcheck
+		}
+	}
+}
@@ -0,0 +1,49 @@
+//go:build !windows
+// +build !windows
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// setUser configures a command to run as the named user.
+func setUser(cmd *exec.Cmd, username string) error {
+	if username == "" {
+		return nil
+	}
+
+	u, err := user.Lookup(username)
+	if err != nil {
+		return err
+	}
+
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return errors.Wrapf(err, "invalid uid for user %q", username)
+	}
+
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return errors.Wrapf(err, "invalid gid for user %q", username)
+	}
+
+	if euid := os.Geteuid(); euid != 0 && uint64(euid) != uid {
+		return fmt.Errorf(
+			"cannot run command as user %q: tusk must be run as root", username,
+		)
+	}
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Credential: &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)},
+	}
+
+	return nil
+}
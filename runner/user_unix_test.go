@@ -0,0 +1,54 @@
+//go:build !windows
+// +build !windows
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"os/user"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSetUser(t *testing.T) {
+	nobody, err := user.Lookup("nobody")
+	if err != nil {
+		t.Skipf("user nobody does not exist: %v", err)
+	}
+
+	cmd := exec.Command("id", "-u")
+	err = setUser(cmd, "nobody")
+
+	if os.Geteuid() != 0 {
+		assert.ErrorContains(t, err, "tusk must be run as root")
+		return
+	}
+	assert.NilError(t, err)
+
+	out, err := cmd.Output()
+	assert.NilError(t, err)
+	assert.Equal(t, strings.TrimSpace(string(out)), nobody.Uid)
+}
+
+func TestSetUser_current(t *testing.T) {
+	current, err := user.Current()
+	if err != nil {
+		t.Skipf("could not look up current user: %v", err)
+	}
+
+	cmd := exec.Command("id", "-u")
+	assert.NilError(t, setUser(cmd, current.Username))
+
+	out, err := cmd.Output()
+	assert.NilError(t, err)
+	assert.Equal(t, strings.TrimSpace(string(out)), current.Uid)
+}
+
+func TestSetUser_unknown(t *testing.T) {
+	cmd := exec.Command("id", "-u")
+	err := setUser(cmd, "tusk-user-that-does-not-exist")
+	assert.ErrorContains(t, err, "unknown user")
+}
@@ -0,0 +1,20 @@
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/rliebz/tusk/ui"
+)
+
+// setUser is not supported on Windows, so commands run as the current user.
+func setUser(_ *exec.Cmd, username string) error {
+	if username != "" {
+		ui.Warn(fmt.Sprintf(
+			"running commands as another user is not supported on windows, ignoring user %q",
+			username,
+		))
+	}
+
+	return nil
+}
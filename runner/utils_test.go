@@ -5,6 +5,7 @@ import (
 	"math/rand"
 	"os"
 	"runtime"
+	"time"
 
 	"github.com/rliebz/tusk/marshal"
 )
@@ -73,6 +74,21 @@ func withWhenCommand(command string) func(w *When) {
 	}
 }
 
+// withWhenRetry is an operator that retries command conditions.
+func withWhenRetry(attempts int, interval time.Duration) func(w *When) {
+	return func(w *When) {
+		w.Retry = &Retry{Attempts: attempts, Interval: interval}
+	}
+}
+
+// withWhenAnyOf returns an operator that passes if any group of conditions
+// passes.
+func withWhenAnyOf(groups ...WhenList) func(w *When) {
+	return func(w *When) {
+		w.AnyOf = append(w.AnyOf, groups...)
+	}
+}
+
 // withWhenCommandSuccess is an operator that includes a successful command.
 var withWhenCommandSuccess = func(w *When) {
 	w.Command = append(w.Command, "test 1 = 1")
@@ -198,3 +214,8 @@ func withWhenNotEqual(key, value string) func(w *When) {
 		w.NotEqual[key] = append(w.NotEqual[key], value)
 	}
 }
+
+// stringPointer returns a pointer to a copy of the string passed.
+func stringPointer(s string) *string {
+	return &s
+}
@@ -2,18 +2,33 @@ package runner
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"os/exec"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/rliebz/tusk/marshal"
 )
 
+// defaultURLTimeout is the timeout used when fetching a URL value if none is
+// specified.
+const defaultURLTimeout = 10 * time.Second
+
 // Value represents a value candidate for an option.
-// When the when condition is true, either the command or value will be used.
+// When the when condition is true, either the command, url, task output, or
+// value will be used.
 type Value struct {
 	When    WhenList
 	Command string
 	Value   string
+
+	URL     string            `yaml:"url,omitempty"`
+	Headers map[string]string `yaml:",omitempty"`
+	Timeout time.Duration     `yaml:",omitempty"`
+
+	FromTask *FromTask `yaml:"from-task,omitempty"`
 }
 
 // commandValueOrDefault validates a content definition, then gets the value.
@@ -27,9 +42,48 @@ func (v *Value) commandValueOrDefault() (string, error) {
 		return strings.TrimSpace(string(out)), nil
 	}
 
+	if v.URL != "" {
+		return v.fetchURL()
+	}
+
 	return v.Value, nil
 }
 
+// fetchURL gets the value from the body of a URL.
+func (v *Value) fetchURL() (string, error) {
+	req, err := http.NewRequest(http.MethodGet, v.URL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	for key, value := range v.Headers {
+		req.Header.Set(key, value)
+	}
+
+	timeout := v.Timeout
+	if timeout == 0 {
+		timeout = defaultURLTimeout
+	}
+
+	client := http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("GET %s: unexpected status %s", v.URL, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
+
 // UnmarshalYAML allows plain strings to represent a full struct. The value of
 // the string is used as the Default field.
 func (v *Value) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -52,6 +106,25 @@ func (v *Value) UnmarshalYAML(unmarshal func(interface{}) error) error {
 				)
 			}
 
+			if valueItem.URL != "" && (valueItem.Value != "" || valueItem.Command != "") {
+				return fmt.Errorf(
+					"url (%s) cannot be defined with a value or command",
+					valueItem.URL,
+				)
+			}
+
+			if valueItem.FromTask != nil &&
+				(valueItem.Value != "" || valueItem.Command != "" || valueItem.URL != "") {
+				return fmt.Errorf(
+					"from-task (%s) cannot be defined with a value, command, or url",
+					valueItem.FromTask.Task,
+				)
+			}
+
+			if valueItem.URL == "" && (len(valueItem.Headers) != 0 || valueItem.Timeout != 0) {
+				return fmt.Errorf("headers and timeout can only be defined with a url")
+			}
+
 			return nil
 		},
 	}
@@ -76,7 +149,83 @@ func (vl *ValueList) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		Assign:    func() { *vl = ValueList{valueItem} },
 	}
 
-	return marshal.UnmarshalOneOf(sliceCandidate, itemCandidate)
+	var osMap map[string]Value
+	var osList ValueList
+	osCandidate := marshal.UnmarshalCandidate{
+		Unmarshal: func() error { return unmarshal(&osMap) },
+		Validate: func() (err error) {
+			osList, err = newOSValueList(osMap)
+			return err
+		},
+		Assign: func() { *vl = osList },
+	}
+
+	return marshal.UnmarshalOneOf(sliceCandidate, itemCandidate, osCandidate)
+}
+
+// otherOS is the key used for the value on any OS not listed.
+const otherOS = "other"
+
+// knownOS are the operating systems that can be used as keys in a map of
+// values by OS.
+var knownOS = []string{
+	"aix", "android", "darwin", "dragonfly", "freebsd", "illumos", "ios",
+	"js", "linux", "netbsd", "openbsd", "plan9", "solaris", "windows",
+}
+
+// newOSValueList creates a list of values from a map of values by OS, where
+// each value is used only on its OS. The value for other is used last, on any
+// OS not listed.
+func newOSValueList(values map[string]Value) (ValueList, error) {
+	names := make([]string, 0, len(values))
+	byOS := make(map[string]Value, len(values))
+	for name, value := range values {
+		if name == otherOS {
+			continue
+		}
+
+		normalized := normalizeOS(name)
+		if !isKnownOS(normalized) {
+			return nil, fmt.Errorf("unknown OS %q, must be one of %v or %q", name, knownOS, otherOS)
+		}
+		if _, ok := byOS[normalized]; ok {
+			return nil, fmt.Errorf("value for OS %q is defined more than once", normalized)
+		}
+		if len(value.When) != 0 {
+			return nil, fmt.Errorf("value for OS %q cannot define a when clause", normalized)
+		}
+
+		names = append(names, normalized)
+		byOS[normalized] = value
+	}
+	sort.Strings(names)
+
+	vl := make(ValueList, 0, len(values))
+	for _, name := range names {
+		value := byOS[name]
+		value.When = WhenList{{OS: marshal.StringList{name}}}
+		vl = append(vl, value)
+	}
+
+	if value, ok := values[otherOS]; ok {
+		if len(value.When) != 0 {
+			return nil, fmt.Errorf("value for %q cannot define a when clause", otherOS)
+		}
+
+		vl = append(vl, value)
+	}
+
+	return vl, nil
+}
+
+func isKnownOS(name string) bool {
+	for _, known := range knownOS {
+		if name == known {
+			return true
+		}
+	}
+
+	return false
 }
 
 // ValueWithList is a list of allowable values for an option or argument.
@@ -1,10 +1,17 @@
 package runner
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/rliebz/tusk/marshal"
 	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
 )
 
 func TestValue_UnmarshalYAML(t *testing.T) {
@@ -47,6 +54,74 @@ func TestValue_UnmarshalYAML_value_and_command(t *testing.T) {
 	}
 }
 
+func TestValue_UnmarshalYAML_url(t *testing.T) {
+	s := []byte(`{url: "http://example.com", headers: {X-Foo: bar}, timeout: 5s}`)
+	v := Value{}
+
+	assert.NilError(t, yaml.UnmarshalStrict(s, &v))
+	assert.DeepEqual(t, v, Value{
+		URL:     "http://example.com",
+		Headers: map[string]string{"X-Foo": "bar"},
+		Timeout: 5 * time.Second,
+	})
+}
+
+func TestValue_UnmarshalYAML_url_invalid(t *testing.T) {
+	tests := []string{
+		`{url: "http://example.com", value: "example"}`,
+		`{url: "http://example.com", command: "echo hello"}`,
+		`{value: "example", timeout: 5s}`,
+		`{command: "echo hello", headers: {X-Foo: bar}}`,
+	}
+
+	for _, s := range tests {
+		t.Run(s, func(t *testing.T) {
+			v := Value{}
+			err := yaml.UnmarshalStrict([]byte(s), &v)
+			assert.Check(t, err != nil, "want error for %s", s)
+		})
+	}
+}
+
+func TestValue_commandValueOrDefault_url(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "  %s\n", r.Header.Get("X-Foo"))
+	}))
+	defer server.Close()
+
+	v := Value{URL: server.URL, Headers: map[string]string{"X-Foo": "bar"}}
+
+	value, err := v.commandValueOrDefault()
+	assert.NilError(t, err)
+	assert.Equal(t, value, "bar")
+}
+
+func TestValue_commandValueOrDefault_url_error_status(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	v := Value{URL: server.URL}
+
+	_, err := v.commandValueOrDefault()
+	assert.ErrorContains(t, err, "unexpected status 404 Not Found")
+}
+
+func TestValue_commandValueOrDefault_url_timeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	v := Value{URL: server.URL, Timeout: 10 * time.Millisecond}
+
+	_, err := v.commandValueOrDefault()
+	assert.Check(t, err != nil && strings.Contains(err.Error(), "Timeout"), "got %v", err)
+}
+
 func TestValueList_UnmarshalYAML(t *testing.T) {
 	s1 := []byte(`example`)
 	s2 := []byte(`[example]`)
@@ -82,3 +157,62 @@ func TestValueList_UnmarshalYAML(t *testing.T) {
 		)
 	}
 }
+
+func TestValueList_UnmarshalYAML_by_os(t *testing.T) {
+	var vl ValueList
+	input := `{linux: /usr/bin, mac: {command: echo /opt}, other: /bin}`
+	assert.NilError(t, yaml.UnmarshalStrict([]byte(input), &vl))
+
+	want := ValueList{
+		{Command: "echo /opt", When: WhenList{{OS: marshal.StringList{"darwin"}}}},
+		{Value: "/usr/bin", When: WhenList{{OS: marshal.StringList{"linux"}}}},
+		{Value: "/bin"},
+	}
+	assert.DeepEqual(t, want, vl)
+}
+
+func TestValueList_UnmarshalYAML_by_os_invalid(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{`{linux: a, beos: b}`, `unknown OS "beos"`},
+		{`{mac: a, darwin: b}`, `value for OS "darwin" is defined more than once`},
+		{`{linux: {value: a, when: {os: linux}}}`, `value for OS "linux" cannot define a when clause`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var vl ValueList
+			err := yaml.UnmarshalStrict([]byte(tt.input), &vl)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestOption_Evaluate_default_by_os(t *testing.T) {
+	defer func(value string) { goos = value }(goos)
+
+	input := `{default: {linux: /usr/bin, darwin: /opt, other: /bin}}`
+
+	tests := []struct {
+		os   string
+		want string
+	}{
+		{"linux", "/usr/bin"},
+		{"darwin", "/opt"},
+		{"windows", "/bin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.os, func(t *testing.T) {
+			var option Option
+			assert.NilError(t, yaml.UnmarshalStrict([]byte(input), &option))
+
+			goos = tt.os
+			got, err := option.Evaluate(nil)
+			assert.NilError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
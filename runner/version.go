@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// checkVersion returns an error if the version of tusk running is older than
+// the minimum version required by the config. Development builds, which do not
+// have a release version, are always allowed.
+func (c *Config) checkVersion(version string) error {
+	if c.MinTuskVersion == "" {
+		return nil
+	}
+
+	required, err := parseVersion(c.MinTuskVersion)
+	if err != nil {
+		return err
+	}
+
+	current, err := parseVersion(version)
+	if err != nil {
+		return nil
+	}
+
+	if compareVersions(current, required) < 0 {
+		return fmt.Errorf(
+			"this config requires tusk %s or later, but the current version is %s; "+
+				"upgrade tusk, or use --ignore-version to run anyway",
+			c.MinTuskVersion, version,
+		)
+	}
+
+	return nil
+}
+
+// compareVersions returns -1 if a is older than b, 1 if a is newer than b, and
+// 0 if they are the same.
+func compareVersions(a, b [3]int) int {
+	for i := range a {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// parseVersion parses a version such as 1.2.3 into its major, minor, and patch
+// numbers. Missing minor and patch numbers are treated as zero, and any
+// pre-release or build suffix is ignored.
+func parseVersion(version string) ([3]int, error) {
+	var parsed [3]int
+
+	text := strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(text, "-+"); i >= 0 {
+		text = text[:i]
+	}
+
+	parts := strings.Split(text, ".")
+	if len(parts) > len(parsed) {
+		return parsed, fmt.Errorf("invalid version %q", version)
+	}
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return parsed, fmt.Errorf("invalid version %q", version)
+		}
+
+		parsed[i] = n
+	}
+
+	return parsed, nil
+}
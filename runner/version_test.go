@@ -0,0 +1,75 @@
+package runner
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/rliebz/tusk/ui"
+	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestConfig_checkVersion(t *testing.T) {
+	tests := []struct {
+		required string
+		current  string
+		wantErr  bool
+	}{
+		{"", "1.0.0", false},
+		{"2.1.0", "2.1.0", false},
+		{"2.1.0", "2.1.1", false},
+		{"2.1.0", "v2.10.0", false},
+		{"2.1.0", "3.0.0", false},
+		{"2.1", "2.1.0", false},
+		{"2.1.0", "2.0.9", true},
+		{"2.1.0", "1.9.0", true},
+		{"2.1.0", "2.1.0-rc1", false},
+		{"2.1.0", "dev", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.required+" "+tt.current, func(t *testing.T) {
+			cfg := Config{MinTuskVersion: tt.required}
+			err := cfg.checkVersion(tt.current)
+			if tt.wantErr != (err != nil) {
+				t.Errorf("checkVersion(%q): want error %t, got %v", tt.current, tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConfig_UnmarshalYAML_invalid_version(t *testing.T) {
+	var cfg Config
+	err := yaml.UnmarshalStrict([]byte(`min-tusk-version: latest`), &cfg)
+	assert.ErrorContains(t, err, `min-tusk-version: invalid version "latest"`)
+}
+
+func TestParseComplete_min_tusk_version(t *testing.T) {
+	defer func(l *log.Logger) { ui.LoggerStderr = l }(ui.LoggerStderr)
+
+	cfgText := []byte(`
+min-tusk-version: 2.1.0
+tasks:
+  mytask:
+    run: echo hello
+`)
+
+	meta := &Metadata{CfgText: cfgText, Version: "2.1.0"}
+	_, err := ParseComplete(meta, "mytask", []string{}, map[string]string{})
+	assert.NilError(t, err)
+
+	meta = &Metadata{CfgText: cfgText, Version: "2.0.0"}
+	_, err = ParseComplete(meta, "mytask", []string{}, map[string]string{})
+	assert.ErrorContains(t, err, "this config requires tusk 2.1.0 or later")
+
+	buf := new(bytes.Buffer)
+	ui.LoggerStderr = log.New(buf, "", 0)
+
+	meta = &Metadata{CfgText: cfgText, Version: "2.0.0", IgnoreVersion: true}
+	cfg, err := ParseComplete(meta, "mytask", []string{}, map[string]string{})
+	assert.NilError(t, err)
+	assert.Equal(t, cfg.Tasks["mytask"].RunList[0].Command[0].Exec, "echo hello")
+	assert.Check(t, strings.Contains(buf.String(), "this config requires tusk 2.1.0 or later"))
+}
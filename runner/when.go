@@ -4,13 +4,19 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"regexp"
 	"runtime"
 	"strings"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/rliebz/tusk/marshal"
 	yaml "gopkg.in/yaml.v2"
 )
 
+// goos allows overwriting during tests.
+var goos = runtime.GOOS
+
 // When defines the conditions for running a task.
 type When struct {
 	Command   marshal.StringList `yaml:",omitempty"`
@@ -18,9 +24,71 @@ type When struct {
 	NotExists marshal.StringList `yaml:"not-exists,omitempty"`
 	OS        marshal.StringList `yaml:",omitempty"`
 
+	CI         *bool              `yaml:"ci,omitempty"`
+	CIProvider marshal.StringList `yaml:"ci-provider,omitempty"`
+
 	Environment map[string]marshal.NullableStringList `yaml:",omitempty"`
 	Equal       map[string]marshal.StringList         `yaml:",omitempty"`
 	NotEqual    map[string]marshal.StringList         `yaml:"not-equal,omitempty"`
+	OptionSet   marshal.StringList                    `yaml:"option-set,omitempty"`
+
+	Schedule *Schedule `yaml:",omitempty"`
+
+	Retry *Retry `yaml:",omitempty"`
+
+	AnyOf []WhenList `yaml:"any-of,omitempty"`
+
+	// Computed members not specified in yaml file
+	Specified map[string]bool `yaml:"-"`
+}
+
+// Retry defines how command conditions are polled before they are considered
+// to have failed.
+type Retry struct {
+	Attempts int
+	Interval time.Duration `yaml:",omitempty"`
+
+	OnExitCodes     []int  `yaml:"on-exit-codes,omitempty"`
+	OnStderrMatches string `yaml:"on-stderr-matches,omitempty"`
+}
+
+// validate checks that the retry settings can be used.
+func (r *Retry) validate() error {
+	if r.Attempts < 1 {
+		return fmt.Errorf("retry attempts must be at least 1, got %d", r.Attempts)
+	}
+
+	if _, err := regexp.Compile(r.OnStderrMatches); err != nil {
+		return errors.Wrapf(err, "invalid on-stderr-matches pattern %q", r.OnStderrMatches)
+	}
+
+	return nil
+}
+
+// shouldRetry returns whether a command that failed with the given error may
+// succeed on another attempt. If no exit codes or patterns are set, all
+// failures are retried. Otherwise, a failure is retried if either matches.
+func (r *Retry) shouldRetry(err error) bool {
+	if len(r.OnExitCodes) == 0 && r.OnStderrMatches == "" {
+		return true
+	}
+
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return false
+	}
+
+	for _, code := range r.OnExitCodes {
+		if exitErr.ExitCode() == code {
+			return true
+		}
+	}
+
+	if r.OnStderrMatches == "" {
+		return false
+	}
+
+	return regexp.MustCompile(r.OnStderrMatches).Match(exitErr.Stderr)
 }
 
 // UnmarshalYAML warns about deprecated features.
@@ -52,6 +120,29 @@ func (w *When) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 			return nil
 		},
+		Validate: func() error {
+			if whenItem.Schedule != nil {
+				if err := whenItem.Schedule.validate(); err != nil {
+					return err
+				}
+			}
+
+			for _, provider := range whenItem.CIProvider {
+				if err := validateCIProviderName(provider); err != nil {
+					return err
+				}
+			}
+
+			if whenItem.Retry == nil {
+				return nil
+			}
+
+			if len(whenItem.Command) == 0 {
+				return errors.New("retry can only be used with a command condition")
+			}
+
+			return whenItem.Retry.validate()
+		},
 		Assign: func() {
 			*w = When(whenItem)
 			fixNilEnvironment(w, ms)
@@ -96,6 +187,14 @@ func (w *When) Dependencies() []string {
 	for opt := range w.NotEqual {
 		references[opt] = struct{}{}
 	}
+	for _, opt := range w.OptionSet {
+		references[opt] = struct{}{}
+	}
+	for i := range w.AnyOf {
+		for _, opt := range w.AnyOf[i].Dependencies() {
+			references[opt] = struct{}{}
+		}
+	}
 
 	options := make([]string, 0, len(references))
 	for opt := range references {
@@ -113,15 +212,38 @@ func (w *When) Validate(vars map[string]string) error {
 
 	return validateAny(
 		w.validateOS(),
+		w.validateCI(),
+		w.validateCIProvider(),
 		w.validateEqual(vars),
 		w.validateNotEqual(vars),
+		w.validateOptionSet(),
 		w.validateEnv(),
 		w.validateExists(),
 		w.validateNotExists(),
+		w.validateSchedule(),
+		w.validateAnyOf(vars),
 		w.validateCommand(),
 	)
 }
 
+// usesOptionSet returns whether an option-set check is used, including in
+// any nested conditions.
+func (w *When) usesOptionSet() bool {
+	if len(w.OptionSet) != 0 {
+		return true
+	}
+
+	for _, group := range w.AnyOf {
+		for i := range group {
+			if group[i].usesOptionSet() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // TODO: Should this be done in parallel?
 func validateAny(errs ...error) error {
 	var errOutput error
@@ -143,15 +265,54 @@ func (w *When) validateCommand() error {
 		return newUnspecifiedError("command")
 	}
 
-	for _, command := range w.Command {
-		if err := testCommand(command); err == nil {
-			return nil
+	attempts := 1
+	var interval time.Duration
+	if w.Retry != nil {
+		attempts = w.Retry.Attempts
+		interval = w.Retry.Interval
+	}
+
+	for attempt := 1; ; attempt++ {
+		retryable := false
+		for _, command := range w.Command {
+			err := testCommand(command)
+			if err == nil {
+				return nil
+			}
+
+			if w.Retry != nil && w.Retry.shouldRetry(err) {
+				retryable = true
+			}
+		}
+
+		if attempt >= attempts {
+			break
 		}
+
+		if !retryable {
+			return newCondFailErrorf(
+				"no commands exited successfully, and no failures could be retried",
+			)
+		}
+
+		time.Sleep(interval)
+	}
+
+	if attempts > 1 {
+		return newCondFailErrorf("no commands exited successfully after %d attempts", attempts)
 	}
 
 	return newCondFailErrorf("no commands exited successfully")
 }
 
+func (w *When) validateSchedule() error {
+	if w.Schedule == nil {
+		return newUnspecifiedError("schedule")
+	}
+
+	return w.Schedule.matches(now())
+}
+
 func (w *When) validateExists() error {
 	if len(w.Exists) == 0 {
 		return newUnspecifiedError("exists")
@@ -194,13 +355,45 @@ func (w *When) validateOS() error {
 	}
 
 	return validateOneOf(
-		"current OS", runtime.GOOS, w.OS,
+		"current OS", goos, w.OS,
 		func(expected, actual string) bool {
 			return normalizeOS(expected) == actual
 		},
 	)
 }
 
+func (w *When) validateCI() error {
+	if w.CI == nil {
+		return newUnspecifiedError("ci")
+	}
+
+	if _, inCI := detectCI(); inCI != *w.CI {
+		if inCI {
+			return newCondFailError("running in CI")
+		}
+
+		return newCondFailError("not running in CI")
+	}
+
+	return nil
+}
+
+func (w *When) validateCIProvider() error {
+	if len(w.CIProvider) == 0 {
+		return newUnspecifiedError("ci-provider")
+	}
+
+	provider, _ := detectCI()
+	if provider == "" {
+		return newCondFailErrorf("no CI provider detected, want one of %v", w.CIProvider)
+	}
+
+	return validateOneOf(
+		"CI provider", provider, w.CIProvider,
+		func(a, b string) bool { return a == b },
+	)
+}
+
 func (w *When) validateEnv() error {
 	if len(w.Environment) == 0 {
 		return newUnspecifiedError("env")
@@ -258,6 +451,40 @@ func (w *When) validateNotEqual(vars map[string]string) error {
 	})
 }
 
+func (w *When) validateAnyOf(vars map[string]string) error {
+	if len(w.AnyOf) == 0 {
+		return newUnspecifiedError("any-of")
+	}
+
+	var errOutput error
+	for i := range w.AnyOf {
+		err := w.AnyOf[i].Validate(vars)
+		if err == nil {
+			return nil
+		}
+
+		if errOutput == nil {
+			errOutput = err
+		}
+	}
+
+	return errOutput
+}
+
+func (w *When) validateOptionSet() error {
+	if len(w.OptionSet) == 0 {
+		return newUnspecifiedError("option-set")
+	}
+
+	for _, name := range w.OptionSet {
+		if w.Specified[name] {
+			return nil
+		}
+	}
+
+	return newCondFailErrorf("none of the options %v were set", w.OptionSet)
+}
+
 func validateOneOf(
 	desc, value string, required []string, compare func(string, string) bool,
 ) error {
@@ -352,6 +579,17 @@ func (l *WhenList) Validate(vars map[string]string) error {
 	return nil
 }
 
+// setSpecified records which options were passed explicitly, including for
+// any nested conditions.
+func (l WhenList) setSpecified(specified map[string]bool) {
+	for i := range l {
+		l[i].Specified = specified
+		for _, group := range l[i].AnyOf {
+			group.setSpecified(specified)
+		}
+	}
+}
+
 // Dependencies returns a list of options that are required explicitly.
 // This does not include interpolations.
 func (l *WhenList) Dependencies() []string {
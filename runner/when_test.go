@@ -1,10 +1,19 @@
 package runner
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/rliebz/tusk/marshal"
 	yaml "gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
 )
 
 var unmarshalTests = []struct {
@@ -46,6 +55,37 @@ var unmarshalTests = []struct {
 			withWhenEnv("foo", "b"),
 		),
 	},
+	{
+		"command with retry",
+		`{command: "true", retry: {attempts: 3, interval: 1s}}`,
+		createWhen(
+			withWhenCommand("true"),
+			withWhenRetry(3, time.Second),
+		),
+	},
+	{
+		"any-of groups",
+		`{os: linux, any-of: [{equal: {foo: a}}, [{equal: {foo: b}}, {os: darwin}]]}`,
+		When{
+			OS: marshal.StringList{"linux"},
+			AnyOf: []WhenList{
+				{createWhen(withWhenEqual("foo", "a"))},
+				{createWhen(withWhenEqual("foo", "b")), createWhen(withWhenOS("darwin"))},
+			},
+		},
+	},
+	{
+		"command with conditional retry",
+		`{command: "true", retry: {attempts: 3, on-exit-codes: [75], on-stderr-matches: timeout}}`,
+		When{
+			Command: marshal.StringList{"true"},
+			Retry: &Retry{
+				Attempts:        3,
+				OnExitCodes:     []int{75},
+				OnStderrMatches: "timeout",
+			},
+		},
+	},
 }
 
 func TestWhen_UnmarshalYAML(t *testing.T) {
@@ -65,6 +105,22 @@ func TestWhen_UnmarshalYAML(t *testing.T) {
 	}
 }
 
+func TestWhen_UnmarshalYAML_invalid_retry(t *testing.T) {
+	tests := []string{
+		`{os: linux, retry: {attempts: 3}}`,
+		`{command: "true", retry: {attempts: 0}}`,
+		`{command: "true", retry: {interval: 1s}}`,
+		`{command: "true", retry: {attempts: 3, on-stderr-matches: "("}}`,
+	}
+
+	for _, input := range tests {
+		w := When{}
+		if err := yaml.UnmarshalStrict([]byte(input), &w); err == nil {
+			t.Errorf("Unmarshaling %s: expected error, got nil", input)
+		}
+	}
+}
+
 var whenDepTests = []struct {
 	when     When
 	expected []string
@@ -100,6 +156,20 @@ var whenDepTests = []struct {
 		createWhen(withWhenEqual("foo", "true"), withWhenNotEqual("bar", "true")),
 		[]string{"foo", "bar"},
 	},
+
+	// AnyOf
+	{
+		createWhen(
+			withWhenEqual("foo", "true"),
+			withWhenAnyOf(
+				WhenList{createWhen(withWhenEqual("bar", "true"))},
+				WhenList{createWhen(withWhenAnyOf(
+					WhenList{createWhen(withWhenNotEqual("baz", "true"))},
+				))},
+			),
+		),
+		[]string{"foo", "bar", "baz"},
+	},
 }
 
 func TestWhen_Dependencies(t *testing.T) {
@@ -350,6 +420,89 @@ var whenValidateTests = []struct {
 		map[string]string{"foo": "true"},
 		false,
 	},
+	{
+		When{OptionSet: marshal.StringList{"foo"}, Specified: map[string]bool{"foo": true}},
+		map[string]string{"foo": "true"},
+		false,
+	},
+	{
+		When{OptionSet: marshal.StringList{"foo", "bar"}, Specified: map[string]bool{"bar": true}},
+		nil,
+		false,
+	},
+	{
+		When{OptionSet: marshal.StringList{"foo"}},
+		map[string]string{"foo": "true"},
+		true,
+	},
+
+	// Any Of Clauses
+	{
+		createWhen(withWhenAnyOf(
+			WhenList{createWhen(withWhenEqual("foo", "a"))},
+			WhenList{createWhen(withWhenEqual("foo", "b"))},
+		)),
+		map[string]string{"foo": "b"},
+		false,
+	},
+	{
+		createWhen(withWhenAnyOf(
+			WhenList{createWhen(withWhenEqual("foo", "a"))},
+			WhenList{createWhen(withWhenEqual("foo", "b"))},
+		)),
+		map[string]string{"foo": "c"},
+		true,
+	},
+	{
+		createWhen(withWhenAnyOf(
+			WhenList{createWhen(withWhenEqual("foo", "a")), createWhen(withWhenEqual("bar", "b"))},
+			WhenList{createWhen(withWhenEqual("foo", "c"))},
+		)),
+		map[string]string{"foo": "a", "bar": "b"},
+		false,
+	},
+	{
+		createWhen(withWhenAnyOf(
+			WhenList{createWhen(withWhenEqual("foo", "a")), createWhen(withWhenEqual("bar", "b"))},
+			WhenList{createWhen(withWhenEqual("foo", "c"))},
+		)),
+		map[string]string{"foo": "a", "bar": "c"},
+		true,
+	},
+	{
+		createWhen(withWhenAnyOf(
+			WhenList{
+				createWhen(withWhenEqual("foo", "a")),
+				createWhen(withWhenAnyOf(
+					WhenList{createWhen(withWhenEqual("bar", "b"))},
+					WhenList{createWhen(withWhenEqual("baz", "c"))},
+				)),
+			},
+		)),
+		map[string]string{"foo": "a", "bar": "x", "baz": "c"},
+		false,
+	},
+	{
+		createWhen(withWhenAnyOf(
+			WhenList{
+				createWhen(withWhenEqual("foo", "a")),
+				createWhen(withWhenAnyOf(
+					WhenList{createWhen(withWhenEqual("bar", "b"))},
+					WhenList{createWhen(withWhenEqual("baz", "c"))},
+				)),
+			},
+		)),
+		map[string]string{"foo": "a", "bar": "x", "baz": "x"},
+		true,
+	},
+	{
+		createWhen(
+			withWhenOSFailure,
+			withWhenAnyOf(WhenList{createWhen(withWhenEqual("foo", "a"))}),
+		),
+		map[string]string{"foo": "a"},
+		false,
+	},
 }
 
 func TestWhen_Validate(t *testing.T) {
@@ -365,6 +518,117 @@ func TestWhen_Validate(t *testing.T) {
 	}
 }
 
+func TestWhen_Validate_retry(t *testing.T) {
+	dir := fs.NewDir(t, "retry")
+	defer dir.Remove()
+
+	counter := filepath.Join(dir.Path(), "counter")
+	command := fmt.Sprintf(`echo >> %[1]s; test "$(wc -l < %[1]s)" -ge 3`, counter)
+
+	tests := []struct {
+		desc      string
+		attempts  int
+		shouldErr bool
+	}{
+		{"too few attempts", 2, true},
+		{"enough attempts", 3, false},
+	}
+
+	for _, tt := range tests {
+		if err := os.RemoveAll(counter); err != nil {
+			t.Fatal(err)
+		}
+
+		w := createWhen(
+			withWhenCommand(command),
+			withWhenRetry(tt.attempts, time.Millisecond),
+		)
+
+		err := w.Validate(nil)
+		if didErr := err != nil; tt.shouldErr != didErr {
+			t.Errorf(
+				"%s: expected error: %t, got error: '%s'",
+				tt.desc, tt.shouldErr, err,
+			)
+		}
+	}
+}
+
+func TestWhen_Validate_retry_conditional(t *testing.T) {
+	dir := fs.NewDir(t, "retry")
+	defer dir.Remove()
+
+	counter := filepath.Join(dir.Path(), "counter")
+	command := fmt.Sprintf(
+		`echo >> %[1]s; test "$(wc -l < %[1]s)" -ge 3 && exit 0; echo "$MSG" >&2; exit 7`,
+		counter,
+	)
+
+	tests := []struct {
+		desc      string
+		message   string
+		retry     Retry
+		shouldErr bool
+	}{
+		{
+			"matching stderr",
+			"connection timeout",
+			Retry{Attempts: 3, OnStderrMatches: "time(d )?out"},
+			false,
+		},
+		{
+			"non-matching stderr",
+			"permission denied",
+			Retry{Attempts: 3, OnStderrMatches: "time(d )?out"},
+			true,
+		},
+		{
+			"matching exit code",
+			"permission denied",
+			Retry{Attempts: 3, OnExitCodes: []int{7}},
+			false,
+		},
+		{
+			"non-matching exit code",
+			"permission denied",
+			Retry{Attempts: 3, OnExitCodes: []int{1}},
+			true,
+		},
+		{
+			"either matching",
+			"permission denied",
+			Retry{Attempts: 3, OnExitCodes: []int{7}, OnStderrMatches: "timeout"},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.NilError(t, os.RemoveAll(counter))
+			defer os.Unsetenv("MSG")
+			assert.NilError(t, os.Setenv("MSG", tt.message))
+
+			retry := tt.retry
+			w := createWhen(withWhenCommand(command))
+			w.Retry = &retry
+
+			err := w.Validate(nil)
+			if didErr := err != nil; tt.shouldErr != didErr {
+				t.Errorf("expected error: %t, got error: '%s'", tt.shouldErr, err)
+			}
+
+			contents, err := ioutil.ReadFile(counter)
+			assert.NilError(t, err)
+
+			wantAttempts := 3
+			if tt.shouldErr {
+				wantAttempts = 1
+			}
+			assert.Equal(t, strings.Count(string(contents), "\n"), wantAttempts)
+		})
+	}
+}
+
 var normalizetests = []struct {
 	input    string
 	expected string
@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"sync"
+)
+
+var (
+	// Stdout is where command output is written.
+	Stdout io.Writer = os.Stdout
+
+	// Stderr is where command errors are written.
+	Stderr io.Writer = os.Stderr
+)
+
+// ansiPattern matches color codes and the escape sequences around hyperlinks.
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]|\x1b\]8;[^\x1b\a]*(\x1b\\|\a)`)
+
+// Capture copies all output, including that of commands, to w with any color
+// codes removed. The function returned stops capturing output.
+func Capture(w io.Writer) (stop func()) {
+	stdout, stderr := Stdout, Stderr
+	loggerStdout, loggerStderr := LoggerStdout.Writer(), LoggerStderr.Writer()
+
+	capture := &captureWriter{w: w}
+	Stdout = io.MultiWriter(stdout, capture)
+	Stderr = io.MultiWriter(stderr, capture)
+	LoggerStdout.SetOutput(io.MultiWriter(loggerStdout, capture))
+	LoggerStderr.SetOutput(io.MultiWriter(loggerStderr, capture))
+
+	return func() {
+		Stdout, Stderr = stdout, stderr
+		LoggerStdout.SetOutput(loggerStdout)
+		LoggerStderr.SetOutput(loggerStderr)
+	}
+}
+
+// captureWriter strips color codes from output written concurrently.
+type captureWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (c *captureWriter) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := c.w.Write(ansiPattern.ReplaceAll(p, nil)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestCapture(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	defer func(w io.Writer) { LoggerStderr.SetOutput(w) }(LoggerStderr.Writer())
+	defer func(w io.Writer) { Stdout = w }(Stdout)
+
+	var stderr, stdout, captured bytes.Buffer
+	LoggerStderr.SetOutput(&stderr)
+	Stdout = &stdout
+
+	stop := Capture(&captured)
+	PrintCommand("echo hello", 1, "greet")
+	fmt.Fprintln(Stdout, "hello")
+	stop()
+
+	PrintCommand("echo after", 1, "greet")
+	fmt.Fprintln(Stdout, "after")
+
+	if !bytes.Contains(stderr.Bytes(), []byte("\x1b[")) {
+		t.Errorf("want colored output on stderr, got %q", stderr.String())
+	}
+
+	if want := "hello\nafter\n"; stdout.String() != want {
+		t.Errorf("want stdout %q, got %q", want, stdout.String())
+	}
+
+	if want := "greet $ echo hello\nhello\n"; captured.String() != want {
+		t.Errorf("want captured output %q, got %q", want, captured.String())
+	}
+}
+
+func TestCapture_hyperlinks(t *testing.T) {
+	defer func(w io.Writer) { Stdout = w }(Stdout)
+	Stdout = ioutil.Discard
+
+	var captured bytes.Buffer
+	stop := Capture(&captured)
+	fmt.Fprintln(Stdout, "see \x1b]8;;file:///tmp/out.log\x1b\\out.log\x1b]8;;\x1b\\")
+	stop()
+
+	if want := "see out.log\n"; captured.String() != want {
+		t.Errorf("want captured output %q, got %q", want, captured.String())
+	}
+}
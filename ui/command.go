@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 )
 
 const (
@@ -11,21 +12,35 @@ const (
 	promptCharacter    = "$"
 
 	completedString        = "Completed"
+	failedString           = "failed"
+	outputString           = "Output"
+	passedString           = "passed"
 	environmentString      = "Setting Environment"
+	commandEnvString       = "Environment"
 	finallyString          = "Finally"
 	startedString          = "Started"
 	setEnvironmentString   = "set"
 	skippedString          = "Skipping"
+	skippedStepString      = "skipped"
+	stderrString           = "Stderr"
+	summaryString          = "Summary"
 	taskString             = "Task"
 	unsetEnvironmentString = "unset"
 )
 
 // PrintCommand prints the command to be executed.
-func PrintCommand(command string, namespaces ...string) {
+func PrintCommand(command string, step int, namespaces ...string) {
 	if Verbosity <= VerbosityLevelQuiet {
 		return
 	}
 
+	data := newTemplateData(namespaces)
+	data.Step = step
+	if prefix, ok := executeTemplate(CustomTemplates.Command, data); ok {
+		printf(LoggerStderr, "%s %s", prefix, bold(command))
+		return
+	}
+
 	for i, ns := range namespaces {
 		namespaces[i] = green(ns)
 	}
@@ -42,11 +57,21 @@ func PrintCommand(command string, namespaces ...string) {
 }
 
 // PrintCommandWithParenthetical prints a command with additional information.
-func PrintCommandWithParenthetical(command, parenthetical string, namespaces ...string) {
+func PrintCommandWithParenthetical(
+	command, parenthetical string, step int, namespaces ...string,
+) {
 	if Verbosity <= VerbosityLevelQuiet {
 		return
 	}
 
+	data := newTemplateData(namespaces)
+	data.Step = step
+	data.Parenthetical = parenthetical
+	if prefix, ok := executeTemplate(CustomTemplates.Command, data); ok {
+		printf(LoggerStderr, "%s %s", prefix, bold(command))
+		return
+	}
+
 	for i, ns := range namespaces {
 		namespaces[i] = green(ns)
 	}
@@ -148,6 +173,12 @@ func PrintTask(taskName string) {
 		return
 	}
 
+	data := newTemplateData([]string{taskName})
+	if line, ok := executeTemplate(CustomTemplates.TaskStarted, data); ok {
+		println(LoggerStderr, line)
+		return
+	}
+
 	s := fmt.Sprintf("%s %s", taskString, startedString)
 
 	printf(
@@ -175,11 +206,18 @@ func PrintTaskFinally(taskName string) {
 }
 
 // PrintTaskCompleted prints when a task has completed.
-func PrintTaskCompleted(taskName string) {
+func PrintTaskCompleted(taskName string, elapsed time.Duration) {
 	if Verbosity <= VerbosityLevelNormal {
 		return
 	}
 
+	data := newTemplateData([]string{taskName})
+	data.Duration = elapsed
+	if line, ok := executeTemplate(CustomTemplates.TaskCompleted, data); ok {
+		println(LoggerStderr, line)
+		return
+	}
+
 	s := fmt.Sprintf("%s %s", taskString, completedString)
 
 	printf(
@@ -202,3 +240,121 @@ func PrintCommandError(err error) {
 		red(err.Error()),
 	)
 }
+
+// PrintCommandStderr prints the final lines of stderr from a failed command.
+// Since it is only printed when explicitly requested for commands whose
+// output was not shown, this is printed in silent mode as well.
+func PrintCommandStderr(lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+
+	f := red
+
+	LoggerStderr.Printf(
+		logFormat,
+		tag(stderrString, f),
+		fmt.Sprintf("last %d lines", len(lines)),
+	)
+
+	for _, line := range lines {
+		LoggerStderr.Printf(
+			"%s%s\n",
+			f(outputPrefix),
+			line,
+		)
+	}
+}
+
+// PrintCommandOutput prints the output of a failed command, which was held
+// back while only printing the summaries of a run.
+func PrintCommandOutput(command string, output []byte) {
+	if len(output) == 0 {
+		return
+	}
+
+	f := red
+
+	printf(
+		LoggerStderr,
+		logFormat,
+		tag(outputString, f),
+		command,
+	)
+
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		printf(
+			LoggerStderr,
+			"%s%s\n",
+			f(outputPrefix),
+			line,
+		)
+	}
+}
+
+// PrintCommandEnvironment prints the environment variables of a failed
+// command. Like the stderr of a failed command, this is printed in quiet mode
+// as well.
+func PrintCommandEnvironment(env []string) {
+	f := red
+
+	printf(
+		LoggerStderr,
+		logFormat,
+		tag(commandEnvString, f),
+		fmt.Sprintf("%d variables", len(env)),
+	)
+
+	for _, entry := range env {
+		printf(
+			LoggerStderr,
+			"%s%s\n",
+			f(outputPrefix),
+			entry,
+		)
+	}
+}
+
+// StepResult is the outcome of a single step of a task, for printing in a
+// summary.
+type StepResult struct {
+	Name    string
+	Err     error
+	Skipped bool
+}
+
+// PrintStepSummary prints whether each step of a task passed or failed.
+func PrintStepSummary(taskName string, results []StepResult) {
+	if !printsSummaries() {
+		return
+	}
+
+	f := blue
+
+	printf(
+		LoggerStderr,
+		logFormat,
+		tag(summaryString, f),
+		bold(taskName),
+	)
+
+	for _, result := range results {
+		status := green(passedString)
+		name := result.Name
+		switch {
+		case result.Err != nil:
+			status = red(failedString)
+			name = fmt.Sprintf("%s (%s)", name, result.Err)
+		case result.Skipped:
+			status = cyan(skippedStepString)
+		}
+
+		printf(
+			LoggerStderr,
+			"%s%s %s\n",
+			f(outputPrefix),
+			status,
+			name,
+		)
+	}
+}
@@ -1,24 +1,26 @@
 package ui
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 )
 
 var commandTests = []printTestCase{
 	{
-		`PrintCommand("echo hello", "foo", "bar")`,
+		`PrintCommand("echo hello", 1, "foo", "bar")`,
 		LoggerStderr,
-		func() { PrintCommand("echo hello", "foo", "bar") },
+		func() { PrintCommand("echo hello", 1, "foo", "bar") },
 		VerbosityLevelQuiet,
 		VerbosityLevelNormal,
 		"foo > bar $ echo hello\n",
 	},
 	{
-		`PrintCommandWithParenthetical("echo hello", "paren", "foo", "bar")`,
+		`PrintCommandWithParenthetical("echo hello", "paren", 1, "foo", "bar")`,
 		LoggerStderr,
-		func() { PrintCommandWithParenthetical("echo hello", "paren", "foo", "bar") },
+		func() { PrintCommandWithParenthetical("echo hello", "paren", 1, "foo", "bar") },
 		VerbosityLevelQuiet,
 		VerbosityLevelNormal,
 		"foo > bar (paren) $ echo hello\n",
@@ -84,9 +86,9 @@ var commandTests = []printTestCase{
 		"Task Finally: foo\n",
 	},
 	{
-		`PrintTaskCompleted("foo")`,
+		`PrintTaskCompleted("foo", time.Second)`,
 		LoggerStderr,
-		func() { PrintTaskCompleted("foo") },
+		func() { PrintTaskCompleted("foo", time.Second) },
 		VerbosityLevelNormal,
 		VerbosityLevelVerbose,
 		"Task Completed: foo\n",
@@ -99,6 +101,52 @@ var commandTests = []printTestCase{
 		VerbosityLevelNormal,
 		fmt.Sprintf("%s\n", "oops"),
 	},
+	{
+		`PrintCommandOutput("make", []byte("one\ntwo\n"))`,
+		LoggerStderr,
+		func() { PrintCommandOutput("make", []byte("one\ntwo\n")) },
+		VerbosityLevelSilent,
+		VerbosityLevelQuiet,
+		fmt.Sprintf(
+			"%s make\n%sone\n%stwo\n",
+			tag(outputString, red),
+			outputPrefix,
+			outputPrefix,
+		),
+	},
+	{
+		`PrintCommandEnvironment([]string{"A=one", "B=two"})`,
+		LoggerStderr,
+		func() { PrintCommandEnvironment([]string{"A=one", "B=two"}) },
+		VerbosityLevelSilent,
+		VerbosityLevelQuiet,
+		fmt.Sprintf(
+			"%s 2 variables\n%sA=one\n%sB=two\n",
+			tag(commandEnvString, red),
+			outputPrefix,
+			outputPrefix,
+		),
+	},
+	{
+		`PrintStepSummary("foo", results)`,
+		LoggerStderr,
+		func() {
+			PrintStepSummary("foo", []StepResult{
+				{Name: "lint"},
+				{Name: "test", Err: errors.New("exit status 2")},
+				{Name: "docs", Skipped: true},
+			})
+		},
+		VerbosityLevelQuiet,
+		VerbosityLevelNormal,
+		fmt.Sprintf(
+			"%s foo\n%spassed lint\n%sfailed test (exit status 2)\n%sskipped docs\n",
+			tag(summaryString, blue),
+			outputPrefix,
+			outputPrefix,
+			outputPrefix,
+		),
+	},
 }
 
 func TestCommandPrintFunctions(t *testing.T) {
@@ -106,3 +154,37 @@ func TestCommandPrintFunctions(t *testing.T) {
 		testPrint(t, tt)
 	}
 }
+
+func TestPrintCommandStderr(t *testing.T) {
+	defer resetUIState()
+
+	buf := new(bytes.Buffer)
+	LoggerStderr.SetOutput(buf)
+	Verbosity = VerbosityLevelSilent
+
+	PrintCommandStderr([]string{"one", "two"})
+
+	want := fmt.Sprintf(
+		"%s last 2 lines\n%sone\n%stwo\n",
+		tag(stderrString, red),
+		outputPrefix,
+		outputPrefix,
+	)
+	if got := buf.String(); got != want {
+		t.Errorf(`PrintCommandStderr() with verbosity silent: expected "%s", actual: "%s"`, want, got)
+	}
+}
+
+func TestPrintStepSummary_summary_only(t *testing.T) {
+	defer func() { SummaryOnly = false }()
+	SummaryOnly = true
+
+	testPrint(t, printTestCase{
+		`PrintStepSummary("foo", results)`,
+		LoggerStderr,
+		func() { PrintStepSummary("foo", []StepResult{{Name: "lint"}}) },
+		VerbosityLevelSilent,
+		VerbosityLevelQuiet,
+		fmt.Sprintf("%s foo\n%spassed lint\n", tag(summaryString, blue), outputPrefix),
+	})
+}
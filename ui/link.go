@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Hyperlinks allows file paths and urls to be printed as terminal hyperlinks.
+// Links are only printed when output is also colored.
+var Hyperlinks = false
+
+// hyperlinkTerminals are the values of TERM_PROGRAM for terminals known to
+// support hyperlinks.
+var hyperlinkTerminals = []string{"iTerm.app", "WezTerm", "vscode", "Hyper"}
+
+// DetectHyperlinks returns whether the terminal is known to support
+// hyperlinks. Setting FORCE_HYPERLINK to 1 or 0 overrides detection.
+func DetectHyperlinks() bool {
+	if force, ok := os.LookupEnv("FORCE_HYPERLINK"); ok {
+		enabled, err := strconv.ParseBool(force)
+		return err == nil && enabled
+	}
+
+	if color.NoColor {
+		return false
+	}
+
+	program := os.Getenv("TERM_PROGRAM")
+	for _, terminal := range hyperlinkTerminals {
+		if program == terminal {
+			return true
+		}
+	}
+
+	if vte, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && vte >= 5000 {
+		return true
+	}
+
+	return os.Getenv("WT_SESSION") != "" ||
+		os.Getenv("KITTY_WINDOW_ID") != "" ||
+		os.Getenv("DOMTERM") != ""
+}
+
+// Link returns text that opens the target when clicked, if hyperlinks are
+// enabled. Otherwise, the text is returned as-is.
+func Link(target, text string) string {
+	if !Hyperlinks || color.NoColor || Verbosity <= VerbosityLevelQuiet {
+		return text
+	}
+
+	return fmt.Sprintf("\x1b]8;;%s\x1b\\%s\x1b]8;;\x1b\\", target, text)
+}
+
+// LinkURL returns a url that can be clicked, if hyperlinks are enabled.
+func LinkURL(u string) string {
+	return Link(u, u)
+}
+
+// LinkPath returns a file path that can be clicked, if hyperlinks are enabled.
+// The path is printed as given, but the link always uses the absolute path.
+func LinkPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	host, _ := os.Hostname()
+	target := url.URL{
+		Scheme: "file",
+		Host:   host,
+		Path:   filepath.ToSlash(abs),
+	}
+	if !strings.HasPrefix(target.Path, "/") {
+		// Windows paths such as C:/ need a leading slash
+		target.Path = "/" + target.Path
+	}
+
+	return Link(target.String(), path)
+}
@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/env"
+)
+
+func TestLink(t *testing.T) {
+	defer func(noColor, hyperlinks bool, level VerbosityLevel) {
+		color.NoColor = noColor
+		Hyperlinks = hyperlinks
+		Verbosity = level
+	}(color.NoColor, Hyperlinks, Verbosity)
+
+	tests := []struct {
+		name       string
+		hyperlinks bool
+		noColor    bool
+		verbosity  VerbosityLevel
+		want       string
+	}{
+		{
+			name:       "enabled",
+			hyperlinks: true,
+			verbosity:  VerbosityLevelNormal,
+			want:       "\x1b]8;;https://example.com\x1b\\example\x1b]8;;\x1b\\",
+		},
+		{
+			name:      "disabled",
+			verbosity: VerbosityLevelNormal,
+			want:      "example",
+		},
+		{
+			name:       "no color",
+			hyperlinks: true,
+			noColor:    true,
+			verbosity:  VerbosityLevelNormal,
+			want:       "example",
+		},
+		{
+			name:       "quiet",
+			hyperlinks: true,
+			verbosity:  VerbosityLevelQuiet,
+			want:       "example",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Hyperlinks = tt.hyperlinks
+			color.NoColor = tt.noColor
+			Verbosity = tt.verbosity
+
+			assert.Equal(t, Link("https://example.com", "example"), tt.want)
+		})
+	}
+}
+
+func TestLinkPath(t *testing.T) {
+	defer func(noColor, hyperlinks bool) {
+		color.NoColor = noColor
+		Hyperlinks = hyperlinks
+	}(color.NoColor, Hyperlinks)
+
+	color.NoColor = false
+	Hyperlinks = true
+
+	wd, err := os.Getwd()
+	assert.NilError(t, err)
+
+	got := LinkPath("foo.log")
+	assert.Assert(t, strings.HasPrefix(got, "\x1b]8;;file://"), got)
+	assert.Assert(t, strings.Contains(got, wd+"/foo.log\x1b\\foo.log\x1b]8;;\x1b\\"), got)
+
+	Hyperlinks = false
+	assert.Equal(t, LinkPath("foo.log"), "foo.log")
+}
+
+func TestDetectHyperlinks(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+
+	keys := []string{
+		"FORCE_HYPERLINK", "TERM_PROGRAM", "VTE_VERSION",
+		"WT_SESSION", "KITTY_WINDOW_ID", "DOMTERM",
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		noColor bool
+		want    bool
+	}{
+		{name: "unknown terminal", want: false},
+		{name: "known terminal", env: map[string]string{"TERM_PROGRAM": "iTerm.app"}, want: true},
+		{name: "vte", env: map[string]string{"VTE_VERSION": "6003"}, want: true},
+		{name: "old vte", env: map[string]string{"VTE_VERSION": "4000"}, want: false},
+		{
+			name:    "no color",
+			env:     map[string]string{"TERM_PROGRAM": "iTerm.app"},
+			noColor: true,
+			want:    false,
+		},
+		{name: "forced", env: map[string]string{"FORCE_HYPERLINK": "1"}, noColor: true, want: true},
+		{
+			name: "forced off",
+			env:  map[string]string{"FORCE_HYPERLINK": "0", "TERM_PROGRAM": "iTerm.app"},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range keys {
+				defer env.Patch(t, key, "")()
+				os.Unsetenv(key) // nolint: errcheck
+			}
+			for key, value := range tt.env {
+				defer env.Patch(t, key, value)()
+			}
+			color.NoColor = tt.noColor
+
+			assert.Equal(t, DetectHyperlinks(), tt.want)
+		})
+	}
+}
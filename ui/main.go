@@ -43,6 +43,10 @@ var (
 	// Verbosity allows the verbosity of output to be set.
 	Verbosity = VerbosityLevelNormal
 
+	// SummaryOnly prints the summaries of a run in quiet mode, where the
+	// output of each step is otherwise held back.
+	SummaryOnly = false
+
 	// LoggerStdout is a logger that prints to stdout.
 	LoggerStdout = log.New(os.Stdout, "", 0)
 
@@ -12,6 +12,7 @@ func resetUIState() {
 	LoggerStderr.SetOutput(os.Stderr)
 	Verbosity = VerbosityLevelNormal
 	deprecations = nil
+	CustomTemplates = Templates{}
 }
 
 type printTestCase struct {
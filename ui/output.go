@@ -43,6 +43,25 @@ func Info(a ...interface{}) {
 	logInStyle(infoString, blue, a...)
 }
 
+// Summary prints the summary of a run. Unlike other info, this is printed in
+// quiet mode as well when only summaries are printed.
+func Summary(a ...interface{}) {
+	if !printsSummaries() {
+		return
+	}
+
+	logInStyle(infoString, blue, a...)
+}
+
+// printsSummaries returns whether the summaries of a run are printed.
+func printsSummaries() bool {
+	if SummaryOnly {
+		return Verbosity >= VerbosityLevelQuiet
+	}
+
+	return Verbosity > VerbosityLevelQuiet
+}
+
 // Warn prints an application warning.
 func Warn(a ...interface{}) {
 	if Verbosity <= VerbosityLevelQuiet {
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Templates are custom formats for the lines printed when tasks start and
+// complete and before each command. A nil template uses the built-in format.
+type Templates struct {
+	TaskStarted   *template.Template
+	TaskCompleted *template.Template
+	Command       *template.Template
+}
+
+// CustomTemplates are the templates used in place of the built-in formats.
+var CustomTemplates Templates
+
+// TemplateData is the data available to custom templates.
+type TemplateData struct {
+	// Task is the name of the task running.
+	Task string
+	// Tasks is the full list of task names, joined with " > ".
+	Tasks string
+	// Step is the position of the run item in its task, starting at 1.
+	Step int
+	// Duration is how long the task took, once it has completed.
+	Duration time.Duration
+	// Parenthetical is any additional information about how a command is run.
+	Parenthetical string
+}
+
+// newTemplateData creates template data for a list of task names.
+func newTemplateData(namespaces []string) TemplateData {
+	data := TemplateData{Tasks: strings.Join(namespaces, namespaceSeparator)}
+	if len(namespaces) > 0 {
+		data.Task = namespaces[len(namespaces)-1]
+	}
+
+	return data
+}
+
+// executeTemplate renders a template, returning false if it is not set.
+func executeTemplate(t *template.Template, data TemplateData) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", false
+	}
+
+	return buf.String(), true
+}
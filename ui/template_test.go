@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"testing"
+	"text/template"
+	"time"
+)
+
+func withTemplates(templates Templates, f func()) func() {
+	return func() {
+		defer func() { CustomTemplates = Templates{} }()
+		CustomTemplates = templates
+		f()
+	}
+}
+
+var templateTests = []printTestCase{
+	{
+		`PrintCommand("echo hello", 2, "foo", "bar") with template`,
+		LoggerStderr,
+		withTemplates(
+			Templates{Command: template.Must(template.New("").Parse(
+				"[{{.Step}}] {{.Task}} ({{.Tasks}}) >",
+			))},
+			func() { PrintCommand("echo hello", 2, "foo", "bar") },
+		),
+		VerbosityLevelQuiet,
+		VerbosityLevelNormal,
+		"[2] bar (foo > bar) > echo hello\n",
+	},
+	{
+		`PrintCommandWithParenthetical("echo hello", "paren", 1, "foo") with template`,
+		LoggerStderr,
+		withTemplates(
+			Templates{Command: template.Must(template.New("").Parse(
+				"{{.Task}} {{.Parenthetical}}:",
+			))},
+			func() { PrintCommandWithParenthetical("echo hello", "paren", 1, "foo") },
+		),
+		VerbosityLevelQuiet,
+		VerbosityLevelNormal,
+		"foo paren: echo hello\n",
+	},
+	{
+		`PrintTask("foo") with template`,
+		LoggerStderr,
+		withTemplates(
+			Templates{TaskStarted: template.Must(template.New("").Parse(
+				"==> {{.Task}}",
+			))},
+			func() { PrintTask("foo") },
+		),
+		VerbosityLevelNormal,
+		VerbosityLevelVerbose,
+		"==> foo\n",
+	},
+	{
+		`PrintTaskCompleted("foo", 1500*time.Millisecond) with template`,
+		LoggerStderr,
+		withTemplates(
+			Templates{TaskCompleted: template.Must(template.New("").Parse(
+				"<== {{.Task}} in {{.Duration}}",
+			))},
+			func() { PrintTaskCompleted("foo", 1500*time.Millisecond) },
+		),
+		VerbosityLevelNormal,
+		VerbosityLevelVerbose,
+		"<== foo in 1.5s\n",
+	},
+	{
+		`PrintTaskCompleted("foo", time.Second) with other template`,
+		LoggerStderr,
+		withTemplates(
+			Templates{TaskStarted: template.Must(template.New("").Parse("==> {{.Task}}"))},
+			func() { PrintTaskCompleted("foo", time.Second) },
+		),
+		VerbosityLevelNormal,
+		VerbosityLevelVerbose,
+		"Task Completed: foo\n",
+	},
+}
+
+func TestTemplatePrintFunctions(t *testing.T) {
+	for _, tt := range templateTests {
+		testPrint(t, tt)
+	}
+}